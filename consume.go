@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"tto_chromedp/pkg/apierror"
+	"tto_chromedp/pkg/jobsource"
+	"tto_chromedp/pkg/ledger"
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/metrics"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/shutdown"
+	"tto_chromedp/pkg/utils"
+)
+
+// runConsume crawls the jobs of source until the shutdown is requested or a finite source is
+// exhausted, and records them in one run ledger.
+//
+// A job whose crawl succeeded (the result sinks committed it) or was skipped for good is published on
+// the output topic and acked. A failed or partial crawl is retried until maxDeliveries, then
+// dead-lettered, as are the messages that cannot be decoded or whose profile does not exist. A job
+// interrupted by the shutdown is not acked, so the broker delivers it again.
+func runConsume(ctx context.Context, enrich *enricher, source jobsource.Source, runID string, maxDeliveries int) models.CrawlRun {
+	ctx = logging.With(ctx, logging.KEY_RUN_ID, runID, "source", source.Name())
+	logger := logging.FromContext(ctx)
+	outcomes := apierror.NewCounter()
+
+	runLedger := ledger.New(runID, "consume", enrich.profileName)
+	enrich.startRun(ctx, runLedger)
+	logger.Info("Consuming crawl jobs")
+
+	// Receive returns as soon as the shutdown is requested, the job in flight keeps ctx
+	receiveCtx, stopReceive := context.WithCancel(ctx)
+	defer stopReceive()
+	go func() {
+		select {
+		case <-enrich.shut.Requested():
+			stopReceive()
+		case <-receiveCtx.Done():
+		}
+	}()
+
+	runStatus := models.CrawlRunFinished
+	for {
+		delivery, err := source.Receive(receiveCtx)
+		if errors.Is(err, jobsource.ErrDone) {
+			break
+		}
+		if err != nil {
+			if receiveCtx.Err() == nil {
+				logger.Error("Failed to receive crawl job", "error", err)
+			}
+			runStatus = models.CrawlRunAborted
+			break
+		}
+		if interrupted := consumeJob(ctx, enrich, source, delivery, runLedger, outcomes, maxDeliveries); interrupted {
+			runStatus = models.CrawlRunAborted
+			break
+		}
+	}
+	return enrich.finishRun(ctx, runLedger, runStatus, outcomes)
+}
+
+// jobCrawler finds and crawls the profile of a job: the enricher, or a fake in the tests.
+type jobCrawler interface {
+	// jobProfile returns the profile of req. The error wraps sql.ErrNoRows when it does not exist.
+	jobProfile(ctx context.Context, req jobsource.Request) (*models.SocialProfile, error)
+	crawlProfile(ctx context.Context, kol models.SocialProfile, outcomes *apierror.Counter) (models.CrawlRunEntry, *models.TTOUser, error)
+}
+
+func (e *enricher) jobProfile(ctx context.Context, req jobsource.Request) (*models.SocialProfile, error) {
+	if req.SocialProfileID != 0 {
+		return e.socialProfileRepo.GetSocialProfileByID(ctx, req.SocialProfileID)
+	}
+	return e.socialProfileRepo.GetSocialProfileByUserName(ctx, utils.NormalizeHandle(req.Handle))
+}
+
+// consumeJob handles one delivery and returns true when its crawl was interrupted.
+func consumeJob(ctx context.Context, crawler jobCrawler, source jobsource.Source, delivery jobsource.Delivery, runLedger *ledger.Ledger, outcomes *apierror.Counter, maxDeliveries int) bool {
+	ctx = logging.With(ctx, "message_id", delivery.ID, "attempt", delivery.Attempt)
+	logger := logging.FromContext(ctx)
+	// Acks and dead letters go out even when a shutdown cancels ctx meanwhile
+	writeCtx, cancelWrite := shutdown.FlushContext(ctx)
+	defer cancelWrite()
+
+	deadLetter := func(reason string) {
+		logger.Warn("Dead-lettering crawl job", "reason", reason)
+		if err := source.DeadLetter(writeCtx, delivery, reason); err != nil {
+			logger.Error("Failed to dead-letter crawl job", "error", err)
+			return
+		}
+		metrics.SourceMessage(source.Name(), metrics.SOURCE_DEAD_LETTERED)
+	}
+
+	req, err := delivery.Decode()
+	if err != nil {
+		deadLetter(err.Error())
+		return false
+	}
+	profile, err := crawler.jobProfile(ctx, req)
+	if errors.Is(err, sql.ErrNoRows) {
+		deadLetter("social profile not found")
+		return false
+	}
+	if err != nil {
+		metrics.DBError(metrics.DB_POSTGRES, "get_social_profile")
+		logger.Error("Failed to resolve social profile, retrying later", "error", err)
+		retryJob(writeCtx, source, delivery)
+		return false
+	}
+
+	entry, user, err := crawler.crawlProfile(ctx, *profile, outcomes)
+	runLedger.Record(entry)
+	if err != nil {
+		logger.Warn("Crawl job interrupted, leaving it unacked", "error", err)
+		return true
+	}
+
+	switch entry.Outcome {
+	case models.CrawlOutcomeSuccess, models.CrawlOutcomeSkipped:
+		result := jobsource.Result{
+			MessageID:   delivery.ID,
+			Request:     req,
+			Profile:     *profile,
+			Outcome:     entry.Outcome,
+			Entry:       entry,
+			TTOUser:     user,
+			PublishedAt: time.Now(),
+		}
+		if err := source.Publish(writeCtx, result); err != nil {
+			logger.Error("Failed to publish crawl result, retrying later", "error", err)
+			retryJob(writeCtx, source, delivery)
+			return false
+		}
+		if err := source.Ack(writeCtx, delivery); err != nil {
+			logger.Error("Failed to ack crawl job", "error", err)
+			return false
+		}
+		metrics.SourceMessage(source.Name(), metrics.SOURCE_ACKED)
+	default:
+		if delivery.Attempt >= maxDeliveries {
+			reason := entry.Outcome
+			if entry.Error != "" {
+				reason += ": " + entry.Error
+			}
+			deadLetter(reason)
+			return false
+		}
+		retryJob(writeCtx, source, delivery)
+	}
+	return false
+}
+
+func retryJob(ctx context.Context, source jobsource.Source, delivery jobsource.Delivery) {
+	if err := source.Retry(ctx, delivery); err != nil {
+		logging.FromContext(ctx).Error("Failed to retry crawl job", "error", err)
+		return
+	}
+	metrics.SourceMessage(source.Name(), metrics.SOURCE_RETRIED)
+}
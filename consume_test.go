@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"tto_chromedp/pkg/apierror"
+	"tto_chromedp/pkg/jobsource"
+	"tto_chromedp/pkg/ledger"
+	"tto_chromedp/pkg/models"
+)
+
+// fakeCrawler plays scripted outcomes per profile and attempt. A success "commits" the result, as
+// the result sinks do, before consumeJob gets to publish and ack it.
+type fakeCrawler struct {
+	profiles  map[string]models.SocialProfile
+	lookupErr error
+	// outcomes[id][n] is the outcome of the n-th crawl of the profile; the last one repeats
+	outcomes map[int][]string
+	events   *eventLog
+
+	crawls map[int]int
+}
+
+func (c *fakeCrawler) jobProfile(ctx context.Context, req jobsource.Request) (*models.SocialProfile, error) {
+	if c.lookupErr != nil {
+		return nil, c.lookupErr
+	}
+	profile, ok := c.profiles[req.Handle]
+	if !ok {
+		return nil, fmt.Errorf("no profile %s: %w", req.Handle, sql.ErrNoRows)
+	}
+	return &profile, nil
+}
+
+func (c *fakeCrawler) crawlProfile(ctx context.Context, kol models.SocialProfile, outcomes *apierror.Counter) (models.CrawlRunEntry, *models.TTOUser, error) {
+	script := c.outcomes[kol.ID]
+	n := c.crawls[kol.ID]
+	c.crawls[kol.ID]++
+	outcome := script[len(script)-1]
+	if n < len(script) {
+		outcome = script[n]
+	}
+
+	entry := models.CrawlRunEntry{SocialProfileID: kol.ID, UserName: kol.UserName, Outcome: outcome}
+	switch outcome {
+	case models.CrawlOutcomeSuccess:
+		c.events.add("commit", kol.ID)
+		return entry, &models.TTOUser{}, nil
+	case models.CrawlOutcomeAborted:
+		return entry, nil, errors.New("crawl interrupted")
+	default:
+		entry.Error = "no creator card responses captured"
+		return entry, nil, nil
+	}
+}
+
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *eventLog) add(event string, id any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, fmt.Sprintf("%s:%v", event, id))
+}
+
+func (l *eventLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.events, " ")
+}
+
+// recordingSource logs the publish and ack of the Memory source it wraps.
+type recordingSource struct {
+	*jobsource.Memory
+	events *eventLog
+}
+
+func (s *recordingSource) Publish(ctx context.Context, result jobsource.Result) error {
+	s.events.add("publish", result.Profile.ID)
+	return s.Memory.Publish(ctx, result)
+}
+
+func (s *recordingSource) Ack(ctx context.Context, d jobsource.Delivery) error {
+	s.events.add("ack", d.ID)
+	return s.Memory.Ack(ctx, d)
+}
+
+func newConsumeTest(outcomes map[int][]string) (*fakeCrawler, *recordingSource) {
+	events := &eventLog{}
+	crawler := &fakeCrawler{
+		profiles: map[string]models.SocialProfile{
+			"alice": {ID: 1, UserName: "alice"},
+			"bob":   {ID: 2, UserName: "bob"},
+		},
+		outcomes: outcomes,
+		events:   events,
+		crawls:   make(map[int]int),
+	}
+	return crawler, &recordingSource{Memory: jobsource.NewMemory(), events: events}
+}
+
+// drain consumes the source until it is exhausted, or a crawl is interrupted.
+func drain(t *testing.T, crawler jobCrawler, source jobsource.Source, maxDeliveries int) bool {
+	t.Helper()
+	ctx := context.Background()
+	runLedger := ledger.New("test", "consume", "tto")
+	outcomes := apierror.NewCounter()
+	for {
+		delivery, err := source.Receive(ctx)
+		if errors.Is(err, jobsource.ErrDone) {
+			return false
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if consumeJob(ctx, crawler, source, delivery, runLedger, outcomes, maxDeliveries) {
+			return true
+		}
+	}
+}
+
+func TestConsumeAcksAfterCommit(t *testing.T) {
+	crawler, source := newConsumeTest(map[int][]string{1: {models.CrawlOutcomeSuccess}})
+	source.Push(jobsource.Request{Handle: "alice"})
+	source.CloseInput()
+
+	if drain(t, crawler, source, 3) {
+		t.Fatal("unexpected interruption")
+	}
+	if got, want := source.events.String(), "commit:1 publish:1 ack:1"; got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	if published := source.Published(); len(published) != 1 || published[0].Outcome != models.CrawlOutcomeSuccess || published[0].TTOUser == nil {
+		t.Errorf("published = %+v", published)
+	}
+	if len(source.DeadLetters()) != 0 {
+		t.Errorf("dead letters = %+v", source.DeadLetters())
+	}
+}
+
+func TestConsumeRetriesThenDeadLetters(t *testing.T) {
+	crawler, source := newConsumeTest(map[int][]string{
+		1: {models.CrawlOutcomeFailed},
+		2: {models.CrawlOutcomePartial, models.CrawlOutcomeSuccess},
+	})
+	source.Push(jobsource.Request{Handle: "alice"})
+	source.Push(jobsource.Request{Handle: "bob"})
+	source.CloseInput()
+
+	if drain(t, crawler, source, 3) {
+		t.Fatal("unexpected interruption")
+	}
+
+	// alice fails on every delivery: crawled maxDeliveries times, then dead-lettered, never acked
+	if crawler.crawls[1] != 3 {
+		t.Errorf("alice crawled %d times, want 3", crawler.crawls[1])
+	}
+	deadLetters := source.DeadLetters()
+	if len(deadLetters) != 1 || deadLetters[0].Attempt != 3 || !strings.HasPrefix(deadLetters[0].Reason, models.CrawlOutcomeFailed) {
+		t.Fatalf("dead letters = %+v", deadLetters)
+	}
+
+	// bob succeeds on the retry
+	if crawler.crawls[2] != 2 {
+		t.Errorf("bob crawled %d times, want 2", crawler.crawls[2])
+	}
+	acked := source.Acked()
+	if len(acked) != 1 || acked[0].Attempt != 2 {
+		t.Errorf("acked = %+v", acked)
+	}
+	if strings.Contains(source.events.String(), "commit:1") {
+		t.Errorf("alice was committed: %s", source.events)
+	}
+}
+
+func TestConsumeDeadLettersInvalidJobs(t *testing.T) {
+	crawler, source := newConsumeTest(nil)
+	source.PushBody([]byte("not json"))
+	source.Push(jobsource.Request{Handle: "carol"})
+	source.CloseInput()
+
+	drain(t, crawler, source, 3)
+	deadLetters := source.DeadLetters()
+	if len(deadLetters) != 2 {
+		t.Fatalf("dead letters = %+v", deadLetters)
+	}
+	if deadLetters[1].Reason != "social profile not found" {
+		t.Errorf("reason = %q", deadLetters[1].Reason)
+	}
+	if len(crawler.crawls) != 0 || len(source.Acked()) != 0 {
+		t.Errorf("crawls = %v, acked = %v", crawler.crawls, source.Acked())
+	}
+}
+
+func TestConsumeRetriesLookupErrors(t *testing.T) {
+	crawler, source := newConsumeTest(nil)
+	crawler.lookupErr = errors.New("connection refused")
+	source.Push(jobsource.Request{Handle: "alice"})
+
+	ctx := context.Background()
+	delivery, err := source.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumeJob(ctx, crawler, source, delivery, ledger.New("test", "consume", "tto"), apierror.NewCounter(), 3)
+
+	// A database error is not the job's fault: it goes back to the queue, without a dead letter
+	crawler.lookupErr = nil
+	source.CloseInput()
+	retried, err := source.Receive(ctx)
+	if err != nil || retried.Attempt != 2 {
+		t.Fatalf("retried delivery = %+v, %v", retried, err)
+	}
+	if len(source.DeadLetters()) != 0 {
+		t.Errorf("dead letters = %+v", source.DeadLetters())
+	}
+}
+
+func TestConsumeLeavesInterruptedJobsUnacked(t *testing.T) {
+	crawler, source := newConsumeTest(map[int][]string{1: {models.CrawlOutcomeAborted}})
+	source.Push(jobsource.Request{Handle: "alice"})
+	source.CloseInput()
+
+	if !drain(t, crawler, source, 3) {
+		t.Fatal("expected the interruption to stop the consumer")
+	}
+	if len(source.Acked()) != 0 || len(source.DeadLetters()) != 0 || len(source.Published()) != 0 {
+		t.Errorf("acked = %v, dead letters = %v, published = %v", source.Acked(), source.DeadLetters(), source.Published())
+	}
+}
@@ -3,19 +3,39 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
-	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"tto_chromedp/pkg/apierror"
+	"tto_chromedp/pkg/assets"
+	"tto_chromedp/pkg/changes"
+	"tto_chromedp/pkg/checkpoint"
+	"tto_chromedp/pkg/chromeprofile"
+	"tto_chromedp/pkg/distribution"
+	"tto_chromedp/pkg/growth"
+	"tto_chromedp/pkg/interest"
+	"tto_chromedp/pkg/jobsource"
+	"tto_chromedp/pkg/kpi"
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/metrics"
 	"tto_chromedp/pkg/models"
 	"tto_chromedp/pkg/mongodb"
 	"tto_chromedp/pkg/postgre"
+	"tto_chromedp/pkg/ratelimit"
+	"tto_chromedp/pkg/region"
+	"tto_chromedp/pkg/shutdown"
+	"tto_chromedp/pkg/sink"
+	"tto_chromedp/pkg/taxonomy"
+	"tto_chromedp/pkg/tto/api"
 	"tto_chromedp/pkg/utils"
 
 	"github.com/chromedp/cdproto/cdp"
@@ -24,12 +44,14 @@ import (
 	"github.com/chromedp/cdproto/target"
 	"github.com/chromedp/chromedp"
 	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // Constants and Configuration based on user's request and best practices.
 const (
-	PARTNER_TIKSHOP_HOME_URL = "https://ads.tiktok.com/creative/creator/explore?region=row&from_creative=login" // Replace with actual home URL
-	TARGET_PAGE              = PARTNER_TIKSHOP_HOME_URL                                                         // The page where the search actually happens
+	PARTNER_TIKSHOP_HOME_URL    = "https://ads.tiktok.com/creative/creator/explore?region=row&from_creative=login" // Replace with actual home URL
+	TARGET_PAGE                 = PARTNER_TIKSHOP_HOME_URL                                                         // The page where the search actually happens
+	PARTNER_TIKSHOP_EXPLORE_URL = "https://ads.tiktok.com/creative/creator/explore?region=%s&from_creative=login"  // Explore page for a given market region
 
 	NAME_SEARCH_ELEM   = "//span[text()='Name search']"
 	INPUT_SEARCH_ELEM  = `input:is([placeholder="Enter username or nickname"], [placeholder="Search names, products, hashtags, or keywords"])`
@@ -43,7 +65,15 @@ const (
 	CREATOR_NAME_ELEM = ".text-black" // Assuming the creator's name is in a black text element
 	NO_RESULTS_TEXT   = "No results found"
 
+	// Number of search result rows inspected when looking for the exact creator
+	SEARCH_DISAMBIGUATION_ROWS = 20
+
 	DEFAULT_USER_AGENT = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36"
+
+	// A profile whose crawl failed waits DEFAULT_FAILURE_BACKOFF, doubled at each consecutive failure,
+	// and is skipped after DEFAULT_MAX_CRAWL_FAILURES of them
+	DEFAULT_FAILURE_BACKOFF    = 30 * time.Minute
+	DEFAULT_MAX_CRAWL_FAILURES = 5
 )
 
 // KolData represents a single KOL entry from the input list
@@ -52,219 +82,36 @@ type KolData struct {
 	Username string `json:"username"`
 }
 
-// TTOCreatorResponse represents the structure of the response from the TikTok Creator API.
-type TTOCreatorResponse struct {
-	BaseResp struct {
-		StatusCode    int    `json:"StatusCode"`
-		StatusMessage string `json:"StatusMessage"`
-	} `json:"baseResp"`
-	Creators []struct {
-		AioCreatorID   string         `json:"aioCreatorID"`
-		ContentLabels  []ContentLabel `json:"contentLabels"`
-		CreatorProfile struct {
-			Price struct {
-			} `json:"price"`
-			SpokenLanguageList []string `json:"spokenLanguageList"`
-		} `json:"creatorProfile"`
-		CreatorTTInfo struct {
-			AdCreativeClass int    `json:"adCreativeClass"`
-			AioCreatorID    string `json:"aioCreatorID"`
-			AvatarURI       string `json:"avatarURI"`
-			AvatarURL       string `json:"avatarURL"`
-			AvatarURLList   []struct {
-				Format   string `json:"format"`
-				ImageURL string `json:"imageUrl"`
-			} `json:"avatarURLList"`
-			Bio                 string `json:"bio"`
-			BrandedContentClass int    `json:"brandedContentClass"`
-			Categories          []int  `json:"categories"`
-			CreditScore         struct {
-				AioCreatorID    string `json:"aioCreatorID"`
-				CurrentScore    int    `json:"currentScore"`
-				CurrentTier     int    `json:"currentTier"`
-				ScoreLowerLimit int    `json:"scoreLowerLimit"`
-				ScoreUpperLimit int    `json:"scoreUpperLimit"`
-			} `json:"creditScore"`
-			DataVDCRegion   int    `json:"dataVDCRegion"`
-			DisplayStatus   int    `json:"displayStatus"`
-			FollowerCnt     int    `json:"followerCnt"`
-			HandleName      string `json:"handleName"`
-			IsBannedInTT    bool   `json:"isBannedInTT"`
-			IsRegisteredAIO bool   `json:"isRegisteredAIO"`
-			IsTest          bool   `json:"isTest"`
-			LivingRegion    string `json:"livingRegion"`
-			NickName        string `json:"nickName"`
-			RiskInfo        struct {
-				CreatorID string `json:"creatorID"`
-			} `json:"riskInfo"`
-			StoreRegion string `json:"storeRegion"`
-			TtUID       string `json:"ttUID"`
-		} `json:"creatorTTInfo"`
-		CreatorType int `json:"creatorType"`
-		CreditScore struct {
-			AioCreatorID    string `json:"aioCreatorID"`
-			CurrentScore    int    `json:"currentScore"`
-			CurrentTier     int    `json:"currentTier"`
-			ScoreLowerLimit int    `json:"scoreLowerLimit"`
-			ScoreUpperLimit int    `json:"scoreUpperLimit"`
-		} `json:"creditScore"`
-		DisplayType int `json:"displayType"`
-		EsData      struct {
-			AppearOnSearchSetting bool  `json:"appearOnSearchSetting"`
-			Categories            []int `json:"categories"`
-			Price                 struct {
-				Currency                    string `json:"currency"`
-				RecommendRate100K           string `json:"recommendRate100k"`
-				StartingRate100K            string `json:"startingRate100k"`
-				StoreRegionCurrency         string `json:"storeRegionCurrency"`
-				StoreRegionStartingRate100K string `json:"storeRegionStartingRate100k"`
-			} `json:"price"`
-			Status int `json:"status"`
-		} `json:"esData"`
-		IndustryLabels []struct {
-			LabelID   string `json:"labelID"`
-			LabelName string `json:"labelName"`
-		} `json:"industryLabels"`
-		IsCarveOut  bool        `json:"isCarveOut"`
-		PriceIndex  int         `json:"priceIndex"`
-		RecentItems []VideoItem `json:"recentItems"`
-		RiskInfo    struct {
-			CreatorID string `json:"creatorID"`
-		} `json:"riskInfo"`
-		StatisticData struct {
-			Algo struct {
-				ContentLanguage []string `json:"contentLanguage"`
-			} `json:"algo"`
-			FollowerCountHistory struct {
-				FollowerCount      []FollowerTrend `json:"followerCount"`
-				FollowerGrowthRate []struct {
-					Date string  `json:"date"`
-					Rate float64 `json:"rate"`
-				} `json:"followerGrowthRate"`
-			} `json:"followerCountHistory"`
-			FollowerDistriData struct {
-				Active []struct {
-					Active string  `json:"active"`
-					Ratio  float64 `json:"ratio"`
-				} `json:"active"`
-				Age         []AgeDistri `json:"age"`
-				DeviceBrand []struct {
-					DeviceBrand string  `json:"deviceBrand"`
-					Ratio       float64 `json:"ratio"`
-				} `json:"deviceBrand"`
-				Gender []GenderDistri `json:"gender"`
-				Region []RegionDistri `json:"region"`
-			} `json:"followerDistriData"`
-			OverallPerformance struct {
-				AvgSixSecondsViewsBenchMarkViews float64 `json:"avgSixSecondsViewsBenchMarkViews"`
-				AvgSixSecondsViewsRate           float64 `json:"avgSixSecondsViewsRate"`
-				AvgSixSecondsViewsRateRank       float64 `json:"avgSixSecondsViewsRateRank"`
-				EngagementRate                   float64 `json:"engagementRate"`
-				EngagementRateBenchMark          float64 `json:"engagementRateBenchMark"`
-				EngagementRateRank               float64 `json:"engagementRateRank"`
-				FollowerCount                    int     `json:"followerCount"`
-				FollowerTier                     int     `json:"followerTier"`
-				FollowersGrowthRate              float64 `json:"followersGrowthRate"`
-				FollowersGrowthRateRank          float64 `json:"followersGrowthRateRank"`
-				MedianBenchMarkViews             int     `json:"medianBenchMarkViews"`
-				MedianViews                      int     `json:"medianViews"`
-				MedianViewsRank                  float64 `json:"medianViewsRank"`
-				VideoCompleteRate                float64 `json:"videoCompleteRate"`
-				VideoCompleteRateRank            float64 `json:"videoCompleteRateRank"`
-			} `json:"overallPerformance"`
-			TtBasicInfo struct {
-				AppLanguage []string `json:"appLanguage"`
-			} `json:"ttBasicInfo"`
-			VideoPerformance struct {
-				PopularVideos []struct {
-					Comment      int    `json:"comment"`
-					CoverURL     string `json:"coverURL"`
-					CoverURLList []struct {
-						Format   string `json:"format"`
-						ImageURL string `json:"imageUrl"`
-					} `json:"coverURLList"`
-					CreateTime       string `json:"createTime"`
-					Heart            int    `json:"heart"`
-					IsBoosted        bool   `json:"isBoosted"`
-					IsSponsoredVideo bool   `json:"isSponsoredVideo"`
-					ItemID           string `json:"itemID"`
-					Share            int    `json:"share"`
-					Title            string `json:"title"`
-					VideoURL         string `json:"videoURL"`
-					Views            string `json:"views"`
-				} `json:"popularVideos"`
-				RecentVideos []VideoItem `json:"recentVideos"`
-			} `json:"videoPerformance"`
-		} `json:"statisticData"`
-		TtUID string `json:"ttUID"`
-	} `json:"creators"`
-}
-
-type TTOUser struct {
-	CategoryContent []map[string]interface{}    `json:"content_interest"`
-	AgeDistri       []map[string]interface{}    `json:"audience_age"`
-	RegionDistri    []map[string]interface{}    `json:"audience_location"`
-	GenderDistri    []map[string]interface{}    `json:"audience_gender"`
-	KolGrowth       map[string][]map[string]int `json:"kol_growth"`
-}
-
-type AgeDistri struct {
-	AgeInterval string  `json:"ageInterval"`
-	Ratio       float64 `json:"ratio"`
-}
-
-type GenderDistri struct {
-	Gender string  `json:"gender"`
-	Ratio  float64 `json:"ratio"`
-}
-
-type RegionDistri struct {
-	Country string  `json:"country"`
-	Ratio   float64 `json:"ratio"`
-}
-
-type FollowerTrend struct {
-	Count int    `json:"count"`
-	Date  string `json:"date"`
-}
-
-type ContentLabel struct {
-	LabelID   string `json:"labelID"`
-	LabelName string `json:"labelName"`
-}
-
-type VideoItem struct {
-	Comment      int    `json:"comment"`
-	CoverURL     string `json:"coverURL"`
-	CoverURLList []struct {
-		Format   string `json:"format"`
-		ImageURL string `json:"imageUrl"`
-	} `json:"coverURLList"`
-	CreateTime       string `json:"createTime"`
-	Heart            int    `json:"heart"`
-	IsBoosted        bool   `json:"isBoosted"`
-	IsSponsoredVideo bool   `json:"isSponsoredVideo"`
-	ItemID           string `json:"itemID"`
-	Share            int    `json:"share"`
-	Title            string `json:"title"`
-	VideoURL         string `json:"videoURL"`
-	Views            string `json:"views"`
-}
-
 // CollectedData holds the information captured from a matching network response.
 type CollectedData struct {
-	URL    string              `json:"url"`
-	Status int                 `json:"status"`
-	Body   *TTOCreatorResponse `json:"body,omitempty"`
+	URL    string        `json:"url"`
+	Status int           `json:"status"`
+	Body   *api.Response `json:"body,omitempty"`
+	// Assets are the stored images of the creator in Body, set on one entry per crawl
+	Assets *models.MediaAssets `json:"-"`
 }
 
 // --- New Tab Processing Logic (Conversion of _process_single_kol) ---
 
+// matchResultCard returns the result card whose creator name equals kolName, ignoring case and a leading "@".
+func matchResultCard(cards []ResultCard, kolName string) (ResultCard, bool) {
+	want := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(kolName), "@"))
+	for _, card := range cards {
+		if strings.ToLower(strings.TrimPrefix(strings.TrimSpace(card.Name), "@")) == want {
+			return card, true
+		}
+	}
+	return ResultCard{}, false
+}
+
 // processSingleKol performs the search, clicks the creator link, and captures network data in the new tab.
+// Every search and navigation goes through the limiter under the given account.
 func processSingleKol(
 	ctx context.Context,
 	kolName string,
 	urlPattern string,
+	limiter *ratelimit.Limiter,
+	account string,
 ) (string, []CollectedData, error) {
 
 	// Slice to hold network data collected by the listener
@@ -274,7 +121,14 @@ func processSingleKol(
 	kolCtx, cancel := context.WithTimeout(ctx, 180*time.Second)
 	defer cancel()
 
+	// The stage timer is swapped from search to detail once the matching row is found
+	stopStage := metrics.StageTimer(metrics.STAGE_SEARCH)
+	defer func() { stopStage() }()
+
 	// --- Step 1: Search and Wait for Results ---
+	if err := limiter.Wait(kolCtx, account, ratelimit.ActionSearch); err != nil {
+		return kolName, nil, fmt.Errorf("rate limiter refused search: %w", err)
+	}
 	err := chromedp.Run(kolCtx,
 		chromedp.Sleep(3*time.Second),
 		chromedp.WaitVisible(NAME_SEARCH_ELEM, chromedp.BySearch),
@@ -298,29 +152,33 @@ func processSingleKol(
 	if err != nil {
 		return kolName, nil, fmt.Errorf("search failed: %w", err)
 	}
-	log.Println("Search results table visible. Checking content...")
+	logger := logging.Stage(ctx, "search")
+	logger.Debug("Search results table visible, checking content")
 
 	// --- Step 2: Validate Search Result and Prepare Click Target ---
-	var firstCardContent string
-	var creatorNameText string
-
-	// Get content and creator name from the first result card
-	err = chromedp.Run(kolCtx,
-		// Get the full HTML content of the first result card
-		chromedp.WaitVisible(fmt.Sprintf("%s", SEARCH_RESULTS_BODY), chromedp.ByQuery),
-		chromedp.Sleep(1*time.Second),
-		// Get the specific creator name element text
-		chromedp.Text(fmt.Sprintf("%s div div %s", SECTION_LOCATOR, CREATOR_NAME_ELEM), &creatorNameText, chromedp.ByQuery),
-	)
+	// Name search can return several similar creators, so walk the result rows and pick the exact match.
+	scroller := NewResultsScroller(kolCtx, urlPattern)
+	cards, err := scroller.Collect(SEARCH_DISAMBIGUATION_ROWS)
 	if err != nil {
 		return kolName, nil, fmt.Errorf("failed to retrieve search result content: %w", err)
 	}
 
-	if strings.Contains(firstCardContent, NO_RESULTS_TEXT) || strings.TrimSpace(creatorNameText) != kolName {
-		log.Printf("No results found or name mismatch: Expected '%s', Found '%s'", kolName, creatorNameText)
+	match, ok := matchResultCard(cards, kolName)
+	if !ok {
+		found := make([]string, 0, len(cards))
+		for _, card := range cards {
+			found = append(found, card.Name)
+		}
+		logger.Info("No results found or name mismatch", "expected", kolName, "found", found)
 		return kolName, collectedData, nil
 	}
-	log.Printf("Found matching creator: %s. Proceeding to click.", creatorNameText)
+	if err := scroller.Reveal(match.DataIndex); err != nil {
+		return kolName, nil, fmt.Errorf("failed to scroll to matching creator: %w", err)
+	}
+	logger.Info("Found matching creator, opening the detail tab", "name", match.Name, "row", match.DataIndex)
+	logger = logging.Stage(ctx, "detail")
+	stopStage()
+	stopStage = metrics.StageTimer(metrics.STAGE_DETAIL)
 
 	// --- Step 3: Click and Capture New Tab ---
 
@@ -339,7 +197,12 @@ func processSingleKol(
 	})
 
 	// The click task runs concurrently with the listener
-	clickTask := chromedp.Click(fmt.Sprintf("%s div div %s", SECTION_LOCATOR, CREATOR_NAME_ELEM), chromedp.ByQuery)
+	clickTask := chromedp.Click(CardNameSelector(match.DataIndex), chromedp.ByQuery)
+
+	// Opening the detail tab loads the creator card API, so it counts as a navigation
+	if err := limiter.Wait(kolCtx, account, ratelimit.ActionNavigate); err != nil {
+		return kolName, nil, fmt.Errorf("rate limiter refused detail navigation: %w", err)
+	}
 
 	// Perform the click, which triggers the new target event
 	if err := chromedp.Run(kolCtx, clickTask); err != nil {
@@ -349,7 +212,7 @@ func processSingleKol(
 	var newTargetID target.ID
 	select {
 	case newTargetID = <-targetCh:
-		log.Printf("SUCCESS: Captured new target ID: %s", newTargetID)
+		logger.Debug("Captured new target", "target_id", newTargetID)
 	case <-time.After(15 * time.Second):
 		return kolName, nil, fmt.Errorf("timed out waiting for new tab target event")
 	}
@@ -359,7 +222,11 @@ func processSingleKol(
 	// Create a new context attached to the new tab.
 	// NOTE: We pass the main Allocator Context (ctx) so the new tab is part of the same browser instance.
 	newTabCtx, cancelNewTab := chromedp.NewContext(ctx, chromedp.WithTargetID(newTargetID))
-	defer cancelNewTab()
+	metrics.TabOpened()
+	defer func() {
+		cancelNewTab()
+		metrics.TabClosed()
+	}()
 
 	var wg sync.WaitGroup
 	// Start network listener on the new tab's context
@@ -369,7 +236,12 @@ func processSingleKol(
 				return
 			}
 			resp := ev.Response
-			log.Printf("[NEW TAB RESPONSE] Captured %s (Status: %d): %s", resp.URL, resp.Status, ev.RequestID)
+			logger.Debug("Captured creator card response", "url", resp.URL, "http_status", resp.Status, "request_id", ev.RequestID)
+			if resp.Status == http.StatusTooManyRequests {
+				metrics.ResponseThrottled()
+				limiter.ReportThrottled(ctx, account, int(resp.Status), 0)
+				return
+			}
 
 			wg.Add(1) // Increment counter before spawning goroutine
 			// This goroutine is necessary because GetResponseBody blocks,
@@ -381,19 +253,24 @@ func processSingleKol(
 				body, err := network.GetResponseBody(ev.RequestID).Do(cdp.WithExecutor(newTabCtx, c.Target))
 				// body, err := network.GetResponseBody(ev.RequestID).Do(newTabCtx)
 				if err != nil {
-					log.Printf("Error getting response body for %s: %v", ev.Response.URL, err)
+					logger.Error("Failed to get response body", "url", ev.Response.URL, "error", err)
 					return
 				}
-				fmt.Println("body response from url------------, ", resp.URL)
 
-				var ttoResp TTOCreatorResponse
+				var ttoResp api.Response
 				if err := json.Unmarshal(body, &ttoResp); err != nil {
-					log.Printf("Error unmarshalling response for %s: %v", ev.Response.URL, err)
+					logger.Error("Failed to unmarshal response", "url", ev.Response.URL, "error", err)
 					return
 				}
+				if unknown := ttoResp.UnknownFieldPaths(); len(unknown) > 0 {
+					logger.Warn("Response has fields not modelled in api.Response", "url", resp.URL, "fields", unknown)
+				}
+				metrics.ResponseCaptured(ttoResp.BaseResp.StatusCode)
+				// The limiter hears about every throttled response here, and only here
+				reportToLimiter(ctx, limiter, account, int(resp.Status), ttoResp.BaseResp)
 
 				collectedData = append(collectedData, CollectedData{URL: ev.Response.URL, Status: int(ev.Response.Status), Body: &ttoResp})
-				log.Printf("[NEW TAB RESPONSE] Captured and unmarshalled %s (Status: %d)", ev.Response.URL, ev.Response.Status)
+				logger.Info("Captured and unmarshalled creator card", "url", ev.Response.URL, "http_status", ev.Response.Status)
 			}()
 		}
 	})
@@ -406,8 +283,8 @@ func processSingleKol(
 		chromedp.Sleep(5*time.Second), // Wait for async data load (Playwright's 100000ms equivalent, but reduced)
 		// Refresh the page as requested
 		chromedp.ActionFunc(func(ctx context.Context) error {
-			log.Println("Refreshing the page...")
-			return nil
+			logger.Debug("Refreshing the detail tab")
+			return limiter.Wait(ctx, account, ratelimit.ActionNavigate)
 		}),
 		chromedp.Reload(),
 		chromedp.Sleep(5*time.Second), // Wait again after refresh
@@ -420,184 +297,594 @@ func processSingleKol(
 
 	// Close the new tab's target
 	if err := chromedp.Run(newTabCtx, target.DetachFromTarget()); err != nil {
-		log.Printf("Warning: Failed to detach/close new tab: %v", err)
+		logger.Warn("Failed to detach/close new tab", "error", err)
 	}
-	log.Printf("New tab closed. Total captured responses: %d", len(collectedData))
+	logger.Info("New tab closed", "responses", len(collectedData))
 
 	return kolName, collectedData, nil
 }
 
+// newBrowserTab starts a browser on the given profile and returns its first tab with the desktop
+// viewport and VN locale/timezone emulation applied. The tab context inherits the values (logger) of
+// ctx, and the browser is closed when ctx is cancelled. The returned cancel func closes the browser.
+func newBrowserTab(ctx context.Context, profileName string, headless bool, userAgent string) (context.Context, context.CancelFunc, error) {
+	if err := unlockProfile(ctx, profileName); err != nil {
+		return nil, nil, err
+	}
+	opts := initChromedpOptions(ctx, profileName, headless, userAgent)
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, opts...)
+
+	tabCtx, cancelTab := chromedp.NewContext(allocCtx)
+	metrics.TabOpened()
+	cancel := func() {
+		cancelTab()
+		cancelAlloc()
+		metrics.TabClosed()
+	}
+
+	if err := chromedp.Run(tabCtx,
+		// Set a common desktop viewport size
+		chromedp.EmulateViewport(1920, 1080),
+		// Set locale/timezone for extra realism
+		emulation.SetTimezoneOverride(utils.VN_TIMEZONE),
+		emulation.SetLocaleOverride(),
+	); err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("failed to set initial emulation/state: %w", err)
+	}
+	return tabCtx, cancel, nil
+}
+
+// unlockProfile clears the SingletonLock left in the profile directory by a browser that was killed,
+// which would otherwise keep Chrome from starting on the profile.
+func unlockProfile(ctx context.Context, profileName string) error {
+	dir := chromeprofile.Dir(profileName)
+	cleared, err := chromeprofile.ClearStaleLock(dir)
+	if err != nil {
+		return fmt.Errorf("browser profile %q is not usable: %w", profileName, err)
+	}
+	if cleared {
+		logging.FromContext(ctx).Warn("Removed stale browser profile lock", "path", dir)
+	}
+	return nil
+}
+
+// exploreURL returns the explore page URL for the given market region.
+func exploreURL(regionCode string) string {
+	return fmt.Sprintf(PARTNER_TIKSHOP_EXPLORE_URL, url.QueryEscape(regionCode))
+}
+
 // crawlerKols implements the main looping and state loading logic.
+// The KOL is searched in each region in turn until one of them returns data; the region that
+// produced the data is returned alongside it.
 func crawlerKols(
+	ctx context.Context,
 	kol models.SocialProfile,
 	urlPattern string,
 	statePath string,
 	userAgent string,
 	profileName string,
 	headless bool,
-) ([]CollectedData, error) {
+	limiter *ratelimit.Limiter,
+	regions []string,
+	assetPipeline *assets.Pipeline,
+) ([]CollectedData, string, error) {
 
-	// 1. Initial Setup: Allocator Context (Browser Instance)
-	opts := initChromedpOptions(profileName, headless, userAgent)
-	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer cancelAlloc()
+	// 1. Initial Setup: Browser Instance and Main Tab
+	mainTaskCtx, cancelBrowser, err := newBrowserTab(ctx, profileName, headless, userAgent)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cancelBrowser()
+
+	logger := logging.Stage(ctx, "navigate")
+	for _, regionCode := range regions {
+		// 4. Navigate to the explore page of the region
+		targetPage := exploreURL(regionCode)
+		logger.Info("Navigating to explore page", "url", targetPage, "region", regionCode)
+		if err := limiter.Wait(mainTaskCtx, profileName, ratelimit.ActionNavigate); err != nil {
+			return nil, "", fmt.Errorf("rate limiter refused navigation to %s: %w", targetPage, err)
+		}
+		stopNavigate := metrics.StageTimer(metrics.STAGE_NAVIGATE)
+		err := chromedp.Run(mainTaskCtx,
+			chromedp.Navigate(targetPage),
+			chromedp.WaitVisible(NAME_SEARCH_ELEM, chromedp.BySearch), // Wait for a key element to confirm load
+			chromedp.Sleep(5*time.Second),                             // Deliberate pause
+		)
+		stopNavigate()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to navigate to target page %s: %w", targetPage, err)
+		}
+		logger.Debug("Explore page loaded", "region", regionCode)
 
-	// 3. Create Main Context (Tab) and Set Emulation
-	mainTaskCtx, cancelMainTask := chromedp.NewContext(allocCtx)
-	defer cancelMainTask()
+		// 5. Search the KOL in this region
 
-	if err := chromedp.Run(mainTaskCtx,
-		// 5. Set a common desktop viewport size
-		chromedp.EmulateViewport(1920, 1080),
-		// 6. Set locale/timezone for extra realism
-		emulation.SetTimezoneOverride("Asia/Ho_Chi_Minh"), // <-- FIXED
-		emulation.SetLocaleOverride(),                     // <-- FIXED
-		// Inject cookies if they were loaded successfully
-		// setCookieAction,
-	); err != nil {
-		return nil, fmt.Errorf("failed to set initial emulation/state: %w", err)
-	}
+		// FIX: Pass the main tab's context (mainTaskCtx) to perform actions on the page.
+		finalKolName, collectedData, err := processSingleKol(mainTaskCtx, kol.UserName, urlPattern, limiter, profileName)
 
-	// 4. Navigate to the Target Page
-	log.Printf("Navigating to protected page: %s", TARGET_PAGE)
-	if err := chromedp.Run(mainTaskCtx,
-		chromedp.Navigate(TARGET_PAGE),
-		chromedp.WaitVisible(NAME_SEARCH_ELEM, chromedp.BySearch), // Wait for a key element to confirm load
-		chromedp.Sleep(5*time.Second),                             // Deliberate pause
-	); err != nil {
-		return nil, fmt.Errorf("failed to navigate to target page %s: %w", TARGET_PAGE, err)
+		if err != nil {
+			logger.Error("Failed to process KOL", "name", finalKolName, "region", regionCode, "error", err)
+			return nil, regionCode, err
+		}
+
+		logger.Info("Processed KOL", "name", finalKolName, "region", regionCode, "responses", len(collectedData))
+		if len(collectedData) > 0 {
+			// Images are downloaded while the logged in tab is still open
+			storeCreatorAssets(mainTaskCtx, assetPipeline, collectedData)
+			return collectedData, regionCode, nil
+		}
+		logger.Info("No data in region, trying the next fallback region", "region", regionCode)
 	}
-	log.Println("Successfully navigated to the protected page.")
 
-	// 5. Crawling Loop
+	// Browser close is handled by the defer cancelAlloc()
+	logger.Info("KOL not found in any region", "regions", regions)
+	return nil, "", nil
+}
 
-	log.Printf("\nProcessing KOL: ID=%s, Username=%s", kol.ID, kol.UserName)
+func main() {
+	mode := flag.String("mode", "enrich", "run mode: enrich (crawl pending profiles), discover (find new creators from explore filters), labels (review unmapped TTO labels), serve (crawl on a schedule), consume (crawl the jobs of a queue), login (save a session) or visit (open the home page on the profile)")
+	discoverRegion := flag.String("region", region.DEFAULT_REGION, "discover: explore page region")
+	discoverCategories := flag.String("categories", "", "discover: comma separated category labels")
+	discoverFollowers := flag.String("follower-tiers", "", "discover: comma separated follower tier labels")
+	discoverPrices := flag.String("price-ranges", "", "discover: comma separated price range labels")
+	discoverLanguages := flag.String("languages", "", "discover: comma separated language labels")
+	discoverMax := flag.Int("max-creators", 500, "discover: stop after this many distinct creators (0 for no limit)")
+	mapLabel := flag.String("map-label", "", "labels: approve a mapping given as LABEL_ID=CONTENT_INTEREST_ID")
+	reportDir := flag.String("report-dir", utils.GetEnvString("TTO_REPORT_DIR", "reports"), "directory receiving the <run_id>.json and <run_id>.csv run reports")
+	resume := flag.String("resume", "", "crawl: run ID of an interrupted run to continue from its checkpoint")
+	inputList := flag.String("input", "", "crawl: CSV or JSON file of handles to crawl instead of the pending profiles (- for stdin), without a database; results go to the jsonl sink unless -sink is set")
+	checkpointDir := flag.String("checkpoint-dir", utils.GetEnvString("TTO_CHECKPOINT_DIR", checkpoint.DEFAULT_DIR), "crawl: directory of the run checkpoints")
+	profileID := flag.Int("profile-id", 0, "crawl, consume: only crawl this social profile ID (0 for every pending profile)")
+	crawlLimit := flag.Int("limit", 200, "crawl, serve, consume: maximum number of profiles selected for a run")
+	jobSource := flag.String("source", utils.GetEnvString("TTO_SOURCE", jobsource.SOURCE_POSTGRES), "consume: job source, postgres, redis or nats")
+	sinkList := flag.String("sink", utils.GetEnvString("TTO_SINKS", sink.SINK_POSTGRES), "crawl, serve, consume: comma separated result sinks, postgres, jsonl, csv and/or mongo")
+	outputDir := flag.String("output-dir", utils.GetEnvString("TTO_OUTPUT_DIR", sink.DEFAULT_OUTPUT_DIR), "directory receiving the <run_id>.jsonl and <run_id>.csv exports of the jsonl and csv sinks")
+	visitFor := flag.Duration("visit-for", 10*time.Minute, "visit: how long the home page stays open")
+	metricsAddr := flag.String("metrics-addr", "", "address serving /metrics and /healthz, e.g. :9090 (defaults to TTO_METRICS_ADDR, empty disables)")
+	command, args := splitCommand(os.Args[1:])
+	flag.CommandLine.Parse(args)
+	if command != "" {
+		commandMode, ok := COMMAND_MODES[command]
+		if !ok {
+			log.Fatalf("Unknown command %q", command)
+		}
+		*mode = commandMode
+	}
 
-	// FIX: Pass the main tab's context (mainTaskCtx) to perform actions on the page.
-	finalKolName, collectedData, err := processSingleKol(mainTaskCtx, kol.UserName, urlPattern)
+	// --- Load Environment Variables ---
+	envErr := godotenv.Load()
 
+	logger, err := logging.Setup(logging.LoadConfigFromEnv())
 	if err != nil {
-		log.Printf("Error processing KOL %s: %v", finalKolName, err)
-		return nil, err
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	if err := apierror.LoadStatusCodesFromEnv(); err != nil {
+		fatal(logger, "Invalid status code classes", err)
+	}
+	logging.RegisterSecret(os.Getenv("MONGODB_URI"), os.Getenv("POSTGRES_PASS"), os.Getenv("TTO_S3_SECRET_KEY"), os.Getenv("TTO_ALERT_WEBHOOK_URL"), os.Getenv("TTO_REDIS_PASSWORD"), os.Getenv("TTO_NATS_URL"))
+	runID := logging.NewRunID()
+	if *resume != "" {
+		runID = *resume
+	}
+	logger = logger.With("mode", *mode)
+	if *mode != "enrich" && *mode != "serve" && *mode != "consume" {
+		// Enrich and consume runs add their own run ID, a serve process runs many of them
+		logger = logger.With(logging.KEY_RUN_ID, runID)
+	}
+	ctx := logging.WithLogger(context.Background(), logger)
+	if envErr != nil {
+		logger.Warn("Could not load .env file", "error", envErr)
+	}
+	if *inputList != "" && (*mode != "enrich" || *resume != "") {
+		fatal(logger, "Invalid flags", errors.New("-input only applies to a new crawl run"))
 	}
 
-	log.Printf("Processed %s. Captured %d data points.", finalKolName, len(collectedData))
+	// Every browser and request below runs on the shutdown context: the first SIGINT/SIGTERM stops the
+	// run from taking new KOLs, and the context is cancelled once the grace period is over.
+	shut := shutdown.Notify(ctx, utils.GetEnvDuration("TTO_SHUTDOWN_GRACE", shutdown.DEFAULT_GRACE))
+	defer shut.Stop()
+	ctx = shut.Context()
 
-	// NOTE: Placeholder for your Python's self._parse_user_data logic.
-	// You would typically process 'collectedData' here to create a final structure.
-	// For now, we'll just track the successful names.
-	if len(collectedData) > 0 {
-		log.Printf("Data captured for KOL %s.", kol.UserName, len(collectedData))
+	userAgent := DEFAULT_USER_AGENT
+	profileName := "tto"
+	switch *mode {
+	case "login":
+		logging.RegisterSecret(os.Getenv("TTO_LOGIN_PASSWORD"))
+		if err := simulateLogin(ctx, PARTNER_TIKTOKSHOP_LOGIN_URL, os.Getenv("TTO_LOGIN_USERNAME"), os.Getenv("TTO_LOGIN_PASSWORD"), "tiktokshop_state_go.json", userAgent, profileName, false, ""); err != nil {
+			fatal(logger, "Login and state saving failed", err)
+		}
+		return
+	case "visit":
+		if err := visitHomePage(ctx, PARTNER_TIKSHOP_HOME_URL, userAgent, profileName, false, *visitFor); err != nil {
+			fatal(logger, "Home page visit failed", err)
+		}
+		return
 	}
 
-	// Final 5-second pause and browser close is handled by the defer cancelAlloc()
-	log.Println("Finished processing all KOLs. Browser will close.")
+	if *metricsAddr == "" {
+		*metricsAddr = os.Getenv("TTO_METRICS_ADDR")
+	}
+	metrics.SetStallAfter(utils.GetEnvDuration("TTO_HEALTH_STALL_AFTER", metrics.DEFAULT_STALL_AFTER))
+	if *metricsAddr != "" && *mode != "serve" {
+		metricsCtx, stopMetrics := context.WithCancel(ctx)
+		defer stopMetrics()
+		if _, err := metrics.Serve(metricsCtx, *metricsAddr); err != nil {
+			fatal(logger, "Failed to start the metrics endpoint", err)
+		}
+	}
 
-	return collectedData, nil
-}
+	// A crawl of a handle list, or the resume of one, runs without any database
+	var runCheckpoint *checkpoint.Checkpoint
+	if *resume != "" && *mode == "enrich" {
+		runCheckpoint, err = checkpoint.Load(*checkpointDir, *resume)
+		if err != nil {
+			fatal(logger, "Failed to load checkpoint", err)
+		}
+	}
+	offline := *inputList != "" || (runCheckpoint != nil && runCheckpoint.Selection.Input != "")
 
-func main() {
+	// 1. Configuration parameters
+	var reportMongoDB *mongo.Client
+	var socialProfileRepo postgre.SocialProfileRepository
+	var crawlRunRepo postgre.CrawlRunRepository
+	if !offline {
+		reportMongoDB, err = mongodb.ConnectMongoDB(os.Getenv("MONGODB_URI"))
+		if err != nil {
+			fatal(logger, "Failed to connect to MongoDB", err)
+		}
+		defer reportMongoDB.Disconnect(context.Background())
+
+		creds := postgre.DBCredentials{
+			Host:     os.Getenv("POSTGRES_HOST"),
+			Port:     os.Getenv("POSTGRES_PORT"),
+			User:     os.Getenv("POSTGRES_USER"),
+			Password: os.Getenv("POSTGRES_PASS"),
+			DBName:   os.Getenv("POSTGRES_DATABASE"),
+			SSLMode:  os.Getenv("POSTGRES_SSLMODE"), // e.g., "disable", "require", "verify-full"
+		}
 
-	// --- Load Environment Variables ---
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: Could not load .env file: %v", err)
+		postgreDB, err := postgre.InitDB(creds)
+		if err != nil {
+			fatal(logger, "Failed to connect to PostgreSQL", err)
+		}
+		defer postgreDB.Close()
+		socialProfileRepo = postgre.NewSocialProfileRepository(postgreDB)
+		crawlRunRepo = postgre.NewCrawlRunRepository(postgreDB)
 	}
 
-	// 1. Configuration parameters
-	reportMongoDB, err := mongodb.ConnectMongoDB(os.Getenv("MONGODB_URI"))
+	countryRepository := mongodb.NewCountryDetailRepository(
+		reportMongoDB,
+		utils.GetEnvString("MONGODB_COUNTRY_DETAIL_DATABASE", mongodb.DEFAULT_COUNTRY_DATABASE),
+		os.Getenv("MONGODB_COUNTRY_DETAIL_COLLECTION"),
+		utils.GetEnvDuration("TTO_COUNTRY_CACHE_TTL", mongodb.DEFAULT_COUNTRY_CACHE_TTL),
+	)
+	countryIsoCode, err := countryRepository.GetCountryCodes(ctx)
 	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err, os.Getenv("MONGODB_URI"))
+		fatal(logger, "Failed to get country codes from MongoDB", err)
 	}
-	defer reportMongoDB.Disconnect(context.Background())
 
-	creds := postgre.DBCredentials{
-		Host:     os.Getenv("POSTGRES_HOST"),
-		Port:     os.Getenv("POSTGRES_PORT"),
-		User:     os.Getenv("POSTGRES_USER"),
-		Password: os.Getenv("POSTGRES_PASS"),
-		DBName:   os.Getenv("POSTGRES_DATABASE"),
-		SSLMode:  os.Getenv("POSTGRES_SSLMODE"), // e.g., "disable", "require", "verify-full"
+	if *mode == "labels" {
+		if err := runLabelReview(ctx, socialProfileRepo, *mapLabel); err != nil {
+			fatal(logger, "Label review failed", err)
+		}
+		return
 	}
 
-	postgreDB, err := postgre.InitDB(creds)
+	statePath := "tiktokshop_state_go.json"
+	urlPattern := "CreativeOne/MatchPack/MGetCreatorsCard" // Replace with the actual API endpoint pattern
+	logger = logger.With(logging.KEY_ACCOUNT, profileName)
+	ctx = logging.WithLogger(ctx, logger)
+
+	rateConfig, err := ratelimit.LoadConfigFromEnv()
 	if err != nil {
-		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+		fatal(logger, "Invalid rate limiter configuration", err)
 	}
-	defer postgreDB.Close()
-
-	countryRepository := mongodb.NewCountryDetailRepository(reportMongoDB, os.Getenv("MONGODB_COUNTRY_DETAIL_COLLECTION"))
-	countryIsoCode, err := countryRepository.GetCountryCodes(context.Background())
+	limiter, err := ratelimit.NewLimiter(ctx, rateConfig)
 	if err != nil {
-		log.Fatalf("Failed to get country codes from MongoDB: %v", err)
+		fatal(logger, "Failed to initialise rate limiter", err)
 	}
 
-	socialProfileRepo := postgre.NewSocialProfileRepository(postgreDB)
-	kolsToCrawl, err := socialProfileRepo.GetSocialProfileCrawlTTO()
+	if *mode == "discover" {
+		filters := DiscoveryFilters{
+			Region:        *discoverRegion,
+			Categories:    splitFlagList(*discoverCategories),
+			FollowerTiers: splitFlagList(*discoverFollowers),
+			PriceRanges:   splitFlagList(*discoverPrices),
+			Languages:     splitFlagList(*discoverLanguages),
+			MaxCreators:   *discoverMax,
+		}
+		inserted, err := discoverCreators(ctx, filters, urlPattern, userAgent, profileName, false, limiter, socialProfileRepo)
+		if err != nil {
+			fatal(logger, "Discovery failed", err)
+		}
+		logger.Info("Discovery complete", "inserted", inserted)
+		return
+	}
+
+	growthAggregator, err := growth.NewAggregatorFromEnv()
+	if err != nil {
+		fatal(logger, "Invalid growth aggregation configuration", err)
+	}
+	var changeSink changes.Sink
+	if webhookURL := os.Getenv("TTO_ALERT_WEBHOOK_URL"); webhookURL != "" {
+		changeSink = changes.NewWebhookSink(webhookURL)
+	}
+	assetPipeline, err := assets.NewPipelineFromEnv()
 	if err != nil {
-		log.Fatalf("Failed to get KOLs to crawl from PostgreSQL: %v", err)
+		fatal(logger, "Invalid asset storage configuration", err)
+	}
+	if assetPipeline == nil {
+		logger.Info("Creator images are not stored (TTO_ASSETS_ENABLED off, or no TTO_ASSET_BASE_URL for the local storage)")
+	}
+	if offline && !flagSet("sink") {
+		*sinkList = sink.SINK_JSONL
+	}
+	sinkNames, err := sink.ParseNames(*sinkList)
+	if err != nil {
+		fatal(logger, "Invalid result sinks", err)
+	}
+	results, err := sink.New(sinkNames, sink.Options{
+		RunID:           runID,
+		OutputDir:       *outputDir,
+		Postgres:        socialProfileRepo,
+		Mongo:           reportMongoDB,
+		MongoDatabase:   utils.GetEnvString("TTO_MONGO_SINK_DATABASE", mongodb.DEFAULT_TTO_USER_DATABASE),
+		MongoCollection: utils.GetEnvString("TTO_MONGO_SINK_COLLECTION", mongodb.DEFAULT_TTO_USER_COLLECTION),
+	})
+	if err != nil {
+		fatal(logger, "Failed to open result sinks", err)
+	}
+	defer results.Close()
+	logger.Info("Result sinks", "sinks", results.Names())
+
+	enrich := &enricher{
+		socialProfileRepo: socialProfileRepo,
+		crawlRunRepo:      crawlRunRepo,
+		countryRepository: countryRepository,
+		taxonomyService:   taxonomy.NewService(taxonomy.LoadConfigFromEnv(), socialProfileRepo),
+		growthAggregator:  growthAggregator,
+		interestWeights:   interest.LoadWeightsFromEnv(),
+		normalizer:        distribution.NewNormalizerFromEnv(),
+		changeDetector:    changes.NewDetector(changes.LoadThresholdsFromEnv()),
+		changeSink:        changeSink,
+		results:           results,
+		assetPipeline:     assetPipeline,
+		regionConfig:      region.LoadConfigFromEnv(),
+		limiter:           limiter,
+		shut:              shut,
+		maxAttempts:       utils.GetEnvInt("TTO_MAX_ATTEMPTS", 3),
+		failureBackoff:    utils.GetEnvDuration("TTO_FAILURE_BACKOFF", DEFAULT_FAILURE_BACKOFF),
+		maxFailures:       utils.GetEnvInt("TTO_MAX_CRAWL_FAILURES", DEFAULT_MAX_CRAWL_FAILURES),
+		urlPattern:        urlPattern,
+		statePath:         statePath,
+		userAgent:         userAgent,
+		profileName:       profileName,
+		reportDir:         *reportDir,
+		checkpointDir:     *checkpointDir,
 	}
 
-	fmt.Println(countryIsoCode, kolsToCrawl)
+	if *mode == "serve" {
+		addr := *metricsAddr
+		if addr == "" {
+			addr = DEFAULT_SERVE_ADDR
+		}
+		if err := runServe(ctx, enrich, addr, *crawlLimit); err != nil {
+			fatal(logger, "Serve mode failed", err)
+		}
+		return
+	}
 
-	// loginURL := PARTNER_TIKTOKSHOP_LOGIN_URL
-	// username := "van.le@brancherx.com" // Placeholder
-	// password := "VLantking2013!"       // Placeholder
-	statePath := "tiktokshop_state_go.json"
-	urlPattern := "CreativeOne/MatchPack/MGetCreatorsCard" // Replace with the actual API endpoint pattern
-	userAgent := DEFAULT_USER_AGENT
-	profileName := "tto"
+	if *mode == "consume" {
+		selection := models.CrawlSelection{CreatorStatus: utils.TTO_CREATOR_STATUS_PENDING, ProfileID: *profileID, Limit: *crawlLimit}
+		source, err := jobsource.NewFromEnv(ctx, *jobSource, socialProfileRepo, selection)
+		if err != nil {
+			fatal(logger, "Failed to open job source", err)
+		}
+		defer source.Close()
+		runConsume(ctx, enrich, source, runID, utils.GetEnvInt("TTO_SOURCE_MAX_DELIVERIES", jobsource.DEFAULT_MAX_DELIVERIES))
+		return
+	}
 
 	// 2. Start the main crawling loop using the saved state.
-	log.Println("\n--- Starting KOL Crawling Process ---")
+	if runCheckpoint != nil {
+		logger.Info("Resuming run from checkpoint", "done", len(runCheckpoint.Entries()), "remaining", runCheckpoint.Remaining())
+	} else if *inputList != "" {
+		kols, err := readKolList(*inputList, os.Stdin)
+		if err != nil {
+			fatal(logger, "Failed to read input list", err)
+		}
+		profiles := kolProfiles(kols)
+		if len(profiles) == 0 {
+			logger.Info("No KOLs to crawl")
+			return
+		}
+		logger.Info("Read input list", "entries", len(kols), "kols", len(profiles))
+		runCheckpoint, err = checkpoint.New(*checkpointDir, runID, *mode, profileName, models.CrawlSelection{Input: *inputList}, profiles)
+		if err != nil {
+			fatal(logger, "Failed to create checkpoint", err)
+		}
+	} else {
+		selection := models.CrawlSelection{CreatorStatus: utils.TTO_CREATOR_STATUS_PENDING, ProfileID: *profileID, Limit: *crawlLimit}
+		runCheckpoint, err = enrich.newCheckpoint(ctx, runID, *mode, selection)
+		if err != nil {
+			fatal(logger, "Failed to create checkpoint", err)
+		}
+		if runCheckpoint == nil {
+			logger.Info("No KOLs to crawl")
+			return
+		}
+	}
+	logger.Info("Loaded crawl inputs", "countries", len(countryIsoCode), "kols", runCheckpoint.Remaining())
 
-	for _, kol := range kolsToCrawl {
+	enrich.run(ctx, runID, *mode, runCheckpoint, *resume != "")
+	logger.Info("Chromedp script finished successfully")
+}
 
-		crawledData, err := crawlerKols(kol, urlPattern, statePath, userAgent, profileName, false)
+// COMMAND_MODES maps the subcommands to the run modes of -mode.
+var COMMAND_MODES = map[string]string{
+	"crawl":    "enrich",
+	"enrich":   "enrich",
+	"discover": "discover",
+	"labels":   "labels",
+	"serve":    "serve",
+	"consume":  "consume",
+	"login":    "login",
+	"visit":    "visit",
+}
 
-		if err != nil {
-			log.Println("Fatal: Crawler failed: %v", err)
-			continue
+// splitCommand separates a leading subcommand (e.g. "crawl" in `crawl --resume <run-id>`) from the flags.
+func splitCommand(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", args
+	}
+	return args[0], args[1:]
+}
+
+// flagSet reports whether the flag name was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
 		}
-		log.Printf("Successfully crawled creator: ID=%s, Username=%s", kol.UserName, len(crawledData))
-		userInfo, isFull := parseUserData(crawledData, countryIsoCode, socialProfileRepo)
-		if isFull {
-			log.Printf("Full data collected for KOL %s.", kol.UserName)
-			log.Printf("User Info: %+v", *userInfo)
-
-			// Convert TTOUser struct to map[string]interface{} to match the repository method signature.
-			// This is a common pattern using JSON marshaling/unmarshaling.
-			var dataToUpdate map[string]interface{}
-			jsonData, err := json.Marshal(userInfo)
-			if err != nil {
-				log.Printf("Error marshaling user info to JSON for KOL ID %d: %v", kol.ID, err)
-				continue
-			}
-			if err := json.Unmarshal(jsonData, &dataToUpdate); err != nil {
-				log.Printf("Error unmarshaling user info to map for KOL ID %d: %v", kol.ID, err)
-				continue
-			}
+	})
+	return set
+}
 
-			fmt.Println("dataToUpdate------------, ", dataToUpdate)
-			dataToUpdate["tiktokshop_updated_at"] = time.Now()
-			dataToUpdate["tiktokshop_creator_status"] = 1
+// fatal logs err and exits, as log.Fatalf does, through the structured logger.
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
 
-			// Update the database with the collected data
-			err = socialProfileRepo.UpdateTTOUser(context.Background(), kol.ID, dataToUpdate)
+// recordChanges compares the new data of a KOL with what is stored before it is overwritten, and
+// records the events in the database and on the webhook. Failures are logged, the update goes on.
+func recordChanges(ctx context.Context, socialProfileRepo postgre.SocialProfileRepository, detector *changes.Detector, sink changes.Sink, kol models.SocialProfile, userInfo *models.TTOUser) {
+	logger := logging.FromContext(ctx)
+	previous, err := socialProfileRepo.GetTTOUser(ctx, kol.ID)
+	if err != nil {
+		metrics.DBError(metrics.DB_POSTGRES, "get_tto_user")
+		logger.Error("Failed to read stored data for change detection", "error", err)
+		return
+	}
+	events := detector.Detect(kol, previous, userInfo)
+	if len(events) == 0 {
+		return
+	}
+	for _, event := range events {
+		logger.Warn("Change detected", "event", event.Type, "severity", event.Severity, "message", event.Message)
+	}
+	if err := socialProfileRepo.InsertCreatorEvents(ctx, events); err != nil {
+		metrics.DBError(metrics.DB_POSTGRES, "insert_creator_events")
+		logger.Error("Failed to save change events", "error", err)
+	}
+	if sink != nil {
+		if err := sink.Send(ctx, events); err != nil {
+			logger.Error("Failed to send change events", "error", err)
+		}
+	}
+}
 
+// storeCreatorAssets copies the images of the first creator card with videos (or the first card) to
+// the asset storage through the tab of tabCtx. Failures only lose the images, never the crawl.
+func storeCreatorAssets(tabCtx context.Context, assetPipeline *assets.Pipeline, collectedData []CollectedData) {
+	if assetPipeline == nil {
+		return
+	}
+	target := -1
+	for i, data := range collectedData {
+		if data.Body == nil || len(data.Body.Creators) == 0 {
+			continue
+		}
+		if target == -1 {
+			target = i
+		}
+		if len(data.Body.Creators[0].StatisticData.VideoPerformance.RecentVideos) > 0 {
+			target = i
 			break
+		}
+	}
+	if target == -1 {
+		return
+	}
 
+	media, err := assetPipeline.StoreCreator(tabCtx, assets.NewBrowserFetcher(tabCtx), collectedData[target].Body.Creators[0])
+	if err != nil {
+		logging.Stage(tabCtx, "assets").Error("Failed to store creator images", "error", err)
+		return
+	}
+	collectedData[target].Assets = media
+}
+
+// splitFlagList splits a comma separated flag value, dropping blank entries.
+func splitFlagList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
 		}
 	}
+	return out
+}
+
+// crawlKolWithRetry crawls a single KOL and applies the decision mapped to the classified response:
+// unknown failures are retried, rate limiting triggers a cool-down before retrying, and hidden creators
+// or unsupported regions are returned for a permanent skip, with the responses captured. The returned
+// error is only set when the whole run must stop (rate limiter budget exhausted, session needs a fresh
+// login, or ctx cancelled on shutdown). The number of attempts made is returned for the run ledger.
+func crawlKolWithRetry(
+	ctx context.Context,
+	kol models.SocialProfile,
+	urlPattern string,
+	statePath string,
+	userAgent string,
+	profileName string,
+	limiter *ratelimit.Limiter,
+	maxAttempts int,
+	regions []string,
+	assetPipeline *assets.Pipeline,
+) ([]CollectedData, string, int, *apierror.Error, error) {
+	var apiErr *apierror.Error
+
+	attempt := 0
+	for attempt < maxAttempts {
+		attempt++
+		crawledData, usedRegion, err := crawlerKols(ctx, kol, urlPattern, statePath, userAgent, profileName, false, limiter, regions, assetPipeline)
+		if ctx.Err() != nil {
+			// Shutdown: the KOL did not finish within the grace period
+			return nil, "", attempt, nil, fmt.Errorf("crawl interrupted: %w", ctx.Err())
+		}
+		if errors.Is(err, ratelimit.ErrDailyCapReached) || errors.Is(err, ratelimit.ErrQuietHours) {
+			return nil, "", attempt, nil, err
+		}
+		if err != nil {
+			apiErr = &apierror.Error{Class: apierror.ClassUnknown, Message: err.Error()}
+		} else {
+			apiErr = classifyCollectedData(crawledData)
+		}
+		if apiErr == nil {
+			return crawledData, usedRegion, attempt, nil, nil
+		}
 
-	log.Printf("\n--- Final Summary ---")
+		logging.FromContext(ctx).Warn("Crawl attempt failed", "attempt", attempt, "max_attempts", maxAttempts, "class", apiErr.Class, "decision", apiErr.Decision(), "error", apiErr)
+		switch apiErr.Decision() {
+		case apierror.DecisionRelogin:
+			return nil, "", attempt, apiErr, fmt.Errorf("session for profile %q needs a fresh login: %w", profileName, apiErr)
+		case apierror.DecisionSkip:
+			return crawledData, usedRegion, attempt, apiErr, nil
+		case apierror.DecisionCoolDown:
+			// The network listener already reported the response to the limiter, whose cool-down
+			// holds back the next attempt
+		}
+	}
 
-	log.Println("Chromedp script finished successfully.")
+	return nil, "", attempt, apiErr, nil
 }
 
 // initChromedpOptions sets up the allocator options with anti-detection flags and user data.
-func initChromedpOptions(profileName string, headless bool, userAgent string) []chromedp.ExecAllocatorOption {
-	profilePath := filepath.Join(".", "profiles", profileName)
-	fmt.Println("profilePath---------, ", profilePath)
+func initChromedpOptions(ctx context.Context, profileName string, headless bool, userAgent string) []chromedp.ExecAllocatorOption {
+	profilePath := chromeprofile.Dir(profileName)
+	logging.FromContext(ctx).Debug("Using browser profile", "path", profilePath)
 
 	opts := append(
 		chromedp.DefaultExecAllocatorOptions[:],
@@ -611,13 +898,102 @@ func initChromedpOptions(profileName string, headless bool, userAgent string) []
 	return opts
 }
 
-func parseUserData(collectedData []CollectedData, countryIsoCode map[string]string, socialProfileRepo postgre.SocialProfileRepository) (*TTOUser, bool) {
-	var categoryContent []ContentLabel
-	var ageDistri []AgeDistri
-	var regionDistri []RegionDistri
-	var genderDistri []GenderDistri
-	var followerTrend []FollowerTrend
-	var videoViews []VideoItem
+// reportToLimiter extends the cool-down of account for a rate limited or unclassified response and
+// resets its backoff after a success. The other failures say nothing about the request rate.
+func reportToLimiter(ctx context.Context, limiter *ratelimit.Limiter, account string, httpStatus int, baseResp api.BaseResp) {
+	apiErr := apierror.ClassifyResponse(httpStatus, baseResp.StatusCode, baseResp.StatusMessage)
+	switch {
+	case apiErr == nil:
+		limiter.ReportSuccess(ctx, account)
+	case apiErr.Class == apierror.ClassRateLimited, apiErr.Class == apierror.ClassUnknown:
+		// An unlisted non-zero code is treated as throttling until it is classified
+		limiter.ReportThrottled(ctx, account, httpStatus, baseResp.StatusCode)
+	}
+}
+
+// classifyCollectedData classifies the captured MGetCreatorsCard responses of a KOL.
+// It returns nil when at least one response is a success and its creator is visible.
+func classifyCollectedData(collectedData []CollectedData) *apierror.Error {
+	if len(collectedData) == 0 {
+		return &apierror.Error{Class: apierror.ClassUnknown, Message: "no creator card responses captured"}
+	}
+
+	var firstErr *apierror.Error
+	for _, data := range collectedData {
+		var apiErr *apierror.Error
+		if data.Body == nil {
+			apiErr = apierror.ClassifyResponse(data.Status, 0, "")
+			if apiErr == nil {
+				apiErr = &apierror.Error{Class: apierror.ClassUnknown, HTTPStatus: data.Status, Message: "empty response body"}
+			}
+		} else {
+			apiErr = apierror.ClassifyResponse(data.Status, data.Body.BaseResp.StatusCode, data.Body.BaseResp.StatusMessage)
+		}
+		if apiErr == nil && len(data.Body.Creators) > 0 {
+			info := data.Body.Creators[0].CreatorTTInfo
+			apiErr = apierror.ClassifyCreator(info.HandleName, info.IsBannedInTT, info.DisplayStatus)
+		}
+		if apiErr == nil {
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = apiErr
+		}
+	}
+	return firstErr
+}
+
+// capturedHealth returns the account state of the last creator card captured, or nil when there is
+// none. Skipped creators (banned or hidden) have no other data worth parsing.
+func capturedHealth(collectedData []CollectedData) *models.CreatorHealth {
+	var health *models.CreatorHealth
+	for _, data := range collectedData {
+		if data.Body != nil && len(data.Body.Creators) > 0 {
+			health = creatorHealth(data.Body.Creators[0].CreatorTTInfo)
+		}
+	}
+	return health
+}
+
+// storedRegion returns the creator's own market from the last creator card captured, so that the next
+// crawl searches that market first, or usedRegion, the explore market that found it, when the card
+// names none.
+func storedRegion(collectedData []CollectedData, usedRegion string) string {
+	creatorRegion := ""
+	for _, data := range collectedData {
+		if data.Body != nil && len(data.Body.Creators) > 0 {
+			info := data.Body.Creators[0].CreatorTTInfo
+			if r := region.FromCreator(info.StoreRegion, info.LivingRegion); r != "" {
+				creatorRegion = r
+			}
+		}
+	}
+	if creatorRegion == "" {
+		return usedRegion
+	}
+	return creatorRegion
+}
+
+func creatorHealth(info api.CreatorTTInfo) *models.CreatorHealth {
+	return &models.CreatorHealth{
+		FollowerCount: info.FollowerCnt,
+		CreditScore:   info.CreditScore.CurrentScore,
+		CreditTier:    info.CreditScore.CurrentTier,
+		IsBannedInTT:  info.IsBannedInTT,
+	}
+}
+
+func parseUserData(ctx context.Context, collectedData []CollectedData, countryRepository mongodb.CountryDetailRepository, taxonomyService *taxonomy.Service, growthAggregator *growth.Aggregator, interestWeights interest.Weights, normalizer *distribution.Normalizer) (*models.TTOUser, bool) {
+	var categoryContent []api.Label
+	var interestSignals interest.Signals
+	var videoSource api.Creator
+	var assetsData *models.MediaAssets
+	var health *models.CreatorHealth
+	var ageDistri []api.AgeDistri
+	var regionDistri []api.RegionDistri
+	var genderDistri []api.GenderDistri
+	var followerTrend []api.FollowerTrend
+	var videoViews []api.VideoItem
 
 	var isFull = false
 
@@ -629,9 +1005,11 @@ func parseUserData(collectedData []CollectedData, countryIsoCode map[string]stri
 				continue
 			}
 			creatorData := dataResp.Creators[0]
+			health = creatorHealth(creatorData.CreatorTTInfo)
 			// Collect category labels
 			if len(creatorData.ContentLabels) > 0 || creatorData.ContentLabels != nil {
 				categoryContent = creatorData.ContentLabels
+				interestSignals = interest.SignalsFromCreator(creatorData)
 			}
 			// Collect demographic distributions
 			if len(creatorData.StatisticData.FollowerDistriData.Age) > 0 ||
@@ -657,6 +1035,10 @@ func parseUserData(collectedData []CollectedData, countryIsoCode map[string]stri
 			if len(creatorData.StatisticData.VideoPerformance.RecentVideos) > 0 ||
 				creatorData.StatisticData.VideoPerformance.RecentVideos != nil {
 				videoViews = creatorData.StatisticData.VideoPerformance.RecentVideos
+				videoSource = creatorData
+			}
+			if data.Assets != nil {
+				assetsData = data.Assets
 			}
 
 			// If all data is collected, break
@@ -672,202 +1054,45 @@ func parseUserData(collectedData []CollectedData, countryIsoCode map[string]stri
 		}
 	}
 	if isFull {
-		category := convertCategoryDistriToPercent(categoryContent, socialProfileRepo)
-		age := convertAgeDistriToPercent(ageDistri)
-		gender := convertGenderDistriToPercent(genderDistri)
-		region := convertRegionDistriToPercent(regionDistri, countryIsoCode)
-		follower := convertFollowerDistriToPercent(followerTrend)
-		videoViews := convertVideoViewsDistriToPercent(videoViews)
-
-		kolGrowth := mergeKOLGrowthData(follower, videoViews)
-		return &TTOUser{
+		category, err := taxonomyService.MapContentInterests(ctx, interest.Weigh(interestSignals, interestWeights))
+		if err != nil {
+			logging.FromContext(ctx).Error("Failed to map content interests", "error", err)
+		}
+		brands, err := taxonomyService.ResolveBrands(ctx, videoSource)
+		if err != nil {
+			logging.FromContext(ctx).Error("Failed to resolve sponsored brands", "error", err)
+		}
+		age, ageWarnings := normalizer.Age(ageDistri)
+		logDistributionWarnings(ctx, ageWarnings)
+		gender, genderWarnings := normalizer.Gender(genderDistri)
+		logDistributionWarnings(ctx, genderWarnings)
+		region, regionWarnings := normalizer.Region(regionDistri, func(isoCode string) string {
+			return countryRepository.ResolveCountry(ctx, isoCode)
+		})
+		logDistributionWarnings(ctx, regionWarnings)
+		kolGrowth := growthAggregator.Build(ctx, followerTrend, videoViews)
+		userInfo := &models.TTOUser{
 			CategoryContent: category,
 			AgeDistri:       age,
 			RegionDistri:    region,
 			GenderDistri:    gender,
 			KolGrowth:       kolGrowth,
-		}, true
-	}
-	return nil, false
-}
-
-func convertAgeDistriToPercent(ageDistri []AgeDistri) []map[string]interface{} {
-	// Initialize the destination slice
-	result := make([]map[string]interface{}, 0, len(ageDistri))
-
-	// Iterate over the input slice
-	for _, item := range ageDistri {
-		// Create a new map for each struct element
-		m := make(map[string]interface{})
-
-		// Manually map struct fields to map keys.
-		// We typically use the JSON tag names (e.g., "ageInterval", "ratio") as map keys.
-		m["name"] = item.AgeInterval
-		m["value"] = item.Ratio
-
-		// Append the map to the result slice
-		result = append(result, m)
-	}
-
-	return result
-}
-
-func convertGenderDistriToPercent(genderDistri []GenderDistri) []map[string]interface{} {
-	// Initialize the destination slice
-	result := make([]map[string]interface{}, 0, len(genderDistri))
-
-	// Iterate over the input slice
-	for _, item := range genderDistri {
-		// Create a new map for each struct element
-		m := make(map[string]interface{})
-
-		// Manually map struct fields to map keys.
-		// We typically use the JSON tag names (e.g., "ageInterval", "ratio") as map keys.
-		m["name"] = strings.ToLower(item.Gender)
-		m["value"] = item.Ratio
-
-		// Append the map to the result slice
-		result = append(result, m)
-	}
-
-	return result
-}
-
-func convertCategoryDistriToPercent(contentLabels []ContentLabel, socialProfileRepo postgre.SocialProfileRepository) []map[string]interface{} {
-	// Initialize the destination slice
-	result := make([]map[string]interface{}, 0, len(contentLabels))
-
-	avgPercent := 1.0 / float64(len(contentLabels))
-	totalPercent := 0.0
-
-	var contentName []string
-	for _, label := range contentLabels {
-		contentName = append(contentName, label.LabelName)
-	}
-	categoryMapping, err := socialProfileRepo.UpsertContentInterestsAndGetIDs(contentName, 1)
-	if err != nil {
-		log.Printf("Error fetching category mapping: %v", err)
-		return result
-	}
-
-	// Iterate over the input slice
-	for idx, item := range contentLabels {
-		// Create a new map for each struct element
-		m := make(map[string]interface{})
-
-		// Manually map struct fields to map keys.
-		// We typically use the JSON tag names (e.g., "ageInterval", "ratio") as map keys.
-		m["id"] = categoryMapping[item.LabelName]
-		m["name"] = item.LabelName
-		m["label_id"] = item.LabelID
-		totalPercent += avgPercent
-		// Adjust the last item's percent to ensure total sums to 1.0
-		if idx == len(contentLabels)-1 {
-			avgPercent += 1.0 - totalPercent
-		}
-		m["percent"] = avgPercent
-
-		// Append the map to the result slice
-		result = append(result, m)
-	}
-
-	return result
-}
-
-func convertRegionDistriToPercent(regionDistri []RegionDistri, countryIsoCode map[string]string) []map[string]interface{} {
-	// Initialize the destination slice
-	result := make([]map[string]interface{}, 0, len(regionDistri))
-
-	// Iterate over the input slice
-	for _, item := range regionDistri {
-		// Create a new map for each struct element
-		m := make(map[string]interface{})
-
-		// Manually map struct fields to map keys.
-		// We typically use the JSON tag names (e.g., "ageInterval", "ratio") as map keys.
-		m["name"] = countryIsoCode[item.Country]
-		m["iso_code"] = item.Country
-		m["value"] = item.Ratio
-
-		// Append the map to the result slice
-		result = append(result, m)
-	}
-
-	return result
-}
-
-func convertFollowerDistriToPercent(followerTrend []FollowerTrend) map[int64]int {
-	// Initialize the destination slice
-	result := make(map[int64]int)
-
-	// Iterate over the input slice
-	for _, item := range followerTrend {
-		createdDate, parseErr := utils.FormatDatetime(item.Date)
-		if parseErr != nil {
-			createdDate = 0
+			Brands:          brands,
+			KPIs:            kpi.Compute(videoSource),
+			Health:          health,
 		}
-		result[createdDate] = item.Count
-	}
-
-	return result
-}
-
-func convertVideoViewsDistriToPercent(videoViews []VideoItem) map[int64]int {
-	// Initialize the destination slice
-	result := make(map[int64]int)
-
-	// Iterate over the input slice
-	for _, item := range videoViews {
-		// Manually map struct fields to map keys.
-		// We typically use the JSON tag names (e.g., "ageInterval", "ratio") as map keys.
-		createdTime, err := strconv.Atoi(item.CreateTime)
-		if err != nil {
-			createdTime = 0
+		if assetsData != nil {
+			userInfo.AvatarURL = assetsData.AvatarURL
+			userInfo.VideoCovers = assetsData.Covers
 		}
-
-		truncate, err := utils.TruncateToDate(int64(createdTime))
-		if err != nil {
-			truncate = 0
-		}
-
-		videoViews, err := strconv.Atoi(item.Views)
-		if err != nil {
-			videoViews = 0
-		}
-
-		result[truncate] = videoViews
+		return userInfo, true
 	}
-
-	return result
+	return nil, false
 }
 
-func mergeKOLGrowthData(followerTrend, videoViews map[int64]int) map[string][]map[string]int {
-	var mergeData = make(map[int64]map[string]int)
-	for k, v := range followerTrend {
-		mergeData[k] = map[string]int{"followers": v}
+// logDistributionWarnings reports what the normaliser found inconsistent in the audience data.
+func logDistributionWarnings(ctx context.Context, warnings []string) {
+	for _, warning := range warnings {
+		logging.FromContext(ctx).Warn("Inconsistent audience distribution", "detail", warning)
 	}
-	for k, v := range videoViews {
-		if _, exists := mergeData[k]; exists {
-			mergeData[k]["videos"] = v
-			continue
-		} else {
-			mergeData[k] = map[string]int{"videos": v}
-		}
-	}
-	var result = make([]map[string]int, 0, len(mergeData))
-
-	for k, v := range mergeData {
-		m := make(map[string]int)
-		m["time"] = int(k)
-		m["followers"] = v["followers"] // v["followers"] will be 0 if not present, which is fine.
-		m["videos"] = v["videos"]       // Corrected from "video_views"
-		result = append(result, m)
-	}
-
-	// Sort the result slice by the 'time' field in ascending order.
-	sort.Slice(result, func(i, j int) bool {
-		return result[i]["time"] < result[j]["time"]
-	})
-
-	return map[string][]map[string]int{"detail": result}
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"tto_chromedp/pkg/ratelimit"
+	"tto_chromedp/pkg/tto/api"
+)
+
+func cardWithRegions(store, living string) CollectedData {
+	creator := api.Creator{}
+	creator.CreatorTTInfo.StoreRegion = store
+	creator.CreatorTTInfo.LivingRegion = living
+	return CollectedData{Body: &api.Response{Creators: []api.Creator{creator}}}
+}
+
+func TestStoredRegion(t *testing.T) {
+	tests := []struct {
+		name string
+		data []CollectedData
+		want string
+	}{
+		{name: "nothing captured", want: "row"},
+		{name: "store region of the card", data: []CollectedData{cardWithRegions("VN", "TH")}, want: "vn"},
+		{name: "living region when no store region", data: []CollectedData{cardWithRegions("", "th")}, want: "th"},
+		{name: "card without a region", data: []CollectedData{cardWithRegions("", "")}, want: "row"},
+		{name: "empty response", data: []CollectedData{{Body: &api.Response{}}, {}}, want: "row"},
+		{name: "last card naming a region", data: []CollectedData{cardWithRegions("th", ""), cardWithRegions("vn", ""), cardWithRegions("", "")}, want: "vn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := storedRegion(tt.data, "row"); got != tt.want {
+				t.Fatalf("storedRegion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportToLimiter(t *testing.T) {
+	tests := []struct {
+		name       string
+		httpStatus int
+		baseResp   api.BaseResp
+		wantCool   bool
+	}{
+		{name: "success", httpStatus: http.StatusOK},
+		{name: "http 429", httpStatus: http.StatusTooManyRequests, wantCool: true},
+		{name: "listed rate limit code", httpStatus: http.StatusOK, baseResp: api.BaseResp{StatusCode: 10009, StatusMessage: "request too frequent"}, wantCool: true},
+		{name: "unknown code", httpStatus: http.StatusOK, baseResp: api.BaseResp{StatusCode: 99004, StatusMessage: "internal error"}, wantCool: true},
+		{name: "auth expired", httpStatus: http.StatusOK, baseResp: api.BaseResp{StatusCode: 40102}},
+		{name: "region unsupported", httpStatus: http.StatusOK, baseResp: api.BaseResp{StatusCode: 99003, StatusMessage: "Region not supported"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := ratelimit.DefaultConfig()
+			cfg.StatePath = ""
+			limiter, err := ratelimit.NewLimiter(context.Background(), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reportToLimiter(context.Background(), limiter, "acct", tt.httpStatus, tt.baseResp)
+			if cooling := !limiter.CoolDownUntil("acct").IsZero(); cooling != tt.wantCool {
+				t.Fatalf("cooling down = %v, want %v", cooling, tt.wantCool)
+			}
+		})
+	}
+}
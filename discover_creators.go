@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/metrics"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/postgre"
+	"tto_chromedp/pkg/ratelimit"
+	"tto_chromedp/pkg/region"
+	"tto_chromedp/pkg/tto/api"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// Explore page filter selectors. The filter dropdowns are located by their visible label text,
+// the same way NAME_SEARCH_ELEM is.
+const (
+	FILTER_GROUP_ELEM  = "//button[.//span[text()='%s']]"
+	FILTER_OPTION_ELEM = "//label[.//span[text()='%s']]"
+
+	FILTER_GROUP_CATEGORY = "Categories"
+	FILTER_GROUP_FOLLOWER = "Followers"
+	FILTER_GROUP_PRICE    = "Price"
+	FILTER_GROUP_LANGUAGE = "Language"
+)
+
+// DiscoveryFilters describes which explore page filters to apply before paging through the results.
+type DiscoveryFilters struct {
+	Region        string
+	Categories    []string
+	FollowerTiers []string
+	PriceRanges   []string
+	Languages     []string
+	// MaxCreators stops paging once this many distinct creators are captured (0 means no limit)
+	MaxCreators int
+}
+
+// creatorBatchCollector gathers every creator from the MGetCreatorsCard responses seen on a tab,
+// de-duplicated by ttUID (or handle name when the ttUID is missing).
+type creatorBatchCollector struct {
+	mu       sync.Mutex
+	seen     map[string]bool
+	creators []models.DiscoveredCreator
+	batches  int
+}
+
+func newCreatorBatchCollector() *creatorBatchCollector {
+	return &creatorBatchCollector{seen: make(map[string]bool)}
+}
+
+// add records the creators of one response batch and returns how many of them were new.
+func (c *creatorBatchCollector) add(resp *api.Response, fallbackRegion string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.batches++
+	added := 0
+	for _, creator := range resp.Creators {
+		info := creator.CreatorTTInfo
+		ttUID := info.TtUID
+		if ttUID == "" {
+			ttUID = creator.TtUID
+		}
+		handle := strings.TrimSpace(info.HandleName)
+		key := ttUID
+		if key == "" {
+			key = strings.ToLower(handle)
+		}
+		if key == "" || c.seen[key] {
+			continue
+		}
+		c.seen[key] = true
+
+		creatorRegion := region.FromCreator(info.StoreRegion, info.LivingRegion)
+		if creatorRegion == "" {
+			creatorRegion = fallbackRegion
+		}
+		c.creators = append(c.creators, models.DiscoveredCreator{TtUID: ttUID, HandleName: handle, Region: creatorRegion})
+		added++
+	}
+	return added
+}
+
+// snapshot returns a copy of the creators collected so far and the number of batches seen.
+func (c *creatorBatchCollector) snapshot() ([]models.DiscoveredCreator, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]models.DiscoveredCreator, len(c.creators))
+	copy(out, c.creators)
+	return out, c.batches
+}
+
+// discoverCreators opens the explore page, applies the filters, pages through the results list and
+// inserts every creator seen in the MGetCreatorsCard batches as a pending social profile.
+func discoverCreators(
+	ctx context.Context,
+	filters DiscoveryFilters,
+	urlPattern string,
+	userAgent string,
+	profileName string,
+	headless bool,
+	limiter *ratelimit.Limiter,
+	socialProfileRepo postgre.SocialProfileRepository,
+) (int, error) {
+	tabCtx, cancelBrowser, err := newBrowserTab(ctx, profileName, headless, userAgent)
+	if err != nil {
+		return 0, err
+	}
+	defer cancelBrowser()
+
+	regionCode := region.Normalize(filters.Region)
+	if regionCode == "" {
+		regionCode = region.DEFAULT_REGION
+	}
+
+	if err := chromedp.Run(tabCtx, network.Enable()); err != nil {
+		return 0, fmt.Errorf("failed to enable network events: %w", err)
+	}
+
+	logger := logging.Stage(ctx, "discover")
+	collector := newCreatorBatchCollector()
+	// The listener stops taking responses once listening is cleared, so that no wg.Add races wg.Wait
+	var wg sync.WaitGroup
+	var listenMu sync.Mutex
+	listening := true
+	chromedp.ListenTarget(tabCtx, func(ev interface{}) {
+		ev2, ok := ev.(*network.EventResponseReceived)
+		if !ok || !strings.Contains(ev2.Response.URL, urlPattern) {
+			return
+		}
+		listenMu.Lock()
+		defer listenMu.Unlock()
+		if !listening {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := chromedp.FromContext(tabCtx)
+			body, err := network.GetResponseBody(ev2.RequestID).Do(cdp.WithExecutor(tabCtx, c.Target))
+			if err != nil {
+				logger.Error("Failed to get response body", "url", ev2.Response.URL, "error", err)
+				return
+			}
+			var ttoResp api.Response
+			if err := json.Unmarshal(body, &ttoResp); err != nil {
+				logger.Error("Failed to unmarshal response", "url", ev2.Response.URL, "error", err)
+				return
+			}
+			metrics.ResponseCaptured(ttoResp.BaseResp.StatusCode)
+			reportToLimiter(ctx, limiter, profileName, int(ev2.Response.Status), ttoResp.BaseResp)
+			if ttoResp.BaseResp.StatusCode != 0 {
+				return
+			}
+			added := collector.add(&ttoResp, regionCode)
+			logger.Info("Discovery batch captured", "creators", len(ttoResp.Creators), "new", added)
+		}()
+	})
+
+	targetPage := exploreURL(regionCode)
+	if err := limiter.Wait(tabCtx, profileName, ratelimit.ActionNavigate); err != nil {
+		return 0, fmt.Errorf("rate limiter refused navigation to %s: %w", targetPage, err)
+	}
+	logger.Info("Navigating to explore page for discovery", "url", targetPage, "region", regionCode)
+	stopNavigate := metrics.StageTimer(metrics.STAGE_NAVIGATE)
+	err = chromedp.Run(tabCtx,
+		chromedp.Navigate(targetPage),
+		chromedp.WaitVisible(NAME_SEARCH_ELEM, chromedp.BySearch),
+		chromedp.Sleep(5*time.Second),
+	)
+	stopNavigate()
+	if err != nil {
+		return 0, fmt.Errorf("failed to navigate to explore page %s: %w", targetPage, err)
+	}
+
+	if err := applyDiscoveryFilters(tabCtx, filters, limiter, profileName); err != nil {
+		return 0, err
+	}
+
+	if err := limiter.Wait(tabCtx, profileName, ratelimit.ActionSearch); err != nil {
+		return 0, fmt.Errorf("rate limiter refused paging: %w", err)
+	}
+	scroller := NewResultsScroller(tabCtx, urlPattern)
+	cards, err := scroller.Collect(filters.MaxCreators)
+	if err != nil {
+		logger.Warn("Paging stopped early", "error", err)
+	}
+	logger.Info("Paged through result rows", "rows", len(cards))
+	listenMu.Lock()
+	listening = false
+	listenMu.Unlock()
+	wg.Wait()
+
+	creators, batches := collector.snapshot()
+	if filters.MaxCreators > 0 && len(creators) > filters.MaxCreators {
+		creators = creators[:filters.MaxCreators]
+	}
+	logger.Info("Discovery finished", "batches", batches, "creators", len(creators))
+
+	inserted, err := socialProfileRepo.InsertDiscoveredProfiles(ctx, creators)
+	if err != nil {
+		metrics.DBError(metrics.DB_POSTGRES, "insert_discovered_profiles")
+	}
+	return inserted, err
+}
+
+// applyDiscoveryFilters opens each filter dropdown and ticks the requested options. Every change of
+// filter reloads the results, so each one goes through the limiter as a search.
+func applyDiscoveryFilters(ctx context.Context, filters DiscoveryFilters, limiter *ratelimit.Limiter, account string) error {
+	groups := []struct {
+		label   string
+		options []string
+	}{
+		{FILTER_GROUP_CATEGORY, filters.Categories},
+		{FILTER_GROUP_FOLLOWER, filters.FollowerTiers},
+		{FILTER_GROUP_PRICE, filters.PriceRanges},
+		{FILTER_GROUP_LANGUAGE, filters.Languages},
+	}
+
+	for _, group := range groups {
+		if len(group.options) == 0 {
+			continue
+		}
+		groupSel := fmt.Sprintf(FILTER_GROUP_ELEM, group.label)
+		for _, option := range group.options {
+			if err := limiter.Wait(ctx, account, ratelimit.ActionSearch); err != nil {
+				return fmt.Errorf("rate limiter refused filter %s=%s: %w", group.label, option, err)
+			}
+			optionSel := fmt.Sprintf(FILTER_OPTION_ELEM, option)
+			logging.Stage(ctx, "filter").Info("Applying filter", "group", group.label, "option", option)
+			if err := chromedp.Run(ctx,
+				chromedp.WaitVisible(groupSel, chromedp.BySearch),
+				chromedp.Click(groupSel, chromedp.BySearch),
+				chromedp.Sleep(1*time.Second),
+				chromedp.WaitVisible(optionSel, chromedp.BySearch),
+				chromedp.Click(optionSel, chromedp.BySearch),
+				chromedp.Sleep(2*time.Second),
+			); err != nil {
+				return fmt.Errorf("failed to apply filter %s=%s: %w", group.label, option, err)
+			}
+		}
+		// Close the dropdown before moving to the next group
+		if err := chromedp.Run(ctx, chromedp.KeyEvent("\u001b"), chromedp.Sleep(1*time.Second)); err != nil {
+			return fmt.Errorf("failed to close filter %s: %w", group.label, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"tto_chromedp/pkg/apierror"
+	"tto_chromedp/pkg/assets"
+	"tto_chromedp/pkg/changes"
+	"tto_chromedp/pkg/checkpoint"
+	"tto_chromedp/pkg/distribution"
+	"tto_chromedp/pkg/growth"
+	"tto_chromedp/pkg/interest"
+	"tto_chromedp/pkg/ledger"
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/metrics"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/mongodb"
+	"tto_chromedp/pkg/postgre"
+	"tto_chromedp/pkg/ratelimit"
+	"tto_chromedp/pkg/region"
+	"tto_chromedp/pkg/shutdown"
+	"tto_chromedp/pkg/sink"
+	"tto_chromedp/pkg/taxonomy"
+	"tto_chromedp/pkg/utils"
+)
+
+// enricher holds what an enrich run needs, so the one-shot crawl command and the serve scheduler
+// run the same loop.
+type enricher struct {
+	socialProfileRepo postgre.SocialProfileRepository
+	crawlRunRepo      postgre.CrawlRunRepository
+	countryRepository mongodb.CountryDetailRepository
+	taxonomyService   *taxonomy.Service
+	growthAggregator  *growth.Aggregator
+	interestWeights   interest.Weights
+	normalizer        *distribution.Normalizer
+	changeDetector    *changes.Detector
+	changeSink        changes.Sink
+	results           *sink.Fanout
+	assetPipeline     *assets.Pipeline
+	regionConfig      region.Config
+	limiter           *ratelimit.Limiter
+	shut              *shutdown.Shutdown
+
+	maxAttempts int
+	// failureBackoff holds a profile out of the selection after a failed or partial crawl, doubled at
+	// each consecutive failure; it is skipped after maxFailures of them.
+	failureBackoff time.Duration
+	maxFailures    int
+	urlPattern     string
+	statePath      string
+	userAgent      string
+	profileName    string
+	reportDir      string
+	checkpointDir  string
+
+	// between, when set, is called before each profile of a run; serve mode runs the on-demand crawls
+	// queued meanwhile there, so they do not wait for the end of a batch.
+	between func()
+}
+
+// newCheckpoint selects the profiles of a new run and saves its checkpoint. It returns nil when the
+// selection is empty.
+func (e *enricher) newCheckpoint(ctx context.Context, runID, mode string, selection models.CrawlSelection) (*checkpoint.Checkpoint, error) {
+	kolsToCrawl, err := e.socialProfileRepo.GetSocialProfileCrawlTTO(ctx, selection)
+	if err != nil {
+		metrics.DBError(metrics.DB_POSTGRES, "get_profiles_to_crawl")
+		return nil, fmt.Errorf("failed to get KOLs to crawl from PostgreSQL: %w", err)
+	}
+	if len(kolsToCrawl) == 0 {
+		return nil, nil
+	}
+	return checkpoint.New(e.checkpointDir, runID, mode, e.profileName, selection, kolsToCrawl)
+}
+
+// run crawls the profiles of the checkpoint, records every outcome in the ledger, the checkpoint and
+// crawler.crawl_runs, and writes the run reports. resumed reopens the ledger of an interrupted run.
+func (e *enricher) run(ctx context.Context, runID, mode string, runCheckpoint *checkpoint.Checkpoint, resumed bool) models.CrawlRun {
+	ctx = logging.With(ctx, logging.KEY_RUN_ID, runID)
+	logger := logging.FromContext(ctx)
+	outcomes := apierror.NewCounter()
+
+	logger.Info("Starting KOL crawling process", "kols", runCheckpoint.Remaining())
+	metrics.RunStarted()
+	defer metrics.RunFinished()
+
+	runLedger := ledger.New(runID, mode, e.profileName)
+	if resumed {
+		runLedger = ledger.Resume(runID, mode, e.profileName, runCheckpoint.StartedAt, runCheckpoint.Entries())
+	}
+	e.startRun(ctx, runLedger)
+
+	// record adds the outcome to the ledger and the checkpoint. An aborted KOL is released so that a
+	// resume crawls it again.
+	record := func(entry models.CrawlRunEntry) {
+		runLedger.Record(entry)
+		var err error
+		if entry.Outcome == models.CrawlOutcomeAborted {
+			err = runCheckpoint.Release(entry.SocialProfileID)
+		} else {
+			err = runCheckpoint.MarkDone(entry)
+		}
+		if err != nil {
+			logger.Warn("Failed to save checkpoint", "error", err)
+		}
+	}
+
+	runStatus := models.CrawlRunFinished
+	for {
+		if e.shut.IsRequested() {
+			logger.Warn("Shutdown requested, not starting another KOL")
+			runStatus = models.CrawlRunAborted
+			break
+		}
+		if e.between != nil {
+			e.between()
+		}
+		kol, ok, err := runCheckpoint.Next()
+		if err != nil {
+			logger.Warn("Failed to save checkpoint", "error", err)
+		}
+		if !ok {
+			break
+		}
+		entry, _, err := e.crawlProfile(ctx, kol, outcomes)
+		record(entry)
+		if err != nil {
+			runStatus = models.CrawlRunAborted
+			break
+		}
+	}
+
+	// --- Final Summary ---
+	if err := runCheckpoint.Finish(runStatus); err != nil {
+		logger.Warn("Failed to save checkpoint", "error", err)
+	}
+	if runStatus == models.CrawlRunAborted {
+		logger.Info("Run can be continued", "command", "crawl --resume "+runID)
+	}
+	return e.finishRun(ctx, runLedger, runStatus, outcomes)
+}
+
+// startRun stores the first row of crawler.crawl_runs. Runs without a database only keep the reports.
+func (e *enricher) startRun(ctx context.Context, runLedger *ledger.Ledger) {
+	if e.crawlRunRepo == nil {
+		return
+	}
+	if err := e.crawlRunRepo.StartCrawlRun(ctx, runLedger.Run()); err != nil {
+		metrics.DBError(metrics.DB_POSTGRES, "start_crawl_run")
+		logging.FromContext(ctx).Error("Failed to record crawl run start", "error", err)
+	}
+}
+
+// finishRun closes the ledger with runStatus, prints and writes the run reports and stores the final
+// row of crawler.crawl_runs when there is a database.
+func (e *enricher) finishRun(ctx context.Context, runLedger *ledger.Ledger, runStatus string, outcomes *apierror.Counter) models.CrawlRun {
+	logger := logging.FromContext(ctx)
+	runLedger.Finish(runStatus)
+	if err := runLedger.WriteTable(os.Stdout); err != nil {
+		logger.Warn("Failed to print run summary", "error", err)
+	}
+	if jsonPath, csvPath, err := runLedger.WriteReports(e.reportDir); err != nil {
+		logger.Error("Failed to write run reports", "error", err)
+	} else {
+		logger.Info("Run reports written", "json", jsonPath, "csv", csvPath)
+	}
+	if e.crawlRunRepo != nil {
+		flushCtx, cancelFlush := shutdown.FlushContext(ctx)
+		defer cancelFlush()
+		if err := e.crawlRunRepo.FinishCrawlRun(flushCtx, runLedger.Run()); err != nil {
+			metrics.DBError(metrics.DB_POSTGRES, "finish_crawl_run")
+			logger.Error("Failed to record crawl run", "error", err)
+		}
+	}
+	logger.Info("Final summary", "status", runStatus, "kols", runLedger.Counts(), "classes", outcomes.String())
+	if unresolved := e.countryRepository.UnresolvedCodes(); len(unresolved) > 0 {
+		logger.Warn("Country codes that could not be resolved", "codes", unresolved)
+	}
+	return runLedger.Run()
+}
+
+// crawlProfile crawls one profile, stores its data and returns its ledger entry, plus the parsed data
+// when it was complete. The error is only set when the crawl was interrupted and the run must stop;
+// the entry is then aborted.
+func (e *enricher) crawlProfile(ctx context.Context, kol models.SocialProfile, outcomes *apierror.Counter) (models.CrawlRunEntry, *models.TTOUser, error) {
+	kolCtx := logging.With(ctx, logging.KEY_KOL_ID, kol.ID, logging.KEY_KOL, kol.UserName)
+	kolLog := logging.FromContext(kolCtx)
+	entry := models.CrawlRunEntry{SocialProfileID: kol.ID, UserName: kol.UserName, Account: e.profileName, StartedAt: time.Now()}
+
+	regions := e.regionConfig.Candidates(kol.Region)
+	crawledData, usedRegion, attempts, apiErr, err := crawlKolWithRetry(kolCtx, kol, e.urlPattern, e.statePath, e.userAgent, e.profileName, e.limiter, e.maxAttempts, regions, e.assetPipeline)
+	entry.Attempts = attempts
+	entry.Region = usedRegion
+	if err != nil {
+		kolLog.Error("Stopping crawl", "error", err)
+		if apiErr != nil {
+			outcomes.Add(apiErr.Class)
+			metrics.KOLProcessed(string(apiErr.Class))
+			entry.ErrorClass = string(apiErr.Class)
+		}
+		return finishEntry(entry, models.CrawlOutcomeAborted, err), nil, err
+	}
+	if apiErr != nil {
+		outcomes.Add(apiErr.Class)
+		metrics.KOLProcessed(string(apiErr.Class))
+		entry.ErrorClass = string(apiErr.Class)
+		if apiErr.Decision() != apierror.DecisionSkip {
+			kolLog.Warn("Giving up on KOL", "class", apiErr.Class, "error", apiErr)
+			e.recordFailure(kolCtx, kol)
+			return finishEntry(entry, models.CrawlOutcomeFailed, apiErr), nil, nil
+		}
+		kolLog.Warn("Skipping KOL permanently", "class", apiErr.Class, "error", apiErr)
+		return e.markSkipped(kolCtx, kol, entry, crawledData, usedRegion, apiErr), nil, nil
+	}
+	outcomes.Add(apierror.ClassSuccess)
+	metrics.KOLProcessed(string(apierror.ClassSuccess))
+	kolLog.Info("Successfully crawled creator", "responses", len(crawledData), "region", usedRegion)
+	stopParse := metrics.StageTimer(metrics.STAGE_PARSE)
+	userInfo, isFull := parseUserData(logging.With(kolCtx, logging.KEY_STAGE, "parse"), crawledData, e.countryRepository, e.taxonomyService, e.growthAggregator, e.interestWeights, e.normalizer)
+	stopParse()
+	if !isFull {
+		kolLog.Warn("Incomplete data collected, nothing stored")
+		e.recordFailure(kolCtx, kol)
+		return finishEntry(entry, models.CrawlOutcomePartial, nil), nil, nil
+	}
+
+	kolLog.Info("Full data collected")
+	kolLog.Debug("Parsed user info", "user_info", *userInfo)
+
+	userInfo.UpdatedAt = time.Now()
+	userInfo.CreatorStatus = utils.TTO_CREATOR_STATUS_DONE
+	userInfo.Region = storedRegion(crawledData, usedRegion)
+
+	// The data of the KOL in flight is written even when a shutdown cancels ctx meanwhile
+	writeCtx, cancelWrite := shutdown.FlushContext(kolCtx)
+	defer cancelWrite()
+	// Changes are detected against the stored row, before the postgres sink overwrites it
+	if e.results.Has(sink.SINK_POSTGRES) {
+		recordChanges(logging.With(writeCtx, logging.KEY_STAGE, "changes"), e.socialProfileRepo, e.changeDetector, e.changeSink, kol, userInfo)
+	}
+
+	// Store the collected data in every result sink
+	stopPersist := metrics.StageTimer(metrics.STAGE_PERSIST)
+	err = e.results.Write(writeCtx, kol, userInfo)
+	stopPersist()
+	if err != nil {
+		kolLog.Error("Failed to store TTO data", logging.KEY_STAGE, "store", "error", err)
+		entry.ErrorClass = ledger.ERROR_CLASS_PERSIST
+		e.recordFailure(kolCtx, kol)
+		return finishEntry(entry, models.CrawlOutcomeFailed, err), userInfo, nil
+	}
+	return finishEntry(entry, models.CrawlOutcomeSuccess, nil), userInfo, nil
+}
+
+// markSkipped stores the skipped status of a profile TTO will not serve (e.g. a banned creator). The
+// entry is only a skip once that status is committed; when the write fails it is a failed entry, so
+// that the job is retried rather than acked.
+func (e *enricher) markSkipped(ctx context.Context, kol models.SocialProfile, entry models.CrawlRunEntry, crawledData []CollectedData, usedRegion string, apiErr *apierror.Error) models.CrawlRunEntry {
+	if !e.results.Has(sink.SINK_POSTGRES) {
+		return finishEntry(entry, models.CrawlOutcomeSkipped, apiErr)
+	}
+	writeCtx, cancelWrite := shutdown.FlushContext(ctx)
+	defer cancelWrite()
+	// A banned creator is skipped before parsing, its health is still compared with the stored one
+	if health := capturedHealth(crawledData); health != nil {
+		current := &models.TTOUser{Health: health, UpdatedAt: time.Now(), Region: storedRegion(crawledData, usedRegion)}
+		recordChanges(logging.With(writeCtx, logging.KEY_STAGE, "changes"), e.socialProfileRepo, e.changeDetector, e.changeSink, kol, current)
+	}
+	if err := e.socialProfileRepo.UpdateTTOCreatorStatus(writeCtx, kol.ID, utils.TTO_CREATOR_STATUS_SKIPPED); err != nil {
+		metrics.DBError(metrics.DB_POSTGRES, "update_creator_status")
+		logging.FromContext(ctx).Error("Failed to mark KOL as skipped", "error", err)
+		entry.ErrorClass = ledger.ERROR_CLASS_PERSIST
+		e.recordFailure(ctx, kol)
+		return finishEntry(entry, models.CrawlOutcomeFailed, err)
+	}
+	return finishEntry(entry, models.CrawlOutcomeSkipped, apiErr)
+}
+
+// recordFailure backs the profile off after a failed or partial crawl, so the pending selection does
+// not hand it out again on the next poll.
+func (e *enricher) recordFailure(ctx context.Context, kol models.SocialProfile) {
+	if !e.results.Has(sink.SINK_POSTGRES) {
+		return
+	}
+	writeCtx, cancelWrite := shutdown.FlushContext(ctx)
+	defer cancelWrite()
+	failures, err := e.socialProfileRepo.RecordTTOCrawlFailure(writeCtx, kol.ID, e.failureBackoff, e.maxFailures)
+	if err != nil {
+		metrics.DBError(metrics.DB_POSTGRES, "record_crawl_failure")
+		logging.FromContext(ctx).Error("Failed to record crawl failure", "error", err)
+		return
+	}
+	if e.maxFailures > 0 && failures >= e.maxFailures {
+		logging.FromContext(ctx).Warn("Skipping KOL after consecutive failed crawls", "failures", failures)
+	}
+}
+
+// finishEntry sets the outcome, error and duration of a ledger entry.
+func finishEntry(entry models.CrawlRunEntry, outcome string, err error) models.CrawlRunEntry {
+	entry.Outcome = outcome
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	entry.DurationMs = time.Since(entry.StartedAt).Milliseconds()
+	return entry
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"tto_chromedp/pkg/apierror"
+	"tto_chromedp/pkg/ledger"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/postgre"
+	"tto_chromedp/pkg/sink"
+	"tto_chromedp/pkg/utils"
+)
+
+// statusRepo records the creator status writes and the crawl failures of the skip path.
+type statusRepo struct {
+	postgre.SocialProfileRepository
+	statusErr error
+	statuses  map[int]int
+	failures  []int
+}
+
+func (r *statusRepo) UpdateTTOCreatorStatus(ctx context.Context, userID int, status int) error {
+	if r.statusErr != nil {
+		return r.statusErr
+	}
+	r.statuses[userID] = status
+	return nil
+}
+
+func (r *statusRepo) RecordTTOCrawlFailure(ctx context.Context, userID int, backoff time.Duration, maxFailures int) (int, error) {
+	r.failures = append(r.failures, userID)
+	return len(r.failures), nil
+}
+
+func TestMarkSkipped(t *testing.T) {
+	hidden := &apierror.Error{Class: apierror.ClassCreatorHidden, Message: "creator is hidden"}
+	kol := models.SocialProfile{ID: 7, UserName: "alice"}
+
+	tests := []struct {
+		name      string
+		statusErr error
+		outcome   string
+		class     string
+	}{
+		{"status stored", nil, models.CrawlOutcomeSkipped, string(apierror.ClassCreatorHidden)},
+		{"status write fails", errors.New("connection reset"), models.CrawlOutcomeFailed, ledger.ERROR_CLASS_PERSIST},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &statusRepo{statusErr: tt.statusErr, statuses: make(map[int]int)}
+			results, err := sink.New([]string{sink.SINK_POSTGRES}, sink.Options{Postgres: repo})
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := &enricher{socialProfileRepo: repo, results: results}
+			entry := models.CrawlRunEntry{SocialProfileID: kol.ID, ErrorClass: string(hidden.Class), StartedAt: time.Now()}
+
+			got := e.markSkipped(context.Background(), kol, entry, nil, "vn", hidden)
+			// consumeJob only acks success and skipped entries
+			if got.Outcome != tt.outcome || got.ErrorClass != tt.class {
+				t.Fatalf("entry = %+v, want outcome %s and class %s", got, tt.outcome, tt.class)
+			}
+			if tt.statusErr == nil {
+				if repo.statuses[kol.ID] != utils.TTO_CREATOR_STATUS_SKIPPED || len(repo.failures) != 0 {
+					t.Fatalf("statuses = %v, failures = %v", repo.statuses, repo.failures)
+				}
+				return
+			}
+			if got.Error != tt.statusErr.Error() || len(repo.failures) != 1 {
+				t.Fatalf("entry = %+v, failures = %v", got, repo.failures)
+			}
+		})
+	}
+}
+
+func TestMarkSkippedWithoutPostgres(t *testing.T) {
+	results, err := sink.New([]string{sink.SINK_JSONL}, sink.Options{RunID: "run1", OutputDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer results.Close()
+	e := &enricher{results: results}
+	got := e.markSkipped(context.Background(), models.SocialProfile{ID: 7}, models.CrawlRunEntry{}, nil, "vn", &apierror.Error{Class: apierror.ClassCreatorHidden})
+	if got.Outcome != models.CrawlOutcomeSkipped {
+		t.Fatalf("outcome = %s, want skipped", got.Outcome)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/utils"
+)
+
+// INPUT_STDIN is the -input value reading the handle list from stdin.
+const INPUT_STDIN = "-"
+
+// inputHandleColumns are the header names of the handle column of a CSV input, in order of preference.
+var inputHandleColumns = []string{"username", "handle", "url", "profile_url"}
+
+// readKolList reads the handle list of -input: a CSV file (an optional header naming a username,
+// handle or url column and an id column, otherwise one handle per line), or a JSON array of KolData
+// objects or of handles. The format of stdin is guessed from its first character.
+func readKolList(path string, stdin io.Reader) ([]KolData, error) {
+	var data []byte
+	var err error
+	if path == INPUT_STDIN {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input %s: %w", path, err)
+	}
+
+	isJSON := strings.EqualFold(filepath.Ext(path), ".json")
+	if path == INPUT_STDIN {
+		trimmed := bytes.TrimSpace(data)
+		isJSON = len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{')
+	}
+	var kols []KolData
+	if isJSON {
+		kols, err = parseJSONKolList(data)
+	} else {
+		kols, err = parseCSVKolList(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse input %s: %w", path, err)
+	}
+	return kols, nil
+}
+
+// parseJSONKolList accepts the IDs as strings or numbers, and the handle under "username" or "handle".
+func parseJSONKolList(data []byte) ([]KolData, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	kols := make([]KolData, 0, len(items))
+	for i, item := range items {
+		var handle string
+		if err := json.Unmarshal(item, &handle); err == nil {
+			kols = append(kols, KolData{Username: handle})
+			continue
+		}
+		var entry struct {
+			ID       any    `json:"id"`
+			Username string `json:"username"`
+			Handle   string `json:"handle"`
+		}
+		decoder := json.NewDecoder(bytes.NewReader(item))
+		decoder.UseNumber()
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		if entry.Username == "" {
+			entry.Username = entry.Handle
+		}
+		kol := KolData{Username: entry.Username}
+		switch id := entry.ID.(type) {
+		case json.Number:
+			kol.ID = id.String()
+		case string:
+			kol.ID = id
+		}
+		kols = append(kols, kol)
+	}
+	return kols, nil
+}
+
+// parseCSVKolList skips the blank lines and the lines starting with #.
+func parseCSVKolList(data []byte) ([]KolData, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var kols []KolData
+	handleColumn, idColumn := 0, -1
+	first := true
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			first = false
+			if column, id, ok := csvHeaderColumns(record); ok {
+				handleColumn, idColumn = column, id
+				continue
+			}
+		}
+		if handleColumn >= len(record) {
+			continue
+		}
+		kol := KolData{Username: record[handleColumn]}
+		if idColumn >= 0 && idColumn < len(record) {
+			kol.ID = strings.TrimSpace(record[idColumn])
+		}
+		kols = append(kols, kol)
+	}
+	return kols, nil
+}
+
+// csvHeaderColumns finds the handle and id columns when record is a header.
+func csvHeaderColumns(record []string) (int, int, bool) {
+	columns := make(map[string]int, len(record))
+	for i, name := range record {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	idColumn := -1
+	for _, name := range []string{"id", "social_profile_id"} {
+		if i, ok := columns[name]; ok {
+			idColumn = i
+			break
+		}
+	}
+	for _, name := range inputHandleColumns {
+		if i, ok := columns[name]; ok {
+			return i, idColumn, true
+		}
+	}
+	return 0, -1, false
+}
+
+// kolProfiles normalises the handles of kols and drops the blank and repeated ones. The profiles keep
+// the IDs of the list when they are all distinct numbers, otherwise they are numbered from 1 in list
+// order, so that the checkpoint, the run ledger and the exports can tell them apart.
+func kolProfiles(kols []KolData) []models.SocialProfile {
+	profiles := make([]models.SocialProfile, 0, len(kols))
+	ids := make(map[int]bool, len(kols))
+	seen := make(map[string]bool, len(kols))
+	keepIDs := true
+	for _, kol := range kols {
+		handle := utils.NormalizeHandle(kol.Username)
+		if handle == "" || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		id, err := strconv.Atoi(strings.TrimSpace(kol.ID))
+		if err != nil || id <= 0 || ids[id] {
+			keepIDs = false
+		}
+		ids[id] = true
+		profiles = append(profiles, models.SocialProfile{ID: id, UserName: handle})
+	}
+	if !keepIDs {
+		for i := range profiles {
+			profiles[i].ID = i + 1
+		}
+	}
+	return profiles
+}
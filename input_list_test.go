@@ -0,0 +1,183 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tto_chromedp/pkg/models"
+)
+
+func TestParseCSVKolList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []KolData
+	}{
+		{
+			name:  "headerless: one handle per line",
+			input: "alice\n\n@bob\n",
+			want:  []KolData{{Username: "alice"}, {Username: "@bob"}},
+		},
+		{
+			name:  "header with id and username",
+			input: "id,username\n12,alice\n 15 , bob\n",
+			want:  []KolData{{ID: "12", Username: "alice"}, {ID: "15", Username: "bob"}},
+		},
+		{
+			name:  "header columns in any case and order, url column",
+			input: "Name,URL,Social_Profile_ID\nAlice,https://www.tiktok.com/@alice,3\n",
+			want:  []KolData{{ID: "3", Username: "https://www.tiktok.com/@alice"}},
+		},
+		{
+			name:  "username preferred over url",
+			input: "url,username\nhttps://www.tiktok.com/@a,b\n",
+			want:  []KolData{{Username: "b"}},
+		},
+		{
+			name:  "comments and short rows",
+			input: "# exported 2024-05-01\nid,handle\n# 1,skipped\n2,carol\n3\n",
+			want:  []KolData{{ID: "2", Username: "carol"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCSVKolList([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSONKolList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []KolData
+	}{
+		{
+			name:  "strings",
+			input: `["alice", "@bob"]`,
+			want:  []KolData{{Username: "alice"}, {Username: "@bob"}},
+		},
+		{
+			name:  "objects with numeric and string IDs",
+			input: `[{"id": 12, "username": "alice"}, {"id": "15", "handle": "bob"}, {"username": "carol"}]`,
+			want:  []KolData{{ID: "12", Username: "alice"}, {ID: "15", Username: "bob"}, {Username: "carol"}},
+		},
+		{
+			name:  "mixed",
+			input: `["alice", {"id": 9007199254740993, "username": "bob"}]`,
+			want:  []KolData{{Username: "alice"}, {ID: "9007199254740993", Username: "bob"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJSONKolList([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseJSONKolList([]byte(`[1]`)); err == nil {
+		t.Error("a bare number was accepted as an entry")
+	}
+	if _, err := parseJSONKolList([]byte(`{"username": "alice"}`)); err == nil {
+		t.Error("an object outside an array was accepted")
+	}
+}
+
+func TestReadKolList(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "kols.JSON")
+	csvPath := filepath.Join(dir, "kols.csv")
+	if err := os.WriteFile(jsonPath, []byte(`["alice"]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(csvPath, []byte("username\nbob\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		stdin string
+		want  []KolData
+	}{
+		{"json file", jsonPath, "", []KolData{{Username: "alice"}}},
+		{"csv file", csvPath, "", []KolData{{Username: "bob"}}},
+		{"json on stdin", INPUT_STDIN, "\n  [\"carol\"]", []KolData{{Username: "carol"}}},
+		{"csv on stdin", INPUT_STDIN, "carol\ndave\n", []KolData{{Username: "carol"}, {Username: "dave"}}},
+		// Nothing on stdin is an empty list, not an error
+		{"empty stdin", INPUT_STDIN, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readKolList(tt.path, strings.NewReader(tt.stdin))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := readKolList(filepath.Join(dir, "missing.csv"), nil); err == nil {
+		t.Error("a missing input file was accepted")
+	}
+	if _, err := readKolList(INPUT_STDIN, strings.NewReader(`{"username": "alice"}`)); err == nil {
+		t.Error("a JSON object on stdin was accepted")
+	}
+}
+
+func TestKolProfiles(t *testing.T) {
+	tests := []struct {
+		name string
+		kols []KolData
+		want []models.SocialProfile
+	}{
+		{
+			name: "distinct numeric IDs are kept",
+			kols: []KolData{{ID: "12", Username: "@Alice"}, {ID: " 7 ", Username: "https://www.tiktok.com/@bob/video/1?lang=en"}},
+			want: []models.SocialProfile{{ID: 12, UserName: "alice"}, {ID: 7, UserName: "bob"}},
+		},
+		{
+			name: "repeated and blank handles are dropped",
+			kols: []KolData{{ID: "1", Username: "alice"}, {ID: "2", Username: "m.tiktok.com/@ALICE"}, {ID: "3", Username: "@"}, {ID: "4", Username: "bob"}},
+			want: []models.SocialProfile{{ID: 1, UserName: "alice"}, {ID: 4, UserName: "bob"}},
+		},
+		{
+			name: "missing IDs: renumbered in list order",
+			kols: []KolData{{ID: "12", Username: "alice"}, {Username: "bob"}},
+			want: []models.SocialProfile{{ID: 1, UserName: "alice"}, {ID: 2, UserName: "bob"}},
+		},
+		{
+			name: "repeated IDs: renumbered",
+			kols: []KolData{{ID: "5", Username: "alice"}, {ID: "5", Username: "bob"}},
+			want: []models.SocialProfile{{ID: 1, UserName: "alice"}, {ID: 2, UserName: "bob"}},
+		},
+		{
+			name: "non-numeric or negative IDs: renumbered",
+			kols: []KolData{{ID: "abc", Username: "alice"}, {ID: "-3", Username: "bob"}},
+			want: []models.SocialProfile{{ID: 1, UserName: "alice"}, {ID: 2, UserName: "bob"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kolProfiles(tt.kols); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/postgre"
+)
+
+// runLabelReview lists the TTO labels waiting for a CMS mapping, or approves one when mapping is
+// given as LABEL_ID=CONTENT_INTEREST_ID.
+func runLabelReview(ctx context.Context, socialProfileRepo postgre.SocialProfileRepository, mapping string) error {
+	if mapping != "" {
+		labelID, cmsID, ok := strings.Cut(mapping, "=")
+		if !ok {
+			return fmt.Errorf("invalid label mapping %q, expected LABEL_ID=CONTENT_INTEREST_ID", mapping)
+		}
+		contentInterestID, err := strconv.Atoi(strings.TrimSpace(cmsID))
+		if err != nil {
+			return fmt.Errorf("invalid content interest ID %q: %w", cmsID, err)
+		}
+		if err := socialProfileRepo.ApproveLabelMapping(ctx, strings.TrimSpace(labelID), contentInterestID); err != nil {
+			return err
+		}
+		logging.FromContext(ctx).Info("Mapped TTO label to content interest", "label_id", labelID, "content_interest_id", contentInterestID)
+		return nil
+	}
+
+	labels, err := socialProfileRepo.ListPendingLabels(ctx)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("TTO labels waiting for review", "labels", len(labels))
+	for _, label := range labels {
+		fmt.Printf("%s\t%s\t%s\tseen %d times, last %s\n", label.LabelID, label.LabelName, label.Kind, label.SeenCount, label.LastSeenAt.Format("2006-01-02"))
+	}
+	return nil
+}
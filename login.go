@@ -3,11 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
-	"os" // Required for profile path handling
-	"path/filepath"
+	"os"
 	"time"
 
+	"tto_chromedp/pkg/chromeprofile"
+	"tto_chromedp/pkg/logging"
+
 	"github.com/chromedp/cdproto/emulation" // <--- ADDED EMULATION IMPORT
 	"github.com/chromedp/cdproto/network"
 
@@ -20,13 +21,13 @@ const (
 	// Placeholder for the login page and expected dashboard URL
 	// Updated URLs based on the user's local environment testing
 	PARTNER_TIKTOKSHOP_LOGIN_URL = "https://ads.tiktok.com/i18n/login?redirect=https%3A%2F%2Fads.tiktok.com%2Fcreative%2Flogin%3Fredirect%3Dhttps%253A%252F%252Fads.tiktok.com%252Fcreative%252Fcreator%252Fexplore%253Fregion%253Drow&_source_=tiktok-one" // Replace with actual login URL
-	PARTNER_TIKSHOP_HOME_URL     = "https://ads.tiktok.com/creative/creator/explore?region=row&from_creative=login"                                                                                                                                         // Replace with actual home URL
 
 	EMAIL_SELECTOR    = `input[placeholder="Enter your email address"]`
 	PASSWORD_SELECTOR = `input[placeholder="Enter your password"]`
 	LOGIN_BUTTON      = `.login-btn`
 
-	DEFAULT_USER_AGENT = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36"
+	// How long the browser stays open after the session state is saved
+	LOGIN_CLOSE_DELAY = 5 * time.Second
 )
 
 // UserState holds the necessary session data (cookies and local storage)
@@ -36,8 +37,10 @@ type UserState struct {
 	// but can be added via custom JavaScript execution actions.
 }
 
-// simulateLogin logs into the platform and saves the session state (cookies).
+// simulateLogin logs into the platform and saves the session state (cookies). The browser is closed
+// when ctx is cancelled.
 func simulateLogin(
+	ctx context.Context,
 	loginURL string,
 	username string,
 	password string,
@@ -47,13 +50,20 @@ func simulateLogin(
 	headless bool,
 	proxy string, // Note: Proxy configuration is more complex in chromedp and often requires external tools or specific transport settings.
 ) error {
+	logger := logging.Stage(ctx, "login").With(logging.KEY_ACCOUNT, profileName)
+	if err := unlockProfile(ctx, profileName); err != nil {
+		return err
+	}
 	opts := initOPTTTS(profileName, headless, userAgent)
 
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
 	defer cancel()
 
-	// Create browser context with a timeout for the entire login process
-	taskCtx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+	// Create browser context with a timeout for the entire login process; the chromedp messages go to
+	// the debug level of the stage logger
+	taskCtx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(func(format string, args ...any) {
+		logger.Debug(fmt.Sprintf(format, args...))
+	}))
 	defer cancel()
 
 	// Apply context settings for realism (locale, timezone, viewport)
@@ -81,7 +91,7 @@ func simulateLogin(
 	err := chromedp.Run(interactionCtx,
 		// Navigate to login page
 		chromedp.ActionFunc(func(ctx context.Context) error {
-			log.Printf("Navigating to %s", loginURL)
+			logger.Info("Navigating to login page", "url", loginURL)
 			return nil
 			// return network.SetExtraHTTPHeaders(network.Headers{
 			// 	"Accept-Language": "vi-VN,vi;q=0.9,en-US;q=0.8,en;q=0.7", // Adjusting Accept-Language based on vi-VN locale
@@ -104,18 +114,18 @@ func simulateLogin(
 			err := chromedp.Evaluate(js, &isEmailLocatorPresent).Do(ctx)
 			if err != nil {
 				// Log the error but continue, assuming the element is not present if the evaluation fails
-				log.Printf("Error during locator existence check: %v", err)
+				logger.Warn("Locator existence check failed", "selector", EMAIL_SELECTOR, "error", err)
 				isEmailLocatorPresent = false
 			}
 			// Print the result as requested by the user
-			log.Printf("Check for locator '%s': Exists = %t, %s, %s", EMAIL_SELECTOR, isEmailLocatorPresent, username, password)
+			logger.Debug("Checked login form locator", "selector", EMAIL_SELECTOR, "exists", isEmailLocatorPresent)
 			return nil
 		}),
 		// --- END: LOCATOR EXISTENCE CHECK ---
 
 		// Fill the form with added delays
 		chromedp.ActionFunc(func(ctx context.Context) error {
-			log.Println("Filling login form...")
+			logger.Info("Filling login form")
 			return chromedp.Tasks{
 				// Use Clear and SetValue for reliability (more direct than Click + SendKeys)
 				chromedp.Clear(EMAIL_SELECTOR, chromedp.ByQuery),
@@ -133,7 +143,7 @@ func simulateLogin(
 
 		// **RESTORED CRITICAL STEP:** Wait for the URL to change to the home page
 		chromedp.ActionFunc(func(ctx context.Context) error {
-			log.Println("Waiting for navigation to the home page after login...")
+			logger.Info("Waiting for navigation to the home page after login")
 			// Loop until the URL changes to the expected home URL or timeout
 			for i := 0; i < 60; i++ { // Check for up to 60 seconds (half of the context timeout)
 				time.Sleep(1 * time.Second)
@@ -143,7 +153,7 @@ func simulateLogin(
 				}
 				// Check for successful redirection
 				if url == PARTNER_TIKSHOP_HOME_URL {
-					log.Printf("Successfully redirected to: %s", url)
+					logger.Info("Redirected to the home page", "url", url)
 					currentURL = url
 					return nil
 				}
@@ -164,76 +174,32 @@ func simulateLogin(
 			if len(screenshotData) > 0 {
 				screenshotFile := "dashboard_after_login_go.png"
 				if err := os.WriteFile(screenshotFile, screenshotData, 0644); err != nil {
-					log.Printf("Warning: Failed to save screenshot: %v", err)
+					logger.Warn("Failed to save dashboard screenshot", "path", screenshotFile, "error", err)
 				} else {
-					log.Printf("Screenshot of dashboard saved to '%s'", screenshotFile)
+					logger.Info("Saved dashboard screenshot", "path", screenshotFile)
 				}
 			}
 
-			log.Printf("Saving session state (cookies) to %s...", statePath)
-			log.Printf("Session state saved to %s", statePath)
+			logger.Info("Session state saved", "path", statePath)
 
 			// Keep browser open for a bit
-			log.Println("Login and state saving complete. Closing browser in 5 seconds...")
-			time.Sleep(500 * time.Second)
-			return nil
+			logger.Info("Login complete, closing the browser", "delay", LOGIN_CLOSE_DELAY)
+			return chromedp.Sleep(LOGIN_CLOSE_DELAY).Do(ctx)
 		}),
 	)
 
 	if err != nil {
-		// Log the last known URL on error
-		if currentURL != "" {
-			log.Printf("An error occurred. Last known URL: %s", currentURL)
-		} else {
-			log.Printf("An error occurred: %s", err.Error())
-		}
+		// Log the last known URL, if any, on error
+		logger.Error("Login failed", "last_url", currentURL, "error", err)
 		return err
 	}
 
 	return nil
 }
 
-func main() {
-	// Dummy variables for demonstration (replace with actual configuration)
-	// loginURL := PARTNER_TIKTOKSHOP_LOGIN_URL
-	ttoHomepage := PARTNER_TIKSHOP_HOME_URL
-	// username := "van.le@brancherx.com" // Placeholder
-	// password := "VLantking2013!"       // Placeholder
-	// statePath := "tiktokshop_state_go.json"
-	userAgent := DEFAULT_USER_AGENT
-
-	// err := simulateLogin(
-	// 	loginURL,
-	// 	username,
-	// 	password,
-	// 	statePath,
-	// 	userAgent,
-	// 	"tto",
-	// 	false, // headless
-	// 	"",    // proxy
-	// )
-
-	// if err != nil {
-	// 	log.Fatalf("Login and state saving failed: %v", err)
-	// 	return
-	// }
-
-	if err := visitHomePage(
-		ttoHomepage,
-		userAgent,
-		"tto",
-		false, // headless
-		"",    // proxy
-	); err != nil {
-		log.Fatalf("Login and state saving failed: %v", err)
-	}
-
-	log.Println("Chromedp script finished successfully.")
-}
-
 func initOPTTTS(profileName string, headless bool, userAgent string) []chromedp.ExecAllocatorOption {
 	// --- 1. Set up Chromedp Context and Options ---
-	profilePath := filepath.Join("./profiles", profileName)
+	profilePath := chromeprofile.Dir(profileName)
 
 	// Define browser options (based on BROWSER_ARGS from the Python script for realism)
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
@@ -0,0 +1,3 @@
+-- Explore page region the TTO data was crawled with ("row", "vn", ...).
+ALTER TABLE crawler.social_profiles
+    ADD COLUMN IF NOT EXISTS tiktokshop_region VARCHAR(16);
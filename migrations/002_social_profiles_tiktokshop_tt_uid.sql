@@ -0,0 +1,7 @@
+-- TikTok user ID reported by TTO, used to de-duplicate creators found by discovery mode.
+ALTER TABLE crawler.social_profiles
+    ADD COLUMN IF NOT EXISTS tiktokshop_tt_uid VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS social_profiles_tiktokshop_tt_uid_idx
+    ON crawler.social_profiles (tiktokshop_tt_uid)
+    WHERE tiktokshop_tt_uid IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Curated mapping from TTO content label IDs to the CMS content interest taxonomy.
+CREATE TABLE IF NOT EXISTS crawler.tto_content_interest_mapping (
+    label_id            VARCHAR(64) PRIMARY KEY,
+    label_name          VARCHAR(255) NOT NULL,
+    content_interest_id INTEGER NOT NULL REFERENCES cms.content_interest (id),
+    created_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,14 @@
+-- TTO labels without a curated CMS mapping, waiting for review instead of being inserted into cms.content_interest.
+CREATE TABLE IF NOT EXISTS crawler.tto_label_review_queue (
+    label_id      VARCHAR(64) PRIMARY KEY,
+    label_name    VARCHAR(255) NOT NULL,
+    kind          VARCHAR(32) NOT NULL DEFAULT 'content',
+    seen_count    INTEGER NOT NULL DEFAULT 1,
+    status        VARCHAR(16) NOT NULL DEFAULT 'pending',
+    first_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_seen_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Brands extracted from the creator's sponsored videos, as [{"id", "name", "videos"}].
+ALTER TABLE crawler.social_profiles
+    ADD COLUMN IF NOT EXISTS tiktokshop_brands JSONB;
@@ -0,0 +1,9 @@
+-- Metrics derived from the captured videos, see pkg/kpi.
+ALTER TABLE crawler.social_profiles
+    ADD COLUMN IF NOT EXISTS tiktokshop_kpis JSONB;
+
+-- The campaign matching tool ranks creators by engagement rate and median views.
+CREATE INDEX IF NOT EXISTS idx_social_profiles_tiktokshop_engagement_rate
+    ON crawler.social_profiles (((tiktokshop_kpis ->> 'engagement_rate')::numeric));
+CREATE INDEX IF NOT EXISTS idx_social_profiles_tiktokshop_median_views
+    ON crawler.social_profiles (((tiktokshop_kpis ->> 'median_views')::numeric));
@@ -0,0 +1,4 @@
+-- Stable copies of the signed TTO image URLs, see pkg/assets.
+ALTER TABLE crawler.social_profiles
+    ADD COLUMN IF NOT EXISTS tiktokshop_avatar_url TEXT,
+    ADD COLUMN IF NOT EXISTS tiktokshop_video_covers JSONB;
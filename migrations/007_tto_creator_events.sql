@@ -0,0 +1,18 @@
+-- Account state compared between crawls, see pkg/changes.
+ALTER TABLE crawler.social_profiles
+    ADD COLUMN IF NOT EXISTS tiktokshop_health JSONB;
+
+-- Sharp changes detected between two crawls of a creator.
+CREATE TABLE IF NOT EXISTS crawler.tto_creator_events (
+    id                SERIAL PRIMARY KEY,
+    social_profile_id INTEGER NOT NULL,
+    event_type        VARCHAR(64) NOT NULL,
+    severity          VARCHAR(16) NOT NULL,
+    message           TEXT NOT NULL,
+    previous_value    JSONB,
+    current_value     JSONB,
+    created_at        TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tto_creator_events_profile
+    ON crawler.tto_creator_events (social_profile_id, created_at DESC);
@@ -0,0 +1,18 @@
+-- One row per crawl run with its per-KOL outcomes, see pkg/ledger.
+CREATE TABLE IF NOT EXISTS crawler.crawl_runs (
+    run_id      VARCHAR(32) PRIMARY KEY,
+    mode        VARCHAR(32) NOT NULL,
+    account     VARCHAR(128) NOT NULL,
+    status      VARCHAR(16) NOT NULL,
+    started_at  TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    total       INTEGER NOT NULL DEFAULT 0,
+    succeeded   INTEGER NOT NULL DEFAULT 0,
+    partial     INTEGER NOT NULL DEFAULT 0,
+    skipped     INTEGER NOT NULL DEFAULT 0,
+    failed      INTEGER NOT NULL DEFAULT 0,
+    entries     JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS idx_crawl_runs_started_at
+    ON crawler.crawl_runs (started_at DESC);
@@ -0,0 +1,5 @@
+-- Consecutive failed or partial TTO crawls of a profile and when it may be crawled again, so the
+-- pending selection backs off from broken creators instead of retrying them every poll.
+ALTER TABLE crawler.social_profiles
+    ADD COLUMN IF NOT EXISTS tiktokshop_crawl_failures INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tiktokshop_next_attempt_at TIMESTAMP;
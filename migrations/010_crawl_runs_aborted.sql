@@ -0,0 +1,16 @@
+-- KOLs left unfinished because the run was interrupted, counted apart from the failed ones so that the
+-- failure rate of runs can be compared. The runs stored before this column counted them as failed;
+-- they are split out again from the entries.
+ALTER TABLE crawler.crawl_runs
+    ADD COLUMN IF NOT EXISTS aborted INTEGER NOT NULL DEFAULT 0;
+
+UPDATE crawler.crawl_runs r
+SET aborted = a.n,
+    failed  = r.failed - a.n
+FROM (
+    SELECT run_id, COUNT(*) AS n
+    FROM crawler.crawl_runs, jsonb_array_elements(entries) AS entry
+    WHERE entry->>'outcome' = 'aborted'
+    GROUP BY run_id
+) a
+WHERE r.run_id = a.run_id AND r.aborted = 0;
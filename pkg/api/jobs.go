@@ -0,0 +1,112 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/models"
+)
+
+// Job statuses. The outcome of a finished job is one of models.CrawlOutcomes.
+const (
+	JOB_QUEUED   = "queued"
+	JOB_RUNNING  = "running"
+	JOB_FINISHED = "finished"
+)
+
+// Job is an on-demand crawl of one creator.
+type Job struct {
+	ID          string                `json:"id"`
+	Status      string                `json:"status"`
+	Profile     models.SocialProfile  `json:"profile"`
+	SubmittedAt time.Time             `json:"submitted_at"`
+	StartedAt   *time.Time            `json:"started_at,omitempty"`
+	FinishedAt  *time.Time            `json:"finished_at,omitempty"`
+	Outcome     string                `json:"outcome,omitempty"`
+	Entry       *models.CrawlRunEntry `json:"entry,omitempty"`
+	Result      *models.TTOUser       `json:"tto_user,omitempty"`
+}
+
+// jobStore keeps the jobs in memory. Finished jobs are dropped after the retention.
+type jobStore struct {
+	retention time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+func newJobStore(retention time.Duration) *jobStore {
+	return &jobStore{retention: retention, jobs: make(map[string]*Job)}
+}
+
+// add queues a job for profile. When a job for the same profile is still queued or running, it
+// returns that one instead and false.
+func (s *jobStore) add(profile models.SocialProfile) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(time.Now())
+	for _, job := range s.jobs {
+		if job.Profile.ID == profile.ID && job.Status != JOB_FINISHED {
+			return *job, false
+		}
+	}
+	job := &Job{ID: logging.NewRunID(), Status: JOB_QUEUED, Profile: profile, SubmittedAt: time.Now()}
+	s.jobs[job.ID] = job
+	return *job, true
+}
+
+// queued returns the number of jobs waiting for the worker.
+func (s *jobStore) queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, job := range s.jobs {
+		if job.Status == JOB_QUEUED {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *jobStore) get(id string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+func (s *jobStore) start(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		now := time.Now()
+		job.Status = JOB_RUNNING
+		job.StartedAt = &now
+	}
+}
+
+func (s *jobStore) finish(id string, entry models.CrawlRunEntry, result *models.TTOUser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		now := time.Now()
+		job.Status = JOB_FINISHED
+		job.FinishedAt = &now
+		job.Outcome = entry.Outcome
+		job.Entry = &entry
+		job.Result = result
+	}
+}
+
+// pruneLocked drops the jobs finished more than the retention ago. The caller holds mu.
+func (s *jobStore) pruneLocked(now time.Time) {
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > s.retention {
+			delete(s.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package api serves the HTTP control API of serve mode, so that other services can get fresh TTO
+// data of one creator on demand:
+//
+//	POST /crawl              {"social_profile_id": 123} or {"handle": "name"}, returns the job
+//	GET  /jobs/{id}          status of the job and the parsed TTOUser once it finished
+//	GET  /creators/{handle}  latest TTO data stored for the creator
+//
+// The crawls are submitted to the scheduler, so they share its single worker, browser profile and
+// rate limiter with the batch crawls. With a token, every request must carry it as a bearer token.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/metrics"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/postgre"
+	"tto_chromedp/pkg/scheduler"
+	"tto_chromedp/pkg/utils"
+)
+
+const (
+	// DEFAULT_JOB_RETENTION is how long a finished job stays available on GET /jobs/{id}.
+	DEFAULT_JOB_RETENTION = time.Hour
+	// DEFAULT_MAX_QUEUED is the number of queued jobs above which POST /crawl is refused.
+	DEFAULT_MAX_QUEUED = 50
+)
+
+// CrawlFunc crawls and stores one profile. The error is only set when the crawl was interrupted.
+type CrawlFunc func(ctx context.Context, profile models.SocialProfile) (models.CrawlRunEntry, *models.TTOUser, error)
+
+// Server handles the API requests.
+type Server struct {
+	profiles  postgre.SocialProfileRepository
+	submit    func(task scheduler.Task)
+	crawl     CrawlFunc
+	maxQueued int
+	token     string
+	jobs      *jobStore
+}
+
+// New creates a Server that submits the crawls with submit (scheduler.Submit in serve mode). An empty
+// token leaves the API open.
+func New(profiles postgre.SocialProfileRepository, submit func(task scheduler.Task), crawl CrawlFunc, retention time.Duration, maxQueued int, token string) *Server {
+	return &Server{
+		profiles:  profiles,
+		submit:    submit,
+		crawl:     crawl,
+		maxQueued: maxQueued,
+		token:     token,
+		jobs:      newJobStore(retention),
+	}
+}
+
+// Register adds the API routes to mux.
+func (s *Server) Register(mux *http.ServeMux) {
+	mux.Handle("POST /crawl", RequireToken(s.token, http.HandlerFunc(s.handleCrawl)))
+	mux.Handle("GET /jobs/{id}", RequireToken(s.token, http.HandlerFunc(s.handleJob)))
+	mux.Handle("GET /creators/{handle}", RequireToken(s.token, http.HandlerFunc(s.handleCreator)))
+}
+
+// RequireToken answers 401 to the requests without an "Authorization: Bearer <token>" header. An empty
+// token lets every request through.
+func RequireToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(given)), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="tto"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid API token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CrawlRequest is the body of POST /crawl. One of the fields must be set.
+type CrawlRequest struct {
+	SocialProfileID int    `json:"social_profile_id"`
+	Handle          string `json:"handle"`
+}
+
+// CreatorSnapshot is the response of GET /creators/{handle}.
+type CreatorSnapshot struct {
+	Profile models.SocialProfile `json:"profile"`
+	TTOUser *models.TTOUser      `json:"tto_user"`
+}
+
+func (s *Server) handleCrawl(w http.ResponseWriter, r *http.Request) {
+	var req CrawlRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	handle := utils.NormalizeHandle(req.Handle)
+	if req.SocialProfileID == 0 && handle == "" {
+		writeError(w, http.StatusBadRequest, errors.New("social_profile_id or handle is required"))
+		return
+	}
+
+	profile, status, err := s.findProfile(r.Context(), req.SocialProfileID, handle)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	if s.maxQueued > 0 && s.jobs.queued() >= s.maxQueued {
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("too many queued crawls (%d), try again later", s.maxQueued))
+		return
+	}
+
+	job, created := s.jobs.add(*profile)
+	if created {
+		s.submit(func(ctx context.Context) { s.runJob(ctx, job) })
+		logging.FromContext(r.Context()).Info("On-demand crawl queued", "job_id", job.ID, logging.KEY_KOL_ID, profile.ID, logging.KEY_KOL, profile.UserName)
+	}
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobs.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no job %s", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleCreator(w http.ResponseWriter, r *http.Request) {
+	profile, status, err := s.findProfile(r.Context(), 0, utils.NormalizeHandle(r.PathValue("handle")))
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	user, err := s.profiles.GetTTOUser(r.Context(), profile.ID)
+	if err != nil {
+		metrics.DBError(metrics.DB_POSTGRES, "get_tto_user")
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user.UpdatedAt.IsZero() {
+		writeError(w, http.StatusNotFound, fmt.Errorf("creator %s was never crawled", profile.UserName))
+		return
+	}
+	writeJSON(w, http.StatusOK, CreatorSnapshot{Profile: *profile, TTOUser: user})
+}
+
+// findProfile looks the profile up by ID, or by handle when id is 0. It returns the HTTP status to
+// answer with when it fails.
+func (s *Server) findProfile(ctx context.Context, id int, handle string) (*models.SocialProfile, int, error) {
+	var profile *models.SocialProfile
+	var err error
+	if id != 0 {
+		profile, err = s.profiles.GetSocialProfileByID(ctx, id)
+	} else {
+		profile, err = s.profiles.GetSocialProfileByUserName(ctx, handle)
+	}
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, http.StatusNotFound, errors.New("social profile not found")
+	case err != nil:
+		metrics.DBError(metrics.DB_POSTGRES, "get_social_profile")
+		return nil, http.StatusInternalServerError, err
+	}
+	return profile, http.StatusOK, nil
+}
+
+// runJob runs on the scheduler worker.
+func (s *Server) runJob(ctx context.Context, job Job) {
+	ctx = logging.With(ctx, "job_id", job.ID)
+	s.jobs.start(job.ID)
+	if err := ctx.Err(); err != nil {
+		entry := models.CrawlRunEntry{SocialProfileID: job.Profile.ID, UserName: job.Profile.UserName, Outcome: models.CrawlOutcomeAborted, Error: err.Error()}
+		s.jobs.finish(job.ID, entry, nil)
+		return
+	}
+	entry, user, err := s.crawl(ctx, job.Profile)
+	if err != nil {
+		logging.FromContext(ctx).Warn("On-demand crawl interrupted", "error", err)
+	}
+	s.jobs.finish(job.ID, entry, user)
+	logging.FromContext(ctx).Info("On-demand crawl finished", logging.KEY_KOL, job.Profile.UserName, "outcome", entry.Outcome)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
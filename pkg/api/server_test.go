@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/postgre"
+	"tto_chromedp/pkg/scheduler"
+)
+
+// fakeProfiles answers the profile lookups of POST /crawl; the other methods are never called.
+type fakeProfiles struct {
+	postgre.SocialProfileRepository
+}
+
+func (fakeProfiles) GetSocialProfileByUserName(ctx context.Context, userName string) (*models.SocialProfile, error) {
+	return &models.SocialProfile{ID: 7, UserName: userName}, nil
+}
+
+func TestRequireToken(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		want          int
+	}{
+		{name: "no token configured", want: http.StatusNoContent},
+		{name: "missing header", token: "s3cret", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "s3cret", authorization: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "not a bearer token", token: "s3cret", authorization: "Basic s3cret", want: http.StatusUnauthorized},
+		{name: "valid token", token: "s3cret", authorization: "Bearer s3cret", want: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/jobs/1", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			RequireToken(tt.token, next).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without a WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestRegisterRequiresToken(t *testing.T) {
+	var submitted []scheduler.Task
+	server := New(fakeProfiles{}, func(task scheduler.Task) { submitted = append(submitted, task) }, nil, time.Hour, DEFAULT_MAX_QUEUED, "s3cret")
+	mux := http.NewServeMux()
+	server.Register(mux)
+
+	crawl := func(authorization string) int {
+		req := httptest.NewRequest(http.MethodPost, "/crawl", strings.NewReader(`{"handle": "creator"}`))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := crawl(""); code != http.StatusUnauthorized {
+		t.Fatalf("POST /crawl without a token = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if len(submitted) != 0 {
+		t.Fatalf("an unauthenticated request submitted %d crawls", len(submitted))
+	}
+	if code := crawl("Bearer s3cret"); code != http.StatusAccepted {
+		t.Fatalf("POST /crawl with the token = %d, want %d", code, http.StatusAccepted)
+	}
+	if len(submitted) != 1 {
+		t.Fatalf("submitted %d crawls, want 1", len(submitted))
+	}
+}
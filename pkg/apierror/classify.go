@@ -0,0 +1,184 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Class is the category of a captured TTO API response.
+type Class string
+
+const (
+	ClassSuccess           Class = "success"
+	ClassAuthExpired       Class = "auth_expired"
+	ClassRateLimited       Class = "rate_limited"
+	ClassCreatorHidden     Class = "creator_hidden"
+	ClassRegionUnsupported Class = "region_unsupported"
+	ClassUnknown           Class = "unknown"
+)
+
+// Decision is what the crawl loop should do with a KOL after a response of a given class.
+type Decision string
+
+const (
+	DecisionProceed  Decision = "proceed"
+	DecisionRetry    Decision = "retry"
+	DecisionRelogin  Decision = "relogin"
+	DecisionCoolDown Decision = "cool_down"
+	DecisionSkip     Decision = "skip"
+)
+
+// Classes lists every class in a stable order, used when printing summaries.
+var Classes = []Class{
+	ClassSuccess,
+	ClassAuthExpired,
+	ClassRateLimited,
+	ClassCreatorHidden,
+	ClassRegionUnsupported,
+	ClassUnknown,
+}
+
+// VisibleDisplayStatuses are the creatorTTInfo.displayStatus values of a creator that is publicly listed.
+// Any other value is treated as hidden.
+var VisibleDisplayStatuses = map[int]bool{0: true, 1: true}
+
+// StatusCodeClasses maps the BaseResp.StatusCode values with a known meaning to their class. The codes
+// missing here fall back to the StatusMessage heuristics; TTO_STATUS_CODE_CLASSES adds or overrides
+// codes without a release (see LoadStatusCodesFromEnv).
+var StatusCodeClasses = map[int]Class{
+	10009: ClassRateLimited, // request too frequent
+	40001: ClassAuthExpired, // no permission on the resource
+	40100: ClassRateLimited, // requests made too frequently
+	40102: ClassAuthExpired, // access token expired
+	40104: ClassAuthExpired, // access token missing
+	40105: ClassAuthExpired, // access token invalid or revoked
+}
+
+// LoadStatusCodesFromEnv adds the CODE=class pairs of TTO_STATUS_CODE_CLASSES (comma separated, e.g.
+// "10011=rate_limited,10010=auth_expired") to StatusCodeClasses. It is called once at startup.
+func LoadStatusCodesFromEnv() error {
+	for _, pair := range strings.Split(os.Getenv("TTO_STATUS_CODE_CLASSES"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		codeText, classText, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid status code class %q, expected CODE=class", pair)
+		}
+		code, err := strconv.Atoi(strings.TrimSpace(codeText))
+		if err != nil {
+			return fmt.Errorf("invalid status code %q: %w", codeText, err)
+		}
+		class := Class(strings.TrimSpace(classText))
+		if !isClass(class) || class == ClassSuccess {
+			return fmt.Errorf("invalid class %q for status code %d", classText, code)
+		}
+		StatusCodeClasses[code] = class
+	}
+	return nil
+}
+
+func isClass(class Class) bool {
+	for _, c := range Classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// Error is a classified failure of a captured TTO response.
+type Error struct {
+	Class      Class
+	HTTPStatus int
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("tto api %s (http=%d, status_code=%d): %s", e.Class, e.HTTPStatus, e.StatusCode, e.Message)
+}
+
+// Decision returns the crawl decision for the error class.
+func (e *Error) Decision() Decision {
+	return DecisionFor(e.Class)
+}
+
+// DecisionFor maps a response class to the action the crawl loop should take.
+func DecisionFor(class Class) Decision {
+	switch class {
+	case ClassSuccess:
+		return DecisionProceed
+	case ClassAuthExpired:
+		return DecisionRelogin
+	case ClassRateLimited:
+		return DecisionCoolDown
+	case ClassCreatorHidden, ClassRegionUnsupported:
+		return DecisionSkip
+	default:
+		return DecisionRetry
+	}
+}
+
+// ClassifyResponse classifies a response from its HTTP status and its baseResp fields.
+// It returns nil when the response is a success.
+func ClassifyResponse(httpStatus, statusCode int, statusMessage string) *Error {
+	class := classify(httpStatus, statusCode, statusMessage)
+	if class == ClassSuccess {
+		return nil
+	}
+	return &Error{Class: class, HTTPStatus: httpStatus, StatusCode: statusCode, Message: statusMessage}
+}
+
+// ClassifyCreator returns a ClassCreatorHidden error when the creator is banned or not publicly listed.
+func ClassifyCreator(handleName string, isBannedInTT bool, displayStatus int) *Error {
+	if isBannedInTT {
+		return &Error{Class: ClassCreatorHidden, Message: fmt.Sprintf("creator %q is banned in TikTok", handleName)}
+	}
+	if !VisibleDisplayStatuses[displayStatus] {
+		return &Error{Class: ClassCreatorHidden, StatusCode: displayStatus, Message: fmt.Sprintf("creator %q has display status %d", handleName, displayStatus)}
+	}
+	return nil
+}
+
+func classify(httpStatus, statusCode int, statusMessage string) Class {
+	msg := strings.ToLower(statusMessage)
+
+	switch {
+	case httpStatus == http.StatusUnauthorized || httpStatus == http.StatusForbidden:
+		return ClassAuthExpired
+	case httpStatus == http.StatusTooManyRequests:
+		return ClassRateLimited
+	case httpStatus >= http.StatusBadRequest:
+		return ClassUnknown
+	case statusCode == 0:
+		return ClassSuccess
+	}
+	if class, ok := StatusCodeClasses[statusCode]; ok {
+		return class
+	}
+
+	// Unlisted code: guess from the message
+	switch {
+	case containsAny(msg, "login", "session", "unauthori", "token expired", "permission"):
+		return ClassAuthExpired
+	case containsAny(msg, "too many", "frequen", "rate limit", "limit exceeded", "busy"):
+		return ClassRateLimited
+	case containsAny(msg, "region", "country", "market"):
+		return ClassRegionUnsupported
+	default:
+		return ClassUnknown
+	}
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package apierror
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClassifyResponse(t *testing.T) {
+	tests := []struct {
+		name          string
+		httpStatus    int
+		statusCode    int
+		statusMessage string
+		want          Class
+	}{
+		{name: "success", httpStatus: http.StatusOK, want: ClassSuccess},
+		{name: "http 429", httpStatus: http.StatusTooManyRequests, want: ClassRateLimited},
+		{name: "http 401", httpStatus: http.StatusUnauthorized, want: ClassAuthExpired},
+		{name: "http 500", httpStatus: http.StatusInternalServerError, statusCode: 40100, want: ClassUnknown},
+		// The code decides, whatever the message says
+		{name: "rate limit code", httpStatus: http.StatusOK, statusCode: 40100, statusMessage: "", want: ClassRateLimited},
+		{name: "rate limit code, reworded message", httpStatus: http.StatusOK, statusCode: 40100, statusMessage: "please slow down", want: ClassRateLimited},
+		{name: "request too frequent code", httpStatus: http.StatusOK, statusCode: 10009, statusMessage: "request too frequent", want: ClassRateLimited},
+		{name: "token expired code", httpStatus: http.StatusOK, statusCode: 40102, statusMessage: "something else", want: ClassAuthExpired},
+		// Unlisted codes fall back to the message
+		{name: "unlisted code, login message", httpStatus: http.StatusOK, statusCode: 99001, statusMessage: "Please login again", want: ClassAuthExpired},
+		{name: "unlisted code, frequency message", httpStatus: http.StatusOK, statusCode: 99002, statusMessage: "Request too frequent", want: ClassRateLimited},
+		{name: "unlisted code, region message", httpStatus: http.StatusOK, statusCode: 99003, statusMessage: "Region not supported", want: ClassRegionUnsupported},
+		{name: "unlisted code, other message", httpStatus: http.StatusOK, statusCode: 99004, statusMessage: "internal error", want: ClassUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ClassifyResponse(tt.httpStatus, tt.statusCode, tt.statusMessage)
+			got := ClassSuccess
+			if err != nil {
+				got = err.Class
+			}
+			if got != tt.want {
+				t.Errorf("class = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadStatusCodesFromEnv(t *testing.T) {
+	saved := make(map[int]Class, len(StatusCodeClasses))
+	for code, class := range StatusCodeClasses {
+		saved[code] = class
+	}
+	t.Cleanup(func() { StatusCodeClasses = saved })
+
+	t.Setenv("TTO_STATUS_CODE_CLASSES", " 10011=rate_limited, 40100=unknown ")
+	if err := LoadStatusCodesFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ClassifyResponse(http.StatusOK, 10011, ""); err == nil || err.Class != ClassRateLimited {
+		t.Errorf("10011 classified as %v", err)
+	}
+	if err := ClassifyResponse(http.StatusOK, 40100, "too many requests"); err == nil || err.Class != ClassUnknown {
+		t.Errorf("overridden 40100 classified as %v", err)
+	}
+
+	for _, invalid := range []string{"10009", "abc=rate_limited", "10009=slow", "10009=success"} {
+		t.Setenv("TTO_STATUS_CODE_CLASSES", invalid)
+		if err := LoadStatusCodesFromEnv(); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
+
+func TestClassifyCreator(t *testing.T) {
+	if err := ClassifyCreator("a", true, 1); err == nil || err.Class != ClassCreatorHidden || err.Decision() != DecisionSkip {
+		t.Errorf("banned creator = %v", err)
+	}
+	if err := ClassifyCreator("a", false, 5); err == nil || err.Class != ClassCreatorHidden {
+		t.Errorf("hidden creator = %v", err)
+	}
+	if err := ClassifyCreator("a", false, 1); err != nil {
+		t.Errorf("visible creator = %v", err)
+	}
+}
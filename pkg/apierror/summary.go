@@ -0,0 +1,43 @@
+package apierror
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Counter counts classified outcomes for the run summary. It is safe for concurrent use.
+type Counter struct {
+	mu     sync.Mutex
+	counts map[Class]int
+}
+
+// NewCounter creates an empty Counter.
+func NewCounter() *Counter {
+	return &Counter{counts: make(map[Class]int)}
+}
+
+// Add records one outcome of the given class.
+func (c *Counter) Add(class Class) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[class]++
+}
+
+// Get returns the number of outcomes recorded for the class.
+func (c *Counter) Get(class Class) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[class]
+}
+
+// String renders the counts as "class=n" pairs in the order of Classes.
+func (c *Counter) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	parts := make([]string, 0, len(Classes))
+	for _, class := range Classes {
+		parts = append(parts, fmt.Sprintf("%s=%d", class, c.counts[class]))
+	}
+	return strings.Join(parts, " ")
+}
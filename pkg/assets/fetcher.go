@@ -0,0 +1,141 @@
+package assets
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	cdpio "github.com/chromedp/cdproto/io"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// MAX_ASSET_BYTES caps the size of one downloaded image.
+const MAX_ASSET_BYTES = 10 << 20
+
+// Fetcher downloads one asset and returns its content and content type.
+type Fetcher interface {
+	Fetch(ctx context.Context, assetURL string) ([]byte, string, error)
+}
+
+// BrowserFetcher loads assets through the network stack of a logged in tab (Network.loadNetworkResource),
+// so the request carries the same cookies, headers and proxy as the page.
+type BrowserFetcher struct {
+	tabCtx context.Context
+}
+
+// NewBrowserFetcher returns a fetcher bound to the tab of tabCtx.
+func NewBrowserFetcher(tabCtx context.Context) *BrowserFetcher {
+	return &BrowserFetcher{tabCtx: tabCtx}
+}
+
+func (f *BrowserFetcher) Fetch(ctx context.Context, assetURL string) ([]byte, string, error) {
+	var data []byte
+	var contentType string
+	err := chromedp.Run(f.tabCtx, chromedp.ActionFunc(func(tabCtx context.Context) error {
+		tree, err := page.GetFrameTree().Do(tabCtx)
+		if err != nil {
+			return fmt.Errorf("failed to get frame tree: %w", err)
+		}
+		resource, err := network.LoadNetworkResource(assetURL, &network.LoadNetworkResourceOptions{IncludeCredentials: true}).
+			WithFrameID(tree.Frame.ID).Do(tabCtx)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", assetURL, err)
+		}
+		if !resource.Success {
+			return fmt.Errorf("failed to load %s: HTTP %v %s", assetURL, resource.HTTPStatusCode, resource.NetErrorName)
+		}
+		if resource.Stream == "" {
+			return fmt.Errorf("failed to load %s: no response stream", assetURL)
+		}
+		defer cdpio.Close(resource.Stream).Do(tabCtx)
+
+		for key, value := range resource.Headers {
+			if strings.EqualFold(key, "Content-Type") {
+				contentType, _ = value.(string)
+			}
+		}
+		data, err = readStream(tabCtx, resource.Stream)
+		return err
+	}))
+	if err != nil {
+		return nil, "", err
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return data, contentType, nil
+}
+
+// readStream reads an IO stream to the end. IO.read is executed directly since the generated Do
+// drops the base64Encoded flag.
+func readStream(ctx context.Context, handle cdpio.StreamHandle) ([]byte, error) {
+	var out []byte
+	for {
+		var res cdpio.ReadReturns
+		if err := cdp.Execute(ctx, cdpio.CommandRead, cdpio.Read(handle), &res); err != nil {
+			return nil, fmt.Errorf("failed to read response stream: %w", err)
+		}
+		chunk := []byte(res.Data)
+		if res.Base64encoded {
+			decoded, err := base64.StdEncoding.DecodeString(res.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode response stream: %w", err)
+			}
+			chunk = decoded
+		}
+		out = append(out, chunk...)
+		if len(out) > MAX_ASSET_BYTES {
+			return nil, fmt.Errorf("asset larger than %d bytes", MAX_ASSET_BYTES)
+		}
+		if res.EOF {
+			return out, nil
+		}
+	}
+}
+
+// HTTPFetcher downloads assets with a plain HTTP client. It is meant for local HTTP stand-ins and for
+// asset hosts that need no session.
+type HTTPFetcher struct {
+	Client *http.Client
+}
+
+func NewHTTPFetcher(client *http.Client) *HTTPFetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPFetcher{Client: client}
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, assetURL string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, assetURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid asset URL %s: %w", assetURL, err)
+	}
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load %s: %w", assetURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to load %s: HTTP %d", assetURL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MAX_ASSET_BYTES+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", assetURL, err)
+	}
+	if len(data) > MAX_ASSET_BYTES {
+		return nil, "", fmt.Errorf("asset larger than %d bytes", MAX_ASSET_BYTES)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return data, contentType, nil
+}
@@ -0,0 +1,194 @@
+// Package assets copies the signed (and expiring) avatar and cover image URLs of a creator card to
+// storage we control, so the CMS keeps a stable URL.
+package assets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/tto/api"
+	"tto_chromedp/pkg/utils"
+)
+
+const (
+	DEFAULT_ASSET_FORMATS = "jpeg,webp,png"
+	DEFAULT_MAX_COVERS    = 10
+
+	AVATAR_PREFIX = "avatars"
+	COVER_PREFIX  = "covers"
+)
+
+var extensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/jpg":  "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+	"image/gif":  "gif",
+	"image/heic": "heic",
+	"image/avif": "avif",
+}
+
+// Pipeline downloads the preferred format of each image and stores it under the hash of its content,
+// so an image already stored (same avatar on the next crawl, same cover on two creators) is not
+// uploaded again.
+type Pipeline struct {
+	storage   Storage
+	formats   []string
+	maxCovers int
+
+	mu     sync.Mutex
+	stored map[string]string
+}
+
+// NewPipeline stores to storage, trying the image formats in the given order of preference.
+func NewPipeline(storage Storage, formats []string, maxCovers int) *Pipeline {
+	normalized := make([]string, 0, len(formats))
+	for _, f := range formats {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			normalized = append(normalized, f)
+		}
+	}
+	return &Pipeline{storage: storage, formats: normalized, maxCovers: maxCovers, stored: make(map[string]string)}
+}
+
+// NewPipelineFromEnv reads TTO_ASSET_FORMATS and TTO_ASSET_MAX_COVERS on top of NewStorageFromEnv.
+// It returns nil when TTO_ASSETS_ENABLED is false, and for a local storage without TTO_ASSET_BASE_URL
+// since its files would have no stable URL to store.
+func NewPipelineFromEnv() (*Pipeline, error) {
+	if !utils.GetEnvBool("TTO_ASSETS_ENABLED", true) {
+		return nil, nil
+	}
+	if utils.GetEnvString("TTO_ASSET_STORAGE", STORAGE_LOCAL) == STORAGE_LOCAL && os.Getenv("TTO_ASSET_BASE_URL") == "" {
+		return nil, nil
+	}
+	storage, err := NewStorageFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	formats := strings.Split(utils.GetEnvString("TTO_ASSET_FORMATS", DEFAULT_ASSET_FORMATS), ",")
+	return NewPipeline(storage, formats, utils.GetEnvInt("TTO_ASSET_MAX_COVERS", DEFAULT_MAX_COVERS)), nil
+}
+
+// StoreCreator stores the avatar and the covers of the recent videos of creator. Images that fail in
+// every format are logged and left out; the error is only set when nothing could be stored.
+func (p *Pipeline) StoreCreator(ctx context.Context, fetcher Fetcher, creator api.Creator) (*models.MediaAssets, error) {
+	info := creator.CreatorTTInfo
+	media := &models.MediaAssets{}
+	var lastErr error
+
+	avatarURL, err := p.storeImage(ctx, fetcher, AVATAR_PREFIX, info.AvatarURLList, info.AvatarURL)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to store avatar", "handle", info.HandleName, "error", err)
+		lastErr = err
+	}
+	media.AvatarURL = avatarURL
+
+	seen := make(map[string]bool)
+	videos := creator.StatisticData.VideoPerformance
+	for _, video := range append(append([]api.VideoItem{}, videos.RecentVideos...), videos.PopularVideos...) {
+		if p.maxCovers > 0 && len(media.Covers) >= p.maxCovers {
+			break
+		}
+		if video.ItemID == "" || seen[video.ItemID] {
+			continue
+		}
+		seen[video.ItemID] = true
+		coverURL, err := p.storeImage(ctx, fetcher, COVER_PREFIX, video.CoverURLList, video.CoverURL)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to store video cover", "item_id", video.ItemID, "error", err)
+			lastErr = err
+			continue
+		}
+		if coverURL != "" {
+			media.Covers = append(media.Covers, models.VideoCover{ItemID: video.ItemID, URL: coverURL})
+		}
+	}
+
+	if media.AvatarURL == "" && len(media.Covers) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return media, nil
+}
+
+// storeImage tries the candidates in order of preference and returns the stored URL of the first one
+// that downloads. An image without any URL returns "".
+func (p *Pipeline) storeImage(ctx context.Context, fetcher Fetcher, prefix string, list []api.ImageURL, fallback string) (string, error) {
+	candidates := p.candidates(list, fallback)
+	if len(candidates) == 0 {
+		return "", nil
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		data, contentType, err := fetcher.Fetch(ctx, candidate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return p.put(ctx, prefix, data, contentType)
+	}
+	return "", lastErr
+}
+
+func (p *Pipeline) put(ctx context.Context, prefix string, data []byte, contentType string) (string, error) {
+	contentType = strings.TrimSpace(strings.Split(contentType, ";")[0])
+	ext, ok := extensions[strings.ToLower(contentType)]
+	if !ok {
+		return "", fmt.Errorf("unexpected content type %q", contentType)
+	}
+	sum := sha256.Sum256(data)
+	key := fmt.Sprintf("%s/%s.%s", prefix, hex.EncodeToString(sum[:]), ext)
+
+	p.mu.Lock()
+	storedURL, ok := p.stored[key]
+	p.mu.Unlock()
+	if ok {
+		return storedURL, nil
+	}
+
+	storedURL, exists, err := p.storage.Stat(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		if storedURL, err = p.storage.Put(ctx, key, data, contentType); err != nil {
+			return "", err
+		}
+	}
+
+	p.mu.Lock()
+	p.stored[key] = storedURL
+	p.mu.Unlock()
+	return storedURL, nil
+}
+
+// candidates orders the image URLs by format preference; unlisted formats and the plain URL come last.
+func (p *Pipeline) candidates(list []api.ImageURL, fallback string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			out = append(out, u)
+		}
+	}
+	for _, format := range p.formats {
+		for _, image := range list {
+			if strings.EqualFold(image.Format, format) {
+				add(image.ImageURL)
+			}
+		}
+	}
+	for _, image := range list {
+		add(image.ImageURL)
+	}
+	add(fallback)
+	return out
+}
@@ -0,0 +1,179 @@
+package assets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"tto_chromedp/pkg/tto/api"
+)
+
+// countingStorage records the Put calls of a LocalStorage.
+type countingStorage struct {
+	*LocalStorage
+	mu   sync.Mutex
+	puts []string
+}
+
+func (s *countingStorage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	s.mu.Lock()
+	s.puts = append(s.puts, key)
+	s.mu.Unlock()
+	return s.LocalStorage.Put(ctx, key, data, contentType)
+}
+
+// imageServer stands in for the TikTok CDN. Paths ending in .jpeg, .webp and .png are served with
+// their content type, /missing answers 404, and every request is recorded.
+func imageServer(t *testing.T, bodies map[string]string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, ".jpeg"):
+			w.Header().Set("Content-Type", "image/jpeg")
+		case strings.HasSuffix(r.URL.Path, ".webp"):
+			w.Header().Set("Content-Type", "image/webp")
+		case strings.HasSuffix(r.URL.Path, ".png"):
+			w.Header().Set("Content-Type", "image/png")
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestStoreCreator(t *testing.T) {
+	server, requests := imageServer(t, map[string]string{
+		"/avatar.webp": "avatar-webp",
+		"/avatar.jpeg": "avatar-jpeg",
+		"/cover1.jpeg": "same-cover",
+		"/cover2.jpeg": "same-cover",
+		"/cover3.png":  "other-cover",
+	})
+	storage := &countingStorage{LocalStorage: NewLocalStorage(t.TempDir(), "https://cdn.test/")}
+	pipeline := NewPipeline(storage, []string{"JPEG", " webp"}, 10)
+
+	creator := api.Creator{
+		CreatorTTInfo: api.CreatorTTInfo{
+			HandleName: "creator",
+			AvatarURLList: []api.ImageURL{
+				{Format: "webp", ImageURL: server.URL + "/avatar.webp"},
+				{Format: "jpeg", ImageURL: server.URL + "/avatar.jpeg"},
+			},
+		},
+		StatisticData: api.StatisticData{VideoPerformance: api.VideoPerformance{
+			RecentVideos: []api.VideoItem{
+				{ItemID: "1", CoverURLList: []api.ImageURL{{Format: "jpeg", ImageURL: server.URL + "/cover1.jpeg"}}},
+				{ItemID: "2", CoverURLList: []api.ImageURL{
+					// The preferred format is missing, the next candidate is used
+					{Format: "jpeg", ImageURL: server.URL + "/missing.jpeg"},
+					{Format: "webp", ImageURL: server.URL + "/cover2.jpeg"},
+				}},
+			},
+			PopularVideos: []api.VideoItem{
+				{ItemID: "1", CoverURL: server.URL + "/cover1.jpeg"},
+				{ItemID: "3", CoverURL: server.URL + "/cover3.png"},
+			},
+		}},
+	}
+
+	media, err := pipeline.StoreCreator(context.Background(), NewHTTPFetcher(server.Client()), creator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// jpeg is preferred over webp, although listed second
+	if !strings.HasPrefix(media.AvatarURL, "https://cdn.test/avatars/") || !strings.HasSuffix(media.AvatarURL, ".jpg") {
+		t.Errorf("AvatarURL = %q", media.AvatarURL)
+	}
+	for _, path := range *requests {
+		if path == "/avatar.webp" {
+			t.Errorf("the webp avatar was downloaded although jpeg is available")
+		}
+	}
+
+	if len(media.Covers) != 3 {
+		t.Fatalf("got %d covers, want 3: %+v", len(media.Covers), media.Covers)
+	}
+	// Covers 1 and 2 have the same content: one object, one URL
+	if media.Covers[0].URL != media.Covers[1].URL {
+		t.Errorf("identical covers got different URLs: %q and %q", media.Covers[0].URL, media.Covers[1].URL)
+	}
+	if media.Covers[2].ItemID != "3" || !strings.HasSuffix(media.Covers[2].URL, ".png") {
+		t.Errorf("cover 3 = %+v", media.Covers[2])
+	}
+	if len(storage.puts) != 3 {
+		t.Errorf("stored %d objects, want 3 (avatar and two distinct covers): %v", len(storage.puts), storage.puts)
+	}
+
+	// The next crawl finds everything stored already
+	storage.puts = nil
+	again := NewPipeline(storage, []string{"jpeg"}, 10)
+	if _, err := again.StoreCreator(context.Background(), NewHTTPFetcher(server.Client()), creator); err != nil {
+		t.Fatal(err)
+	}
+	if len(storage.puts) != 0 {
+		t.Errorf("stored %v again", storage.puts)
+	}
+}
+
+func TestStoreCreatorMaxCovers(t *testing.T) {
+	server, _ := imageServer(t, map[string]string{"/a.jpeg": "a", "/b.jpeg": "b"})
+	pipeline := NewPipeline(NewLocalStorage(t.TempDir(), "https://cdn.test"), []string{"jpeg"}, 1)
+	creator := api.Creator{StatisticData: api.StatisticData{VideoPerformance: api.VideoPerformance{
+		RecentVideos: []api.VideoItem{{ItemID: "1", CoverURL: server.URL + "/a.jpeg"}, {ItemID: "2", CoverURL: server.URL + "/b.jpeg"}},
+	}}}
+
+	media, err := pipeline.StoreCreator(context.Background(), NewHTTPFetcher(server.Client()), creator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(media.Covers) != 1 || media.Covers[0].ItemID != "1" {
+		t.Errorf("covers = %+v", media.Covers)
+	}
+}
+
+func TestStoreCreatorNothingStored(t *testing.T) {
+	server, _ := imageServer(t, nil)
+	pipeline := NewPipeline(NewLocalStorage(t.TempDir(), "https://cdn.test"), []string{"jpeg"}, 10)
+	creator := api.Creator{CreatorTTInfo: api.CreatorTTInfo{AvatarURL: server.URL + "/missing.jpeg"}}
+
+	if _, err := pipeline.StoreCreator(context.Background(), NewHTTPFetcher(server.Client()), creator); err == nil {
+		t.Error("expected an error when no image could be stored")
+	}
+}
+
+func TestLocalStorageWithoutBaseURL(t *testing.T) {
+	storage := NewLocalStorage(t.TempDir(), "")
+	url, err := storage.Put(context.Background(), "avatars/a.jpg", []byte("a"), "image/jpeg")
+	if err != nil || url != "" {
+		t.Errorf("Put = %q, %v; want no URL", url, err)
+	}
+}
+
+func TestNewPipelineFromEnvNeedsBaseURL(t *testing.T) {
+	t.Setenv("TTO_ASSETS_ENABLED", "true")
+	t.Setenv("TTO_ASSET_STORAGE", STORAGE_LOCAL)
+	t.Setenv("TTO_ASSET_BASE_URL", "")
+	if pipeline, err := NewPipelineFromEnv(); err != nil || pipeline != nil {
+		t.Errorf("NewPipelineFromEnv() = %v, %v; want nil without a base URL", pipeline, err)
+	}
+
+	t.Setenv("TTO_ASSET_BASE_URL", "https://cdn.test")
+	t.Setenv("TTO_ASSET_DIR", t.TempDir())
+	if pipeline, err := NewPipelineFromEnv(); err != nil || pipeline == nil {
+		t.Errorf("NewPipelineFromEnv() = %v, %v; want a pipeline", pipeline, err)
+	}
+}
@@ -0,0 +1,99 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tto_chromedp/pkg/utils"
+)
+
+const (
+	STORAGE_LOCAL = "local"
+	STORAGE_S3    = "s3"
+
+	DEFAULT_ASSET_DIR = "./assets"
+)
+
+// Storage keeps the downloaded assets under content addressed keys and returns their stable URL.
+type Storage interface {
+	// Stat returns the URL of key and whether it is already stored.
+	Stat(ctx context.Context, key string) (string, bool, error)
+	// Put stores data under key and returns its URL.
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+}
+
+// NewStorageFromEnv builds the storage selected by TTO_ASSET_STORAGE (local or s3).
+//
+// local uses TTO_ASSET_DIR and TTO_ASSET_BASE_URL, the URL the directory is served under. s3 uses TTO_S3_ENDPOINT, TTO_S3_REGION,
+// TTO_S3_BUCKET, TTO_S3_ACCESS_KEY, TTO_S3_SECRET_KEY and optionally TTO_ASSET_BASE_URL as the
+// public URL prefix.
+func NewStorageFromEnv() (Storage, error) {
+	switch kind := utils.GetEnvString("TTO_ASSET_STORAGE", STORAGE_LOCAL); kind {
+	case STORAGE_LOCAL:
+		return NewLocalStorage(utils.GetEnvString("TTO_ASSET_DIR", DEFAULT_ASSET_DIR), os.Getenv("TTO_ASSET_BASE_URL")), nil
+	case STORAGE_S3:
+		return NewS3Storage(S3Config{
+			Endpoint:  os.Getenv("TTO_S3_ENDPOINT"),
+			Region:    utils.GetEnvString("TTO_S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv("TTO_S3_BUCKET"),
+			AccessKey: os.Getenv("TTO_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("TTO_S3_SECRET_KEY"),
+			PublicURL: os.Getenv("TTO_ASSET_BASE_URL"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown asset storage %q (expected %s or %s)", kind, STORAGE_LOCAL, STORAGE_S3)
+	}
+}
+
+// LocalStorage writes assets below Dir. The URL of an asset is BaseURL/key; without a BaseURL the files
+// have no URL the CMS could reach, so none is returned.
+type LocalStorage struct {
+	Dir     string
+	BaseURL string
+}
+
+func NewLocalStorage(dir, baseURL string) *LocalStorage {
+	return &LocalStorage{Dir: dir, BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (s *LocalStorage) Stat(ctx context.Context, key string) (string, bool, error) {
+	_, err := os.Stat(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return s.url(key), false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to stat asset %s: %w", key, err)
+	}
+	return s.url(key), true, nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create asset directory: %w", err)
+	}
+	// Same temp file + rename as the rate limiter state, a crash never leaves a truncated image
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write asset %s: %w", key, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("failed to store asset %s: %w", key, err)
+	}
+	return s.url(key), nil
+}
+
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(key))
+}
+
+func (s *LocalStorage) url(key string) string {
+	if s.BaseURL == "" {
+		return ""
+	}
+	return s.BaseURL + "/" + key
+}
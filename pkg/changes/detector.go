@@ -0,0 +1,197 @@
+// Package changes compares a freshly crawled creator with the stored one and reports the sharp
+// changes account managers need to hear about.
+package changes
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"tto_chromedp/pkg/distribution"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/utils"
+)
+
+// Event types written to crawler.tto_creator_events.
+const (
+	EVENT_FOLLOWER_DROP        = "follower_drop"
+	EVENT_AUDIENCE_SHIFT       = "audience_country_shift"
+	EVENT_CREDIT_TIER_CHANGE   = "credit_tier_change"
+	EVENT_BANNED_STATUS_CHANGE = "banned_status_change"
+
+	SEVERITY_INFO     = "info"
+	SEVERITY_WARNING  = "warning"
+	SEVERITY_CRITICAL = "critical"
+)
+
+// Thresholds decide how far a metric has to move before an event is raised.
+type Thresholds struct {
+	// FollowerDrop is the relative follower loss (0.1 = 10%) that raises an event.
+	FollowerDrop float64
+	// CountryShift is the change in audience share of any one country (0.15 = 15 points) that raises
+	// an event. A change of top country always does.
+	CountryShift float64
+	// CreditTierDelta is the number of credit score tiers the creator has to move.
+	CreditTierDelta int
+}
+
+// placeholderCountries are the region buckets that do not name a country: the long tail grouped by
+// the distribution package and the codes TTO uses for audiences it cannot place.
+var placeholderCountries = map[string]bool{
+	"":                             true,
+	distribution.REGION_OTHER_CODE: true,
+	"OTHERS":                       true,
+	"UNKNOWN":                      true,
+	"ZZ":                           true,
+}
+
+func DefaultThresholds() Thresholds {
+	return Thresholds{FollowerDrop: 0.1, CountryShift: 0.15, CreditTierDelta: 1}
+}
+
+// LoadThresholdsFromEnv reads TTO_ALERT_FOLLOWER_DROP, TTO_ALERT_COUNTRY_SHIFT and
+// TTO_ALERT_CREDIT_TIER_DELTA on top of DefaultThresholds.
+func LoadThresholdsFromEnv() Thresholds {
+	t := DefaultThresholds()
+	t.FollowerDrop = utils.GetEnvFloat("TTO_ALERT_FOLLOWER_DROP", t.FollowerDrop)
+	t.CountryShift = utils.GetEnvFloat("TTO_ALERT_COUNTRY_SHIFT", t.CountryShift)
+	t.CreditTierDelta = utils.GetEnvInt("TTO_ALERT_CREDIT_TIER_DELTA", t.CreditTierDelta)
+	return t
+}
+
+// Detector raises events for one creator at a time.
+type Detector struct {
+	thresholds Thresholds
+}
+
+func NewDetector(thresholds Thresholds) *Detector {
+	return &Detector{thresholds: thresholds}
+}
+
+// Detect compares the stored data of a profile with the new crawl. Sections missing on either side
+// are not compared, so the first crawl of a creator raises nothing.
+func (d *Detector) Detect(profile models.SocialProfile, previous, current *models.TTOUser) []models.CreatorEvent {
+	if previous == nil || current == nil || previous.UpdatedAt.IsZero() {
+		return nil
+	}
+
+	var events []models.CreatorEvent
+	now := time.Now()
+	add := func(eventType, severity, message string, prev, curr interface{}) {
+		events = append(events, models.CreatorEvent{
+			SocialProfileID: profile.ID,
+			UserName:        profile.UserName,
+			Type:            eventType,
+			Severity:        severity,
+			Message:         message,
+			Previous:        prev,
+			Current:         curr,
+			DetectedAt:      now,
+		})
+	}
+
+	if previous.Health != nil && current.Health != nil {
+		prev, curr := previous.Health, current.Health
+
+		if prev.FollowerCount > 0 {
+			drop := float64(prev.FollowerCount-curr.FollowerCount) / float64(prev.FollowerCount)
+			if drop >= d.thresholds.FollowerDrop {
+				severity := SEVERITY_WARNING
+				if drop >= 2*d.thresholds.FollowerDrop {
+					severity = SEVERITY_CRITICAL
+				}
+				add(EVENT_FOLLOWER_DROP, severity,
+					fmt.Sprintf("followers dropped %.1f%% (%d -> %d)", drop*100, prev.FollowerCount, curr.FollowerCount),
+					prev.FollowerCount, curr.FollowerCount)
+			}
+		}
+
+		if delta := curr.CreditTier - prev.CreditTier; d.thresholds.CreditTierDelta > 0 && abs(delta) >= d.thresholds.CreditTierDelta {
+			severity := SEVERITY_INFO
+			if delta < 0 {
+				severity = SEVERITY_WARNING
+			}
+			add(EVENT_CREDIT_TIER_CHANGE, severity,
+				fmt.Sprintf("credit tier moved from %d to %d (score %d -> %d)", prev.CreditTier, curr.CreditTier, prev.CreditScore, curr.CreditScore),
+				map[string]int{"tier": prev.CreditTier, "score": prev.CreditScore},
+				map[string]int{"tier": curr.CreditTier, "score": curr.CreditScore})
+		}
+
+		if prev.IsBannedInTT != curr.IsBannedInTT {
+			severity, message := SEVERITY_CRITICAL, "creator is now banned on TikTok"
+			if !curr.IsBannedInTT {
+				severity, message = SEVERITY_INFO, "creator is no longer banned on TikTok"
+			}
+			add(EVENT_BANNED_STATUS_CHANGE, severity, message, prev.IsBannedInTT, curr.IsBannedInTT)
+		}
+	}
+
+	if len(previous.RegionDistri) > 0 && len(current.RegionDistri) > 0 {
+		if message, ok := d.countryShift(previous.RegionDistri, current.RegionDistri); ok {
+			add(EVENT_AUDIENCE_SHIFT, SEVERITY_WARNING, message, previous.RegionDistri, current.RegionDistri)
+		}
+	}
+
+	return events
+}
+
+// countryShift reports a change of top country, or the largest change of share when it reaches the threshold.
+// Placeholder buckets are left out: a growing long tail is not a move to another country.
+func (d *Detector) countryShift(previous, current models.AudienceShares) (string, bool) {
+	prevTop, currTop := topCountry(previous), topCountry(current)
+	if prevTop != "" && currTop != "" && prevTop != currTop {
+		return fmt.Sprintf("top audience country changed from %s to %s", prevTop, currTop), true
+	}
+
+	shares := make(map[string][2]float64)
+	for _, share := range previous {
+		if !isCountry(share) {
+			continue
+		}
+		s := shares[share.ISOCode]
+		s[0] = share.Value
+		shares[share.ISOCode] = s
+	}
+	for _, share := range current {
+		if !isCountry(share) {
+			continue
+		}
+		s := shares[share.ISOCode]
+		s[1] = share.Value
+		shares[share.ISOCode] = s
+	}
+
+	maxCode, maxShift := "", 0.0
+	for code, s := range shares {
+		if shift := math.Abs(s[1] - s[0]); shift > maxShift {
+			maxCode, maxShift = code, shift
+		}
+	}
+	if d.thresholds.CountryShift > 0 && maxShift >= d.thresholds.CountryShift {
+		s := shares[maxCode]
+		return fmt.Sprintf("audience share of %s moved from %.1f%% to %.1f%%", maxCode, s[0]*100, s[1]*100), true
+	}
+	return "", false
+}
+
+func topCountry(shares models.AudienceShares) string {
+	top, best := "", -1.0
+	for _, share := range shares {
+		if isCountry(share) && share.Value > best {
+			top, best = share.ISOCode, share.Value
+		}
+	}
+	return top
+}
+
+func isCountry(share models.AudienceShare) bool {
+	return !placeholderCountries[strings.ToUpper(strings.TrimSpace(share.ISOCode))] && share.Name != utils.COUNTRY_UNKNOWN
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
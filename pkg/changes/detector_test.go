@@ -0,0 +1,167 @@
+package changes
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/utils"
+)
+
+func TestDetectBannedFromHealthOnly(t *testing.T) {
+	// A skipped (banned) creator only carries the health of its card, the other sections are empty
+	profile := models.SocialProfile{ID: 7, UserName: "creator"}
+	previous := &models.TTOUser{
+		UpdatedAt:    time.Now().Add(-24 * time.Hour),
+		Health:       &models.CreatorHealth{FollowerCount: 1000, CreditScore: 90, CreditTier: 4},
+		RegionDistri: models.AudienceShares{{ISOCode: "VN", Value: 0.9}},
+	}
+	current := &models.TTOUser{
+		UpdatedAt: time.Now(),
+		Health:    &models.CreatorHealth{FollowerCount: 1000, CreditScore: 90, CreditTier: 4, IsBannedInTT: true},
+	}
+
+	events := NewDetector(DefaultThresholds()).Detect(profile, previous, current)
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1: %+v", len(events), events)
+	}
+	event := events[0]
+	if event.Type != EVENT_BANNED_STATUS_CHANGE || event.Severity != SEVERITY_CRITICAL || event.SocialProfileID != 7 {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestDetectFirstCrawl(t *testing.T) {
+	current := &models.TTOUser{UpdatedAt: time.Now(), Health: &models.CreatorHealth{IsBannedInTT: true}}
+	if events := NewDetector(DefaultThresholds()).Detect(models.SocialProfile{ID: 1}, &models.TTOUser{}, current); len(events) != 0 {
+		t.Errorf("got %d events on the first crawl, want none", len(events))
+	}
+}
+
+func crawls(prevHealth, currHealth *models.CreatorHealth, prevRegions, currRegions models.AudienceShares) (*models.TTOUser, *models.TTOUser) {
+	previous := &models.TTOUser{UpdatedAt: time.Now().Add(-24 * time.Hour), Health: prevHealth, RegionDistri: prevRegions}
+	current := &models.TTOUser{UpdatedAt: time.Now(), Health: currHealth, RegionDistri: currRegions}
+	return previous, current
+}
+
+func TestDetectFollowerDrop(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     int
+		curr     int
+		severity string // empty: no event
+	}{
+		{"just under the threshold", 1000, 901, ""},
+		{"at the threshold", 1000, 900, SEVERITY_WARNING},
+		{"between the threshold and twice it", 1000, 850, SEVERITY_WARNING},
+		{"at twice the threshold", 1000, 800, SEVERITY_CRITICAL},
+		{"gain", 1000, 1500, ""},
+		{"no previous followers", 0, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous, current := crawls(&models.CreatorHealth{FollowerCount: tt.prev}, &models.CreatorHealth{FollowerCount: tt.curr}, nil, nil)
+			events := NewDetector(DefaultThresholds()).Detect(models.SocialProfile{ID: 1}, previous, current)
+			if tt.severity == "" {
+				if len(events) != 0 {
+					t.Fatalf("got %+v, want no event", events)
+				}
+				return
+			}
+			if len(events) != 1 || events[0].Type != EVENT_FOLLOWER_DROP || events[0].Severity != tt.severity {
+				t.Fatalf("got %+v, want one %s follower drop", events, tt.severity)
+			}
+		})
+	}
+}
+
+func TestDetectCreditTierChange(t *testing.T) {
+	tests := []struct {
+		name       string
+		prev, curr int
+		thresholds Thresholds
+		severity   string
+	}{
+		{"down", 4, 3, DefaultThresholds(), SEVERITY_WARNING},
+		{"up", 3, 4, DefaultThresholds(), SEVERITY_INFO},
+		{"unchanged", 4, 4, DefaultThresholds(), ""},
+		{"below the delta", 4, 3, Thresholds{CreditTierDelta: 2}, ""},
+		{"disabled", 4, 1, Thresholds{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous, current := crawls(&models.CreatorHealth{CreditTier: tt.prev}, &models.CreatorHealth{CreditTier: tt.curr}, nil, nil)
+			events := NewDetector(tt.thresholds).Detect(models.SocialProfile{ID: 1}, previous, current)
+			if tt.severity == "" {
+				if len(events) != 0 {
+					t.Fatalf("got %+v, want no event", events)
+				}
+				return
+			}
+			if len(events) != 1 || events[0].Type != EVENT_CREDIT_TIER_CHANGE || events[0].Severity != tt.severity {
+				t.Fatalf("got %+v, want one %s credit tier change", events, tt.severity)
+			}
+		})
+	}
+}
+
+func TestDetectCountryShift(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     models.AudienceShares
+		curr     models.AudienceShares
+		wantType string // empty: no event
+		message  string
+	}{
+		{
+			name:     "top country changes",
+			prev:     models.AudienceShares{{ISOCode: "VN", Value: 0.5}, {ISOCode: "TH", Value: 0.45}, {ISOCode: "US", Value: 0.05}},
+			curr:     models.AudienceShares{{ISOCode: "TH", Value: 0.5}, {ISOCode: "VN", Value: 0.45}, {ISOCode: "US", Value: 0.05}},
+			wantType: EVENT_AUDIENCE_SHIFT,
+			message:  "top audience country changed from VN to TH",
+		},
+		{
+			name:     "share moves past the threshold",
+			prev:     models.AudienceShares{{ISOCode: "VN", Value: 0.8}, {ISOCode: "US", Value: 0.1}, {ISOCode: "TH", Value: 0.1}},
+			curr:     models.AudienceShares{{ISOCode: "VN", Value: 0.6}, {ISOCode: "US", Value: 0.3}, {ISOCode: "TH", Value: 0.1}},
+			wantType: EVENT_AUDIENCE_SHIFT,
+			message:  "audience share of",
+		},
+		{
+			name: "share moves under the threshold",
+			prev: models.AudienceShares{{ISOCode: "VN", Value: 0.8}, {ISOCode: "US", Value: 0.2}},
+			curr: models.AudienceShares{{ISOCode: "VN", Value: 0.7}, {ISOCode: "US", Value: 0.3}},
+		},
+		{
+			name: "grouped long tail becomes the largest bucket",
+			prev: models.AudienceShares{{ISOCode: "VN", Value: 0.4}, {ISOCode: "US", Value: 0.35}, {Name: "Other", ISOCode: "OTHER", Value: 0.25}},
+			curr: models.AudienceShares{{Name: "Other", ISOCode: "OTHER", Value: 0.4}, {ISOCode: "VN", Value: 0.35}, {ISOCode: "US", Value: 0.25}},
+		},
+		{
+			name: "unresolved country becomes the largest bucket",
+			prev: models.AudienceShares{{ISOCode: "VN", Value: 0.6}, {Name: utils.COUNTRY_UNKNOWN, ISOCode: "XX", Value: 0.4}},
+			curr: models.AudienceShares{{Name: utils.COUNTRY_UNKNOWN, ISOCode: "XX", Value: 0.6}, {ISOCode: "VN", Value: 0.55}},
+		},
+		{
+			name: "placeholder code becomes the largest bucket",
+			prev: models.AudienceShares{{ISOCode: "VN", Value: 0.6}, {ISOCode: "others", Value: 0.3}, {ISOCode: "", Value: 0.1}},
+			curr: models.AudienceShares{{ISOCode: "others", Value: 0.5}, {ISOCode: "VN", Value: 0.5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous, current := crawls(nil, nil, tt.prev, tt.curr)
+			events := NewDetector(DefaultThresholds()).Detect(models.SocialProfile{ID: 1}, previous, current)
+			if tt.wantType == "" {
+				if len(events) != 0 {
+					t.Fatalf("got %+v, want no event", events)
+				}
+				return
+			}
+			if len(events) != 1 || events[0].Type != tt.wantType || !strings.HasPrefix(events[0].Message, tt.message) {
+				t.Fatalf("got %+v, want one %s starting with %q", events, tt.wantType, tt.message)
+			}
+		})
+	}
+}
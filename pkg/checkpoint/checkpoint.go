@@ -0,0 +1,188 @@
+// Package checkpoint persists the progress of an enrich run so that `crawl --resume <run-id>` can
+// continue where an interrupted run stopped without crawling finished profiles again.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"tto_chromedp/pkg/models"
+)
+
+// DEFAULT_DIR is where checkpoint files are kept when TTO_CHECKPOINT_DIR is not set.
+const DEFAULT_DIR = "checkpoints"
+
+// Checkpoint is the state of a run, saved to <dir>/<run_id>.json after every change.
+//
+// Profiles is the list resolved from Selection when the run started, so a resume works on the same
+// profiles even though their status column changed meanwhile. Cursor is the index of the next profile
+// to claim. A claim is in flight until the profile is marked done or released; claims found when a
+// checkpoint is loaded belong to a dead process and are handed out again.
+type Checkpoint struct {
+	RunID     string                 `json:"run_id"`
+	Mode      string                 `json:"mode"`
+	Account   string                 `json:"account"`
+	Status    string                 `json:"status"`
+	Selection models.CrawlSelection  `json:"selection"`
+	Profiles  []models.SocialProfile `json:"profiles"`
+	Cursor    int                    `json:"cursor"`
+	InFlight  map[int]time.Time      `json:"in_flight"`
+	Done      models.CrawlRunEntries `json:"done"`
+	StartedAt time.Time              `json:"started_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+
+	mu   sync.Mutex
+	path string
+	done map[int]bool
+}
+
+// Path returns the checkpoint file of a run.
+func Path(dir, runID string) string {
+	return filepath.Join(dir, runID+".json")
+}
+
+// New creates the checkpoint of a new run and saves it.
+func New(dir, runID, mode, account string, selection models.CrawlSelection, profiles []models.SocialProfile) (*Checkpoint, error) {
+	now := time.Now()
+	cp := &Checkpoint{
+		RunID:     runID,
+		Mode:      mode,
+		Account:   account,
+		Status:    models.CrawlRunRunning,
+		Selection: selection,
+		Profiles:  profiles,
+		InFlight:  make(map[int]time.Time),
+		StartedAt: now,
+		UpdatedAt: now,
+		path:      Path(dir, runID),
+		done:      make(map[int]bool),
+	}
+	if err := cp.save(); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// Load reads the checkpoint of runID from dir and releases the claims left by the previous process.
+func Load(dir, runID string) (*Checkpoint, error) {
+	path := Path(dir, runID)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no checkpoint for run %s in %s", runID, dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", path, err)
+	}
+
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint %s: %w", path, err)
+	}
+	cp.path = path
+	cp.done = make(map[int]bool, len(cp.Done))
+	for _, entry := range cp.Done {
+		cp.done[entry.SocialProfileID] = true
+	}
+	for id := range cp.InFlight {
+		cp.rewindLocked(id)
+	}
+	cp.InFlight = make(map[int]time.Time)
+	cp.Status = models.CrawlRunRunning
+	return cp, nil
+}
+
+// Next claims the next profile that is neither done nor claimed. It returns false when the run is
+// complete.
+func (cp *Checkpoint) Next() (models.SocialProfile, bool, error) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	for cp.Cursor < len(cp.Profiles) {
+		profile := cp.Profiles[cp.Cursor]
+		cp.Cursor++
+		// A release rewinds the cursor past the claims still in flight
+		if _, claimed := cp.InFlight[profile.ID]; claimed || cp.done[profile.ID] {
+			continue
+		}
+		cp.InFlight[profile.ID] = time.Now()
+		return profile, true, cp.save()
+	}
+	return models.SocialProfile{}, false, nil
+}
+
+// MarkDone records the final entry of a profile and drops its claim.
+func (cp *Checkpoint) MarkDone(entry models.CrawlRunEntry) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	delete(cp.InFlight, entry.SocialProfileID)
+	if !cp.done[entry.SocialProfileID] {
+		cp.done[entry.SocialProfileID] = true
+		cp.Done = append(cp.Done, entry)
+	}
+	return cp.save()
+}
+
+// Release drops the claim of a profile that must be crawled again, and moves the cursor back to it.
+func (cp *Checkpoint) Release(profileID int) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	delete(cp.InFlight, profileID)
+	cp.rewindLocked(profileID)
+	return cp.save()
+}
+
+// Finish saves the final status of the run.
+func (cp *Checkpoint) Finish(status string) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.Status = status
+	return cp.save()
+}
+
+// Entries returns a copy of the entries of the profiles already done.
+func (cp *Checkpoint) Entries() models.CrawlRunEntries {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return append(models.CrawlRunEntries(nil), cp.Done...)
+}
+
+// Remaining returns the number of profiles not done yet.
+func (cp *Checkpoint) Remaining() int {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return len(cp.Profiles) - len(cp.done)
+}
+
+// rewindLocked moves the cursor back to profileID if it was already passed. The caller holds mu.
+func (cp *Checkpoint) rewindLocked(profileID int) {
+	for i := 0; i < cp.Cursor && i < len(cp.Profiles); i++ {
+		if cp.Profiles[i].ID == profileID {
+			cp.Cursor = i
+			return
+		}
+	}
+}
+
+// save writes the checkpoint atomically (temp file + rename). The caller holds mu.
+func (cp *Checkpoint) save() error {
+	cp.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cp.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	tmp := cp.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, cp.path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint: %w", err)
+	}
+	return nil
+}
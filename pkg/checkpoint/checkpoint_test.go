@@ -0,0 +1,177 @@
+package checkpoint
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"tto_chromedp/pkg/models"
+)
+
+func profiles(ids ...int) []models.SocialProfile {
+	out := make([]models.SocialProfile, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, models.SocialProfile{ID: id, UserName: "kol" + string(rune('a'+id))})
+	}
+	return out
+}
+
+// claimAll claims profiles until the run is complete and returns their IDs.
+func claimAll(t *testing.T, cp *Checkpoint) []int {
+	t.Helper()
+	var ids []int
+	for {
+		profile, ok, err := cp.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			return ids
+		}
+		ids = append(ids, profile.ID)
+	}
+}
+
+func next(t *testing.T, cp *Checkpoint) int {
+	t.Helper()
+	profile, ok, err := cp.Next()
+	if err != nil || !ok {
+		t.Fatalf("Next() = %v, %v, %v", profile, ok, err)
+	}
+	return profile.ID
+}
+
+func done(t *testing.T, cp *Checkpoint, id int) {
+	t.Helper()
+	if err := cp.MarkDone(models.CrawlRunEntry{SocialProfileID: id, Outcome: models.CrawlOutcomeSuccess}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClaimMarkDoneRelease(t *testing.T) {
+	cp, err := New(t.TempDir(), "run1", "enrich", "tto", models.CrawlSelection{}, profiles(1, 2, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id := next(t, cp); id != 1 {
+		t.Fatalf("first claim = %d, want 1", id)
+	}
+	if id := next(t, cp); id != 2 {
+		t.Fatalf("second claim = %d, want 2", id)
+	}
+	if len(cp.InFlight) != 2 {
+		t.Fatalf("in flight = %v, want 2 claims", cp.InFlight)
+	}
+
+	done(t, cp, 2)
+	// Profile 1 must be crawled again: the cursor goes back to it, past the done profile 2
+	if err := cp.Release(1); err != nil {
+		t.Fatal(err)
+	}
+	if got := claimAll(t, cp); !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Fatalf("claims after release = %v, want [1 3]", got)
+	}
+
+	done(t, cp, 1)
+	done(t, cp, 3)
+	// A repeated MarkDone keeps the first entry
+	done(t, cp, 3)
+	if got := len(cp.Entries()); got != 3 {
+		t.Fatalf("entries = %d, want 3", got)
+	}
+	if cp.Remaining() != 0 || len(cp.InFlight) != 0 {
+		t.Fatalf("remaining = %d, in flight = %v", cp.Remaining(), cp.InFlight)
+	}
+}
+
+func TestReleaseSkipsOtherClaims(t *testing.T) {
+	cp, err := New(t.TempDir(), "run1", "enrich", "tto", models.CrawlSelection{}, profiles(1, 2, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(t, cp)
+	next(t, cp)
+	if err := cp.Release(1); err != nil {
+		t.Fatal(err)
+	}
+	// Profile 2 is still claimed and must not be handed out twice
+	if got := claimAll(t, cp); !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Fatalf("claims = %v, want [1 3]", got)
+	}
+}
+
+func TestLoadRewindsInFlightClaims(t *testing.T) {
+	dir := t.TempDir()
+	cp, err := New(dir, "run1", "enrich", "tto", models.CrawlSelection{}, profiles(1, 2, 3, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(t, cp)
+	done(t, cp, 1)
+	next(t, cp) // 2 is in flight when the process dies
+	next(t, cp) // 3 as well
+	done(t, cp, 3)
+
+	resumed, err := Load(dir, "run1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Cursor != 1 {
+		t.Fatalf("cursor = %d, want 1 (rewound to the claim of profile 2)", resumed.Cursor)
+	}
+	if len(resumed.InFlight) != 0 {
+		t.Fatalf("in flight after load = %v, want none", resumed.InFlight)
+	}
+	// Done profiles are skipped on resume
+	if got := claimAll(t, resumed); !reflect.DeepEqual(got, []int{2, 4}) {
+		t.Fatalf("claims after resume = %v, want [2 4]", got)
+	}
+	if resumed.Remaining() != 2 {
+		t.Fatalf("remaining = %d, want 2", resumed.Remaining())
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	selection := models.CrawlSelection{CreatorStatus: 1, Limit: 10}
+	cp, err := New(dir, "run1", "enrich", "tto", selection, profiles(1, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(t, cp)
+	done(t, cp, 1)
+	if err := cp.Finish(models.CrawlRunAborted); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(Path(dir, "run1") + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary file left behind: %v", err)
+	}
+
+	loaded, err := Load(dir, "run1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.RunID != "run1" || loaded.Mode != "enrich" || loaded.Account != "tto" || loaded.Selection != selection {
+		t.Fatalf("loaded header = %+v", loaded)
+	}
+	if !reflect.DeepEqual(loaded.Profiles, cp.Profiles) {
+		t.Fatalf("profiles = %v, want %v", loaded.Profiles, cp.Profiles)
+	}
+	if !reflect.DeepEqual(loaded.Entries(), cp.Entries()) {
+		t.Fatalf("entries = %v, want %v", loaded.Entries(), cp.Entries())
+	}
+	// A resumed run is running again, whatever status the previous process saved
+	if loaded.Status != models.CrawlRunRunning {
+		t.Fatalf("status = %s, want %s", loaded.Status, models.CrawlRunRunning)
+	}
+	if !loaded.StartedAt.Equal(cp.StartedAt) {
+		t.Fatalf("started at = %s, want %s", loaded.StartedAt, cp.StartedAt)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	if _, err := Load(t.TempDir(), "nope"); err == nil {
+		t.Fatal("Load of a missing run returned no error")
+	}
+}
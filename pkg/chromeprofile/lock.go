@@ -0,0 +1,72 @@
+// Package chromeprofile manages the Chrome user data directories under ./profiles.
+package chromeprofile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Files Chrome creates in a user data directory while a browser owns it.
+var singletonFiles = []string{"SingletonLock", "SingletonSocket", "SingletonCookie"}
+
+// Dir returns the user data directory of a profile.
+func Dir(profileName string) string {
+	return filepath.Join(".", "profiles", profileName)
+}
+
+// ClearStaleLock removes the singleton files of dir when the browser that created them is gone.
+// SingletonLock is a symlink to "<hostname>-<pid>"; the lock is stale when the host is this machine
+// and the pid is no longer running. A lock held by a live process or another host is left alone and
+// reported as an error, since starting Chrome on it would fail anyway.
+func ClearStaleLock(dir string) (bool, error) {
+	lockPath := filepath.Join(dir, "SingletonLock")
+	target, err := os.Readlink(lockPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", lockPath, err)
+	}
+
+	sep := strings.LastIndex(target, "-")
+	if sep < 0 {
+		return false, fmt.Errorf("unexpected lock target %q in %s", target, lockPath)
+	}
+	host, pidText := target[:sep], target[sep+1:]
+	pid, err := strconv.Atoi(pidText)
+	if err != nil {
+		return false, fmt.Errorf("unexpected lock target %q in %s: %w", target, lockPath, err)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return false, fmt.Errorf("failed to get hostname: %w", err)
+	}
+	if host != hostname {
+		return false, fmt.Errorf("profile %s is locked by host %s", dir, host)
+	}
+	if processAlive(pid) {
+		return false, fmt.Errorf("profile %s is in use by running process %d", dir, pid)
+	}
+
+	for _, name := range singletonFiles {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("failed to remove stale %s: %w", name, err)
+		}
+	}
+	return true, nil
+}
+
+// processAlive reports whether a process with the given pid exists.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
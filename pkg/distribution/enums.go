@@ -0,0 +1,81 @@
+package distribution
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AgeBucket is the canonical age interval stored in audience_age.
+type AgeBucket string
+
+const (
+	AGE_13_17   AgeBucket = "13-17"
+	AGE_18_24   AgeBucket = "18-24"
+	AGE_25_34   AgeBucket = "25-34"
+	AGE_35_44   AgeBucket = "35-44"
+	AGE_45_54   AgeBucket = "45-54"
+	AGE_55_PLUS AgeBucket = "55+"
+	AGE_UNKNOWN AgeBucket = "unknown"
+)
+
+// AGE_BUCKETS lists the age buckets in ascending order, AGE_UNKNOWN last.
+var AGE_BUCKETS = []AgeBucket{AGE_13_17, AGE_18_24, AGE_25_34, AGE_35_44, AGE_45_54, AGE_55_PLUS, AGE_UNKNOWN}
+
+var ageBuckets = []struct {
+	bucket   AgeBucket
+	from, to int
+}{
+	{AGE_13_17, 13, 17},
+	{AGE_18_24, 18, 24},
+	{AGE_25_34, 25, 34},
+	{AGE_35_44, 35, 44},
+	{AGE_45_54, 45, 54},
+	{AGE_55_PLUS, 55, 200},
+}
+
+// Gender is the canonical gender stored in audience_gender.
+type Gender string
+
+const (
+	GENDER_MALE    Gender = "male"
+	GENDER_FEMALE  Gender = "female"
+	GENDER_UNKNOWN Gender = "unknown"
+)
+
+// GENDERS lists the genders, GENDER_UNKNOWN last.
+var GENDERS = []Gender{GENDER_MALE, GENDER_FEMALE, GENDER_UNKNOWN}
+
+var agePattern = regexp.MustCompile(`(\d+)\s*(?:[-~_]|to)?\s*(\d+)?\s*(\+)?`)
+
+// ParseAgeBucket reads intervals such as "18-24", "18~24", "AGE_18_24", "55+" or "55-" (open upper bound).
+// It reports false, with AGE_UNKNOWN, when the interval does not match one of the canonical buckets.
+func ParseAgeBucket(interval string) (AgeBucket, bool) {
+	m := agePattern.FindStringSubmatch(strings.TrimSpace(interval))
+	if m == nil {
+		return AGE_UNKNOWN, false
+	}
+	from, _ := strconv.Atoi(m[1])
+	to := 200
+	if m[2] != "" {
+		to, _ = strconv.Atoi(m[2])
+	}
+	for _, b := range ageBuckets {
+		if b.from == from && (b.to == to || (b.bucket == AGE_55_PLUS && to >= b.from)) {
+			return b.bucket, true
+		}
+	}
+	return AGE_UNKNOWN, false
+}
+
+// ParseGender reads the TTO gender labels in any case ("Male", "F", "female", ...).
+func ParseGender(label string) (Gender, bool) {
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "male", "m", "man", "men":
+		return GENDER_MALE, true
+	case "female", "f", "woman", "women":
+		return GENDER_FEMALE, true
+	default:
+		return GENDER_UNKNOWN, false
+	}
+}
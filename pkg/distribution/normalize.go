@@ -0,0 +1,174 @@
+// Package distribution validates and normalises the audience distributions of a creator card.
+//
+// Every distribution goes through the same steps: buckets are mapped to their canonical key, repeated
+// buckets are merged, the scale (fractions or percentages) is detected, a sum close to 1 is rescaled to
+// exactly 1 and the buckets are sorted by value. Anything inconsistent is reported as a warning and the
+// data is kept as close to the source as possible.
+package distribution
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/tto/api"
+	"tto_chromedp/pkg/utils"
+)
+
+const (
+	// DEFAULT_TOLERANCE is how far from 1 the sum of the ratios may be and still be renormalised.
+	DEFAULT_TOLERANCE = 0.05
+	// DEFAULT_OTHER_THRESHOLD groups the regions below 1% of the audience into REGION_OTHER.
+	DEFAULT_OTHER_THRESHOLD = 0.01
+
+	REGION_OTHER      = "Other"
+	REGION_OTHER_CODE = "OTHER"
+)
+
+// Normalizer holds the thresholds used for every distribution.
+type Normalizer struct {
+	Tolerance float64
+	// OtherThreshold is the share under which a region is grouped into REGION_OTHER (0 disables grouping).
+	OtherThreshold float64
+}
+
+func NewNormalizer() *Normalizer {
+	return &Normalizer{Tolerance: DEFAULT_TOLERANCE, OtherThreshold: DEFAULT_OTHER_THRESHOLD}
+}
+
+// NewNormalizerFromEnv reads TTO_DISTRI_TOLERANCE and TTO_REGION_OTHER_THRESHOLD on top of the defaults.
+func NewNormalizerFromEnv() *Normalizer {
+	n := NewNormalizer()
+	n.Tolerance = utils.GetEnvFloat("TTO_DISTRI_TOLERANCE", n.Tolerance)
+	n.OtherThreshold = utils.GetEnvFloat("TTO_REGION_OTHER_THRESHOLD", n.OtherThreshold)
+	return n
+}
+
+// Age maps the age intervals to the canonical AgeBucket values.
+func (n *Normalizer) Age(distri []api.AgeDistri) (models.AudienceShares, []string) {
+	var warnings []string
+	shares := make(models.AudienceShares, 0, len(distri))
+	for _, item := range distri {
+		bucket, ok := ParseAgeBucket(item.AgeInterval)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("age: unknown interval %q counted as %s", item.AgeInterval, bucket))
+		}
+		shares = append(shares, models.AudienceShare{Name: string(bucket), Value: item.Ratio})
+	}
+	shares, more := n.normalize("age", shares)
+	return shares, append(warnings, more...)
+}
+
+// Gender maps the gender labels to the canonical Gender values.
+func (n *Normalizer) Gender(distri []api.GenderDistri) (models.AudienceShares, []string) {
+	var warnings []string
+	shares := make(models.AudienceShares, 0, len(distri))
+	for _, item := range distri {
+		gender, ok := ParseGender(item.Gender)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("gender: unknown label %q counted as %s", item.Gender, gender))
+		}
+		shares = append(shares, models.AudienceShare{Name: string(gender), Value: item.Ratio})
+	}
+	shares, more := n.normalize("gender", shares)
+	return shares, append(warnings, more...)
+}
+
+// Region names each country with resolve and groups the long tail into REGION_OTHER.
+func (n *Normalizer) Region(distri []api.RegionDistri, resolve func(isoCode string) string) (models.AudienceShares, []string) {
+	shares := make(models.AudienceShares, 0, len(distri))
+	for _, item := range distri {
+		shares = append(shares, models.AudienceShare{Name: resolve(item.Country), ISOCode: item.Country, Value: item.Ratio})
+	}
+	shares, warnings := n.normalize("region", shares)
+	return n.groupLongTail(shares), warnings
+}
+
+// normalize merges repeated buckets, converts percentages to fractions, renormalises a sum close to 1
+// and sorts by value. Buckets are identified by ISOCode when set, by Name otherwise.
+func (n *Normalizer) normalize(kind string, shares models.AudienceShares) (models.AudienceShares, []string) {
+	var warnings []string
+	if len(shares) == 0 {
+		return shares, nil
+	}
+
+	merged := make(models.AudienceShares, 0, len(shares))
+	index := make(map[string]int)
+	sum, max := 0.0, 0.0
+	for _, share := range shares {
+		if math.IsNaN(share.Value) || math.IsInf(share.Value, 0) || share.Value < 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: dropped invalid ratio %v for %q", kind, share.Value, share.Name))
+			continue
+		}
+		key := share.ISOCode
+		if key == "" {
+			key = share.Name
+		}
+		if i, ok := index[key]; ok {
+			warnings = append(warnings, fmt.Sprintf("%s: bucket %q listed more than once, merged", kind, key))
+			merged[i].Value += share.Value
+		} else {
+			index[key] = len(merged)
+			merged = append(merged, share)
+		}
+		sum += share.Value
+		max = math.Max(max, share.Value)
+	}
+	if sum == 0 {
+		if len(merged) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: all ratios are zero", kind))
+		}
+		return merged, warnings
+	}
+
+	// Percentages sum to about 100, fractions to about 1; a single value above 1 rules out fractions
+	if max > 1 || math.Abs(sum-100) <= n.Tolerance*100 {
+		warnings = append(warnings, fmt.Sprintf("%s: ratios look like percentages (sum %.4f), converted to fractions", kind, sum))
+		for i := range merged {
+			merged[i].Value /= 100
+		}
+		sum /= 100
+	}
+
+	if math.Abs(sum-1) <= n.Tolerance {
+		// Rounding leftovers go to the largest bucket so the shares sum to exactly 1
+		rounded, largest := 0.0, 0
+		for i := range merged {
+			merged[i].Value = math.Round(merged[i].Value/sum*10000) / 10000
+			rounded += merged[i].Value
+			if merged[i].Value > merged[largest].Value {
+				largest = i
+			}
+		}
+		merged[largest].Value = math.Round((merged[largest].Value+1-rounded)*10000) / 10000
+	} else {
+		warnings = append(warnings, fmt.Sprintf("%s: ratios sum to %.4f, left unchanged", kind, sum))
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Value > merged[j].Value })
+	return merged, warnings
+}
+
+// groupLongTail folds the shares below OtherThreshold into one REGION_OTHER bucket, listed last.
+func (n *Normalizer) groupLongTail(shares models.AudienceShares) models.AudienceShares {
+	if n.OtherThreshold <= 0 {
+		return shares
+	}
+	result := make(models.AudienceShares, 0, len(shares))
+	other := models.AudienceShare{Name: REGION_OTHER, ISOCode: REGION_OTHER_CODE}
+	grouped := 0
+	for _, share := range shares {
+		if share.Value < n.OtherThreshold {
+			other.Value += share.Value
+			grouped++
+			continue
+		}
+		result = append(result, share)
+	}
+	if grouped == 0 {
+		return shares
+	}
+	other.Value = math.Round(other.Value*10000) / 10000
+	return append(result, other)
+}
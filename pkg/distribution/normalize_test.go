@@ -0,0 +1,217 @@
+package distribution
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/tto/api"
+)
+
+var countryNames = map[string]string{"VN": "Vietnam", "US": "United States", "TH": "Thailand", "JP": "Japan"}
+
+func resolve(isoCode string) string {
+	return countryNames[isoCode]
+}
+
+func assertShares(t *testing.T, got models.AudienceShares, want models.AudienceShares) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || got[i].ISOCode != want[i].ISOCode || math.Abs(got[i].Value-want[i].Value) > 1e-9 {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+	}
+}
+
+func assertWarning(t *testing.T, warnings []string, substr string) {
+	t.Helper()
+	for _, w := range warnings {
+		if strings.Contains(w, substr) {
+			return
+		}
+	}
+	t.Fatalf("no warning containing %q in %q", substr, warnings)
+}
+
+func TestParseAgeBucket(t *testing.T) {
+	tests := []struct {
+		interval string
+		want     AgeBucket
+		ok       bool
+	}{
+		{"18-24", AGE_18_24, true},
+		{"18~24", AGE_18_24, true},
+		{"AGE_25_34", AGE_25_34, true},
+		{" 35 to 44 ", AGE_35_44, true},
+		{"55+", AGE_55_PLUS, true},
+		{"55-", AGE_55_PLUS, true},
+		{"55-64", AGE_55_PLUS, true},
+		{"18-25", AGE_UNKNOWN, false},
+		{"adults", AGE_UNKNOWN, false},
+	}
+	for _, tt := range tests {
+		if got, ok := ParseAgeBucket(tt.interval); got != tt.want || ok != tt.ok {
+			t.Errorf("ParseAgeBucket(%q) = %s, %v, want %s, %v", tt.interval, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAgeMergesRepeatedBuckets(t *testing.T) {
+	shares, warnings := NewNormalizer().Age([]api.AgeDistri{
+		{AgeInterval: "18-24", Ratio: 0.3},
+		{AgeInterval: "25-34", Ratio: 0.4},
+		{AgeInterval: "AGE_18_24", Ratio: 0.2},
+		{AgeInterval: "10-12", Ratio: 0.1},
+	})
+	assertShares(t, shares, models.AudienceShares{
+		{Name: string(AGE_18_24), Value: 0.5},
+		{Name: string(AGE_25_34), Value: 0.4},
+		{Name: string(AGE_UNKNOWN), Value: 0.1},
+	})
+	assertWarning(t, warnings, `bucket "18-24" listed more than once`)
+	assertWarning(t, warnings, `unknown interval "10-12"`)
+}
+
+func TestGenderScale(t *testing.T) {
+	tests := []struct {
+		name    string
+		distri  []api.GenderDistri
+		want    models.AudienceShares
+		warning string
+	}{
+		{
+			name:    "percentages",
+			distri:  []api.GenderDistri{{Gender: "Female", Ratio: 40}, {Gender: "Male", Ratio: 60}},
+			want:    models.AudienceShares{{Name: "male", Value: 0.6}, {Name: "female", Value: 0.4}},
+			warning: "look like percentages",
+		},
+		{
+			name:   "fractions",
+			distri: []api.GenderDistri{{Gender: "M", Ratio: 0.25}, {Gender: "f", Ratio: 0.75}},
+			want:   models.AudienceShares{{Name: "female", Value: 0.75}, {Name: "male", Value: 0.25}},
+		},
+		{
+			// A value above 1 cannot be a fraction, even when the sum is far from 100
+			name:    "partial percentages",
+			distri:  []api.GenderDistri{{Gender: "male", Ratio: 30}, {Gender: "female", Ratio: 20}},
+			want:    models.AudienceShares{{Name: "male", Value: 0.3}, {Name: "female", Value: 0.2}},
+			warning: "sum to 0.5000, left unchanged",
+		},
+		{
+			name:    "unknown label",
+			distri:  []api.GenderDistri{{Gender: "male", Ratio: 0.9}, {Gender: "other", Ratio: 0.1}},
+			want:    models.AudienceShares{{Name: "male", Value: 0.9}, {Name: "unknown", Value: 0.1}},
+			warning: `unknown label "other"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, warnings := NewNormalizer().Gender(tt.distri)
+			assertShares(t, shares, tt.want)
+			if tt.warning == "" && len(warnings) > 0 {
+				t.Fatalf("unexpected warnings %q", warnings)
+			}
+			if tt.warning != "" {
+				assertWarning(t, warnings, tt.warning)
+			}
+		})
+	}
+}
+
+func TestNormalizeRenormalises(t *testing.T) {
+	n := NewNormalizer()
+	tests := []struct {
+		name    string
+		shares  models.AudienceShares
+		want    models.AudienceShares
+		warning string
+	}{
+		{
+			// 0.999 is within the tolerance: rescaled, the rounding leftover goes to the first largest bucket
+			name:   "close to 1",
+			shares: models.AudienceShares{{Name: "a", Value: 0.333}, {Name: "b", Value: 0.333}, {Name: "c", Value: 0.333}},
+			want:   models.AudienceShares{{Name: "a", Value: 0.3334}, {Name: "b", Value: 0.3333}, {Name: "c", Value: 0.3333}},
+		},
+		{
+			name:   "percentages close to 100",
+			shares: models.AudienceShares{{Name: "a", Value: 0.5}, {Name: "b", Value: 49}, {Name: "c", Value: 49.5}},
+			want:   models.AudienceShares{{Name: "c", Value: 0.5}, {Name: "b", Value: 0.4949}, {Name: "a", Value: 0.0051}},
+		},
+		{
+			name:    "outside the tolerance",
+			shares:  models.AudienceShares{{Name: "a", Value: 0.3}, {Name: "b", Value: 0.6}},
+			want:    models.AudienceShares{{Name: "b", Value: 0.6}, {Name: "a", Value: 0.3}},
+			warning: "sum to 0.9000, left unchanged",
+		},
+		{
+			name:    "invalid ratios dropped",
+			shares:  models.AudienceShares{{Name: "a", Value: math.NaN()}, {Name: "b", Value: -0.2}, {Name: "c", Value: 1}},
+			want:    models.AudienceShares{{Name: "c", Value: 1}},
+			warning: "dropped invalid ratio",
+		},
+		{
+			name:    "all zero",
+			shares:  models.AudienceShares{{Name: "a"}, {Name: "b"}},
+			want:    models.AudienceShares{{Name: "a"}, {Name: "b"}},
+			warning: "all ratios are zero",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, warnings := n.normalize("test", tt.shares)
+			assertShares(t, shares, tt.want)
+			if tt.warning != "" {
+				assertWarning(t, warnings, tt.warning)
+			}
+		})
+	}
+}
+
+func TestRegionGroupsLongTail(t *testing.T) {
+	distri := []api.RegionDistri{
+		{Country: "TH", Ratio: 0.003},
+		{Country: "VN", Ratio: 0.6},
+		{Country: "US", Ratio: 0.095},
+		{Country: "VN", Ratio: 0.3}, // repeated: merged by ISO code
+		{Country: "JP", Ratio: 0.002},
+	}
+
+	shares, warnings := NewNormalizer().Region(distri, resolve)
+	assertShares(t, shares, models.AudienceShares{
+		{Name: "Vietnam", ISOCode: "VN", Value: 0.9},
+		{Name: "United States", ISOCode: "US", Value: 0.095},
+		{Name: REGION_OTHER, ISOCode: REGION_OTHER_CODE, Value: 0.005},
+	})
+	assertWarning(t, warnings, `bucket "VN" listed more than once`)
+
+	// A zero threshold keeps every country
+	n := NewNormalizer()
+	n.OtherThreshold = 0
+	shares, _ = n.Region(distri, resolve)
+	assertShares(t, shares, models.AudienceShares{
+		{Name: "Vietnam", ISOCode: "VN", Value: 0.9},
+		{Name: "United States", ISOCode: "US", Value: 0.095},
+		{Name: "Thailand", ISOCode: "TH", Value: 0.003},
+		{Name: "Japan", ISOCode: "JP", Value: 0.002},
+	})
+
+	// Nothing below the threshold: no empty Other bucket
+	shares, _ = NewNormalizer().Region([]api.RegionDistri{{Country: "VN", Ratio: 0.7}, {Country: "US", Ratio: 0.3}}, resolve)
+	assertShares(t, shares, models.AudienceShares{
+		{Name: "Vietnam", ISOCode: "VN", Value: 0.7},
+		{Name: "United States", ISOCode: "US", Value: 0.3},
+	})
+}
+
+func TestNewNormalizerFromEnv(t *testing.T) {
+	t.Setenv("TTO_DISTRI_TOLERANCE", "0.1")
+	t.Setenv("TTO_REGION_OTHER_THRESHOLD", "0.05")
+	n := NewNormalizerFromEnv()
+	if n.Tolerance != 0.1 || n.OtherThreshold != 0.05 {
+		t.Fatalf("normalizer = %+v", n)
+	}
+}
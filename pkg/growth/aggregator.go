@@ -0,0 +1,153 @@
+// Package growth builds the kol_growth time series from the follower history and the captured videos.
+package growth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/tto/api"
+	"tto_chromedp/pkg/utils"
+)
+
+// Granularity is the size of one kol_growth bucket.
+type Granularity string
+
+const (
+	Daily   Granularity = "daily"
+	Weekly  Granularity = "weekly"
+	Monthly Granularity = "monthly"
+)
+
+// ViewsMode decides how the views of several videos posted in the same bucket are combined.
+type ViewsMode string
+
+const (
+	ViewsSum     ViewsMode = "sum"
+	ViewsAverage ViewsMode = "average"
+)
+
+// Aggregator groups follower counts and video views into buckets in VN_TIMEZONE.
+type Aggregator struct {
+	Granularity Granularity
+	ViewsMode   ViewsMode
+	location    *time.Location
+}
+
+// NewAggregator creates an Aggregator. Unknown granularity or views mode values are rejected.
+func NewAggregator(granularity Granularity, viewsMode ViewsMode) (*Aggregator, error) {
+	switch granularity {
+	case Daily, Weekly, Monthly:
+	default:
+		return nil, fmt.Errorf("unknown growth granularity %q", granularity)
+	}
+	switch viewsMode {
+	case ViewsSum, ViewsAverage:
+	default:
+		return nil, fmt.Errorf("unknown growth views mode %q", viewsMode)
+	}
+	location, err := time.LoadLocation(utils.VN_TIMEZONE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load location %s: %w", utils.VN_TIMEZONE, err)
+	}
+	return &Aggregator{Granularity: granularity, ViewsMode: viewsMode, location: location}, nil
+}
+
+// NewAggregatorFromEnv creates an Aggregator from TTO_GROWTH_GRANULARITY and TTO_GROWTH_VIEWS_MODE.
+func NewAggregatorFromEnv() (*Aggregator, error) {
+	return NewAggregator(
+		Granularity(strings.ToLower(utils.GetEnvString("TTO_GROWTH_GRANULARITY", string(Daily)))),
+		ViewsMode(strings.ToLower(utils.GetEnvString("TTO_GROWTH_VIEWS_MODE", string(ViewsSum)))),
+	)
+}
+
+// BucketStart returns the start of the bucket containing t: local midnight, the Monday of the week,
+// or the first day of the month.
+func (a *Aggregator) BucketStart(t time.Time) time.Time {
+	local := t.In(a.location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, a.location)
+	switch a.Granularity {
+	case Weekly:
+		offset := (int(day.Weekday()) + 6) % 7 // Monday = 0
+		return day.AddDate(0, 0, -offset)
+	case Monthly:
+		return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, a.location)
+	default:
+		return day
+	}
+}
+
+// Build merges the follower history and the videos into the kol_growth series.
+// Entries with an unparseable date or a missing create time are skipped and logged, never bucketed at 0.
+func (a *Aggregator) Build(ctx context.Context, followerTrend []api.FollowerTrend, videos []api.VideoItem) models.KolGrowth {
+	logger := logging.FromContext(ctx)
+	points := make(map[int64]*models.GrowthPoint)
+	point := func(bucket time.Time) *models.GrowthPoint {
+		key := bucket.Unix()
+		p, ok := points[key]
+		if !ok {
+			p = &models.GrowthPoint{Time: key}
+			points[key] = p
+		}
+		return p
+	}
+
+	// Follower counts are a level, so each bucket keeps the count of its latest day
+	latestFollowerDay := make(map[int64]time.Time)
+	for _, item := range followerTrend {
+		day, err := time.ParseInLocation("20060102", item.Date, a.location)
+		if err != nil {
+			logger.Warn("Skipping follower count with invalid date", "date", item.Date, "error", err)
+			continue
+		}
+		bucket := a.BucketStart(day)
+		if last, ok := latestFollowerDay[bucket.Unix()]; ok && last.After(day) {
+			continue
+		}
+		latestFollowerDay[bucket.Unix()] = day
+		point(bucket).Followers = item.Count
+	}
+
+	seen := make(map[string]bool)
+	for _, video := range videos {
+		if video.ItemID != "" {
+			if seen[video.ItemID] {
+				continue
+			}
+			seen[video.ItemID] = true
+		}
+		if video.CreateTime <= 0 {
+			logger.Warn("Skipping video with invalid create time", "item_id", video.ItemID, "create_time", video.CreateTime.Int64())
+			continue
+		}
+		created := time.Unix(video.CreateTime.Int64(), 0)
+		p := point(a.BucketStart(created))
+		p.VideoCount++
+		p.VideoDetails = append(p.VideoDetails, models.VideoDetail{
+			ItemID: video.ItemID,
+			Time:   created.Unix(),
+			Views:  video.Views.Int64(),
+		})
+	}
+
+	result := make([]models.GrowthPoint, 0, len(points))
+	for _, p := range points {
+		var total int64
+		for _, v := range p.VideoDetails {
+			total += v.Views
+		}
+		if a.ViewsMode == ViewsAverage && p.VideoCount > 0 {
+			total /= int64(p.VideoCount)
+		}
+		p.Videos = int(total)
+		sort.Slice(p.VideoDetails, func(i, j int) bool { return p.VideoDetails[i].Time < p.VideoDetails[j].Time })
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Time < result[j].Time })
+
+	return models.KolGrowth{Granularity: string(a.Granularity), Detail: result}
+}
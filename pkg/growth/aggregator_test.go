@@ -0,0 +1,152 @@
+package growth
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/tto/api"
+)
+
+var vn = time.FixedZone("ICT", 7*3600)
+
+func vnDate(year int, month time.Month, day int) int64 {
+	return time.Date(year, month, day, 0, 0, 0, 0, vn).Unix()
+}
+
+func newAggregator(t *testing.T, granularity Granularity, viewsMode ViewsMode) *Aggregator {
+	t.Helper()
+	a, err := NewAggregator(granularity, viewsMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func video(id string, created time.Time, views int64) api.VideoItem {
+	return api.VideoItem{ItemID: id, CreateTime: api.StringInt(created.Unix()), Views: api.StringInt(views)}
+}
+
+func TestBucketStart(t *testing.T) {
+	// Sunday 5 May 2024 at 20:00 UTC is Monday 6 May at 03:00 in VN
+	at := time.Date(2024, 5, 5, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		granularity Granularity
+		want        int64
+	}{
+		{Daily, vnDate(2024, 5, 6)},
+		{Weekly, vnDate(2024, 5, 6)},
+		{Monthly, vnDate(2024, 5, 1)},
+	}
+	for _, tt := range tests {
+		if got := newAggregator(t, tt.granularity, ViewsSum).BucketStart(at).Unix(); got != tt.want {
+			t.Errorf("%s: bucket = %s, want %s", tt.granularity, time.Unix(got, 0).In(vn), time.Unix(tt.want, 0).In(vn))
+		}
+	}
+
+	// A Sunday belongs to the week started on the Monday before it
+	sunday := time.Date(2024, 5, 12, 23, 0, 0, 0, vn)
+	if got := newAggregator(t, Weekly, ViewsSum).BucketStart(sunday).Unix(); got != vnDate(2024, 5, 6) {
+		t.Errorf("weekly bucket of Sunday = %s", time.Unix(got, 0).In(vn))
+	}
+}
+
+func TestBuildFollowers(t *testing.T) {
+	trend := []api.FollowerTrend{
+		{Date: "20240508", Count: 130},
+		{Date: "20240506", Count: 100},
+		{Date: "20240512", Count: 150},
+		{Date: "20240513", Count: 160},
+	}
+	tests := []struct {
+		granularity Granularity
+		want        []models.GrowthPoint
+	}{
+		{Daily, []models.GrowthPoint{
+			{Time: vnDate(2024, 5, 6), Followers: 100},
+			{Time: vnDate(2024, 5, 8), Followers: 130},
+			{Time: vnDate(2024, 5, 12), Followers: 150},
+			{Time: vnDate(2024, 5, 13), Followers: 160},
+		}},
+		// Each bucket keeps the count of its latest day, whatever the input order
+		{Weekly, []models.GrowthPoint{
+			{Time: vnDate(2024, 5, 6), Followers: 150},
+			{Time: vnDate(2024, 5, 13), Followers: 160},
+		}},
+		{Monthly, []models.GrowthPoint{
+			{Time: vnDate(2024, 5, 1), Followers: 160},
+		}},
+	}
+	for _, tt := range tests {
+		got := newAggregator(t, tt.granularity, ViewsSum).Build(context.Background(), trend, nil)
+		if got.Granularity != string(tt.granularity) || !reflect.DeepEqual(got.Detail, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.granularity, got.Detail, tt.want)
+		}
+	}
+}
+
+func TestBuildVideoViews(t *testing.T) {
+	day := time.Date(2024, 5, 6, 9, 0, 0, 0, vn)
+	videos := []api.VideoItem{
+		video("b", day.Add(2*time.Hour), 300),
+		video("a", day, 100),
+		video("a", day, 100), // captured twice
+		video("c", day.AddDate(0, 0, 1), 50),
+	}
+	tests := []struct {
+		mode ViewsMode
+		want []int
+	}{
+		{ViewsSum, []int{400, 50}},
+		{ViewsAverage, []int{200, 50}},
+	}
+	for _, tt := range tests {
+		got := newAggregator(t, Daily, tt.mode).Build(context.Background(), nil, videos).Detail
+		if len(got) != 2 {
+			t.Fatalf("%s: got %d points, want 2: %+v", tt.mode, len(got), got)
+		}
+		for i, p := range got {
+			if p.Videos != tt.want[i] {
+				t.Errorf("%s: point %d views = %d, want %d", tt.mode, i, p.Videos, tt.want[i])
+			}
+		}
+		first := got[0]
+		if first.VideoCount != 2 || first.VideoDetails[0].ItemID != "a" || first.VideoDetails[1].ItemID != "b" {
+			t.Errorf("%s: first point = %+v, want videos a then b", tt.mode, first)
+		}
+	}
+}
+
+func TestBuildSkipsInvalidTimestamps(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil)).With(logging.KEY_KOL, "alice")
+	ctx := logging.WithLogger(context.Background(), logger)
+
+	trend := []api.FollowerTrend{{Date: "2024-05-06", Count: 1}, {Date: "", Count: 2}, {Date: "20240506", Count: 3}}
+	videos := []api.VideoItem{{ItemID: "zero"}, {ItemID: "negative", CreateTime: -1}}
+	got := newAggregator(t, Daily, ViewsSum).Build(ctx, trend, videos).Detail
+
+	want := []models.GrowthPoint{{Time: vnDate(2024, 5, 6), Followers: 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	// The warnings go through the logger of ctx and keep its correlation fields
+	if n := strings.Count(buf.String(), "kol=alice"); n != 4 {
+		t.Fatalf("logged %d warnings with the KOL field, want 4:\n%s", n, buf.String())
+	}
+}
+
+func TestNewAggregatorRejectsUnknownValues(t *testing.T) {
+	if _, err := NewAggregator("hourly", ViewsSum); err == nil {
+		t.Error("unknown granularity accepted")
+	}
+	if _, err := NewAggregator(Daily, "median"); err == nil {
+		t.Error("unknown views mode accepted")
+	}
+}
@@ -0,0 +1,196 @@
+// Package interest turns the TTO content labels of a creator into weighted content interests.
+package interest
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"tto_chromedp/pkg/tto/api"
+	"tto_chromedp/pkg/utils"
+)
+
+// Weights balances the signals used to score a content label. They do not need to sum to 1.
+type Weights struct {
+	// Order rewards labels listed first by TTO (the list is ranked by relevance).
+	Order float64
+	// Titles rewards labels whose keywords appear in the recent and popular video titles and hashtags.
+	Titles float64
+	// Industry rewards labels that are also among the creator's industry labels.
+	Industry float64
+}
+
+// DefaultWeights favours the TTO ranking, refined by what the creator actually posts.
+func DefaultWeights() Weights {
+	return Weights{Order: 0.5, Titles: 0.3, Industry: 0.2}
+}
+
+// LoadWeightsFromEnv reads TTO_INTEREST_WEIGHT_{ORDER,TITLES,INDUSTRY} on top of DefaultWeights.
+func LoadWeightsFromEnv() Weights {
+	w := DefaultWeights()
+	w.Order = utils.GetEnvFloat("TTO_INTEREST_WEIGHT_ORDER", w.Order)
+	w.Titles = utils.GetEnvFloat("TTO_INTEREST_WEIGHT_TITLES", w.Titles)
+	w.Industry = utils.GetEnvFloat("TTO_INTEREST_WEIGHT_INDUSTRY", w.Industry)
+	return w
+}
+
+// Signals are the parts of a creator card that say what the creator posts about. The numeric
+// category IDs of the card are not used: they are not label IDs and there is no mapping between the
+// two.
+type Signals struct {
+	ContentLabels  []api.Label
+	IndustryLabels []api.Label
+	VideoTitles    []string
+}
+
+// SignalsFromCreator collects the signals of a creator card, with the titles of the recent and
+// popular videos.
+func SignalsFromCreator(creator api.Creator) Signals {
+	signals := Signals{
+		ContentLabels:  creator.ContentLabels,
+		IndustryLabels: creator.IndustryLabels,
+	}
+	videos := creator.StatisticData.VideoPerformance
+	for _, list := range [][]api.VideoItem{videos.RecentVideos, videos.PopularVideos, creator.RecentItems} {
+		for _, v := range list {
+			if v.Title != "" {
+				signals.VideoTitles = append(signals.VideoTitles, v.Title)
+			}
+		}
+	}
+	return signals
+}
+
+// Weighted is a content label with its share of the creator's content.
+type Weighted struct {
+	Label   api.Label
+	Percent float64
+}
+
+// Weigh scores every content label and normalises the scores to percentages that sum to 1.
+// Duplicate labels are merged. It returns nil when there is no content label.
+func Weigh(signals Signals, weights Weights) []Weighted {
+	labels := dedupeLabels(signals.ContentLabels)
+	if len(labels) == 0 {
+		return nil
+	}
+
+	industry := make(map[string]bool)
+	for _, l := range signals.IndustryLabels {
+		industry[normalise(l.LabelName)] = true
+		industry["id:"+l.LabelID] = true
+	}
+	titleTokens := make([]map[string]bool, 0, len(signals.VideoTitles))
+	for _, title := range signals.VideoTitles {
+		titleTokens = append(titleTokens, tokenSet(title))
+	}
+
+	// Rank weights 1, 1/2, 1/3, ... normalised over the label count
+	var harmonic float64
+	for i := range labels {
+		harmonic += 1 / float64(i+1)
+	}
+
+	scores := make([]float64, len(labels))
+	var total float64
+	for i, label := range labels {
+		score := weights.Order * (1 / float64(i+1)) / harmonic
+
+		if len(titleTokens) > 0 {
+			keywords := keywordsOf(label.LabelName)
+			matches := 0
+			for _, tokens := range titleTokens {
+				if containsAny(tokens, keywords) {
+					matches++
+				}
+			}
+			score += weights.Titles * float64(matches) / float64(len(titleTokens))
+		}
+		if industry[normalise(label.LabelName)] || industry["id:"+label.LabelID] {
+			score += weights.Industry
+		}
+
+		scores[i] = score
+		total += score
+	}
+
+	result := make([]Weighted, len(labels))
+	if total <= 0 {
+		// No signal at all: fall back to an even split
+		for i := range scores {
+			scores[i] = 1
+		}
+		total = float64(len(scores))
+	}
+
+	sum := 0.0
+	for i, label := range labels {
+		percent := math.Round(scores[i]/total*10000) / 10000
+		result[i] = Weighted{Label: label, Percent: percent}
+		sum += percent
+	}
+	// Put the rounding remainder on the largest share so the total stays exactly 1
+	largest := 0
+	for i := range result {
+		if result[i].Percent > result[largest].Percent {
+			largest = i
+		}
+	}
+	result[largest].Percent = math.Round((result[largest].Percent+1-sum)*10000) / 10000
+	return result
+}
+
+func dedupeLabels(labels []api.Label) []api.Label {
+	seen := make(map[string]bool)
+	out := make([]api.Label, 0, len(labels))
+	for _, l := range labels {
+		key := l.LabelID
+		if key == "" {
+			key = normalise(l.LabelName)
+		}
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, l)
+	}
+	return out
+}
+
+// keywordsOf splits a label name ("Beauty & Personal Care") into its meaningful words.
+func keywordsOf(name string) []string {
+	var out []string
+	for token := range tokenSet(name) {
+		if len([]rune(token)) >= 3 && !stopWords[token] {
+			out = append(out, token)
+		}
+	}
+	return out
+}
+
+// tokenSet lowercases text and splits it on anything that is not a letter or a digit, so hashtags
+// ("#skincare") and plain words are matched the same way.
+func tokenSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, token := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		set[token] = true
+	}
+	return set
+}
+
+func containsAny(tokens map[string]bool, keywords []string) bool {
+	for _, k := range keywords {
+		if tokens[k] {
+			return true
+		}
+	}
+	return false
+}
+
+func normalise(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+var stopWords = map[string]bool{"and": true, "the": true, "for": true, "other": true, "others": true}
@@ -0,0 +1,119 @@
+package interest
+
+import (
+	"math"
+	"testing"
+
+	"tto_chromedp/pkg/tto/api"
+)
+
+func labels(names ...string) []api.Label {
+	out := make([]api.Label, 0, len(names))
+	for i, name := range names {
+		out = append(out, api.Label{LabelID: string(rune('1' + i)), LabelName: name})
+	}
+	return out
+}
+
+func percents(weighted []Weighted) []float64 {
+	out := make([]float64, 0, len(weighted))
+	for _, w := range weighted {
+		out = append(out, w.Percent)
+	}
+	return out
+}
+
+func TestWeigh(t *testing.T) {
+	tests := []struct {
+		name    string
+		signals Signals
+		weights Weights
+		want    []float64
+	}{
+		{
+			name:    "order only: harmonic rank weights",
+			signals: Signals{ContentLabels: labels("Beauty", "Gaming", "Food")},
+			weights: Weights{Order: 1},
+			want:    []float64{0.5455, 0.2727, 0.1818},
+		},
+		{
+			name: "titles only: share of titles mentioning a keyword",
+			signals: Signals{
+				ContentLabels: labels("Beauty & Personal Care", "Gaming"),
+				VideoTitles:   []string{"#skincare and beauty tips", "Gaming night", "BEAUTY haul", "personal vlog"},
+			},
+			weights: Weights{Titles: 1},
+			// Beauty: 3 of 4 titles (beauty, beauty, personal), Gaming: 1 of 4
+			want: []float64{0.75, 0.25},
+		},
+		{
+			name: "industry only: by name or by ID",
+			signals: Signals{
+				ContentLabels:  labels("Food", "Travel", "Gaming"),
+				IndustryLabels: []api.Label{{LabelName: " food "}, {LabelID: "3", LabelName: "Video games"}},
+			},
+			weights: Weights{Industry: 1},
+			want:    []float64{0.5, 0, 0.5},
+		},
+		{
+			name: "weights combine",
+			signals: Signals{
+				ContentLabels:  labels("Food", "Travel"),
+				IndustryLabels: []api.Label{{LabelName: "Travel"}},
+				VideoTitles:    []string{"travel diary"},
+			},
+			weights: DefaultWeights(),
+			// Food: 0.5 * 2/3; Travel: 0.5 * 1/3 + 0.3 + 0.2
+			want: []float64{0.3333, 0.6667},
+		},
+		{
+			name:    "no signal: even split, remainder on the largest share",
+			signals: Signals{ContentLabels: labels("A", "B", "C")},
+			weights: Weights{},
+			want:    []float64{0.3334, 0.3333, 0.3333},
+		},
+		{
+			name:    "duplicate labels are merged",
+			signals: Signals{ContentLabels: append(labels("Beauty", "Gaming"), api.Label{LabelID: "1", LabelName: "Beauty"})},
+			weights: Weights{Order: 1},
+			want:    []float64{0.6667, 0.3333},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := percents(Weigh(tt.signals, tt.weights))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			sum := 0.0
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+				sum += got[i]
+			}
+			if math.Abs(sum-1) > 1e-9 {
+				t.Fatalf("shares sum to %v, want 1", sum)
+			}
+		})
+	}
+}
+
+func TestWeighWithoutLabels(t *testing.T) {
+	if got := Weigh(Signals{VideoTitles: []string{"beauty"}}, DefaultWeights()); got != nil {
+		t.Fatalf("got %v, want nil", got)
+	}
+}
+
+func TestSignalsFromCreator(t *testing.T) {
+	var creator api.Creator
+	creator.ContentLabels = labels("Beauty")
+	creator.StatisticData.VideoPerformance.RecentVideos = []api.VideoItem{{Title: "recent"}, {}}
+	creator.StatisticData.VideoPerformance.PopularVideos = []api.VideoItem{{Title: "popular"}}
+	creator.RecentItems = []api.VideoItem{{Title: "item"}}
+
+	signals := SignalsFromCreator(creator)
+	if len(signals.ContentLabels) != 1 || len(signals.VideoTitles) != 3 {
+		t.Fatalf("signals = %+v", signals)
+	}
+}
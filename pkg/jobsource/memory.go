@@ -0,0 +1,145 @@
+package jobsource
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+)
+
+// Memory is an in-memory Source with the same ack, retry and dead-letter semantics as the brokers,
+// without delays. It keeps what was acked, dead-lettered and published so a test can check it. Once
+// CloseInput was called, Receive returns ErrDone when nothing is queued or in flight.
+type Memory struct {
+	mu          sync.Mutex
+	changed     chan struct{}
+	queue       []Delivery
+	inFlight    map[string]bool
+	nextID      int
+	inputClosed bool
+
+	acked       []Delivery
+	deadLetters []DeadLetter
+	published   []Result
+}
+
+func NewMemory() *Memory {
+	return &Memory{changed: make(chan struct{}), inFlight: make(map[string]bool)}
+}
+
+func (m *Memory) Name() string { return "memory" }
+
+// Push queues a job.
+func (m *Memory) Push(req Request) {
+	body, _ := json.Marshal(req)
+	m.PushBody(body)
+}
+
+// PushBody queues a raw message, e.g. an invalid one.
+func (m *Memory) PushBody(body []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	m.queue = append(m.queue, Delivery{ID: strconv.Itoa(m.nextID), Body: body, Attempt: 1})
+	m.notifyLocked()
+}
+
+// CloseInput tells Receive that no more jobs will be pushed.
+func (m *Memory) CloseInput() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inputClosed = true
+	m.notifyLocked()
+}
+
+func (m *Memory) Receive(ctx context.Context) (Delivery, error) {
+	for {
+		m.mu.Lock()
+		if len(m.queue) > 0 {
+			d := m.queue[0]
+			m.queue = m.queue[1:]
+			m.inFlight[d.ID] = true
+			m.mu.Unlock()
+			return d, nil
+		}
+		if m.inputClosed && len(m.inFlight) == 0 {
+			m.mu.Unlock()
+			return Delivery{}, ErrDone
+		}
+		changed := m.changed
+		m.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Delivery{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (m *Memory) Ack(ctx context.Context, d Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.inFlight, d.ID)
+	m.acked = append(m.acked, d)
+	m.notifyLocked()
+	return nil
+}
+
+func (m *Memory) Retry(ctx context.Context, d Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.inFlight, d.ID)
+	d.Attempt++
+	m.queue = append(m.queue, d)
+	m.notifyLocked()
+	return nil
+}
+
+func (m *Memory) DeadLetter(ctx context.Context, d Delivery, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.inFlight, d.ID)
+	m.deadLetters = append(m.deadLetters, newDeadLetter(d, reason))
+	m.notifyLocked()
+	return nil
+}
+
+func (m *Memory) Publish(ctx context.Context, result Result) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published = append(m.published, result)
+	return nil
+}
+
+func (m *Memory) Close() error {
+	m.CloseInput()
+	return nil
+}
+
+// Acked returns the deliveries acked so far.
+func (m *Memory) Acked() []Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Delivery(nil), m.acked...)
+}
+
+// DeadLetters returns the dead letters so far.
+func (m *Memory) DeadLetters() []DeadLetter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]DeadLetter(nil), m.deadLetters...)
+}
+
+// Published returns the results published so far.
+func (m *Memory) Published() []Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Result(nil), m.published...)
+}
+
+// notifyLocked wakes up the Receive calls waiting for a change. The caller holds mu.
+func (m *Memory) notifyLocked() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
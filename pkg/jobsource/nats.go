@@ -0,0 +1,156 @@
+package jobsource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSConfig points at the JetStream stream of a NATS job queue.
+type NATSConfig struct {
+	URL string
+	// Stream is created if needed and captures Subject, ResultSubject and DeadLetterSubject.
+	Stream            string
+	Subject           string
+	ResultSubject     string
+	DeadLetterSubject string
+	Durable           string
+	// AckWait is how long a delivery may stay unacked before it is delivered again, so it must be
+	// longer than a crawl.
+	AckWait    time.Duration
+	RetryDelay time.Duration
+}
+
+// NATSSource consumes a subject through a durable JetStream pull consumer with explicit acks. The
+// attempt is the delivery count of JetStream; a retry is a NAK with the retry delay, and a dead
+// letter is published on DeadLetterSubject before the message is terminated.
+type NATSSource struct {
+	cfg      NATSConfig
+	conn     *nats.Conn
+	js       jetstream.JetStream
+	consumer jetstream.Consumer
+}
+
+// NewNATSSource connects to NATS and creates the stream and the durable consumer if needed.
+func NewNATSSource(ctx context.Context, cfg NATSConfig) (*NATSSource, error) {
+	conn, err := nats.Connect(cfg.URL, nats.Name(consumerName()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     cfg.Stream,
+		Subjects: []string{cfg.Subject, cfg.ResultSubject, cfg.DeadLetterSubject},
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create stream %s: %w", cfg.Stream, err)
+	}
+	consumer, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       cfg.Durable,
+		FilterSubject: cfg.Subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       cfg.AckWait,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create consumer %s on %s: %w", cfg.Durable, cfg.Stream, err)
+	}
+	return &NATSSource{cfg: cfg, conn: conn, js: js, consumer: consumer}, nil
+}
+
+func (s *NATSSource) Name() string { return SOURCE_NATS }
+
+func (s *NATSSource) Receive(ctx context.Context) (Delivery, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return Delivery{}, err
+		}
+		msg, err := s.consumer.Next(jetstream.FetchMaxWait(5 * time.Second))
+		if errors.Is(err, nats.ErrTimeout) || errors.Is(err, jetstream.ErrNoMessages) {
+			continue
+		}
+		if err != nil {
+			return Delivery{}, fmt.Errorf("failed to fetch jobs from %s: %w", s.cfg.Subject, err)
+		}
+		d := Delivery{Body: msg.Data(), Attempt: 1, msg: msg}
+		if meta, err := msg.Metadata(); err == nil {
+			d.ID = strconv.FormatUint(meta.Sequence.Stream, 10)
+			d.Attempt = int(meta.NumDelivered)
+		}
+		return d, nil
+	}
+}
+
+func natsMsg(d Delivery) (jetstream.Msg, error) {
+	msg, ok := d.msg.(jetstream.Msg)
+	if !ok {
+		return nil, fmt.Errorf("job %s was not received from NATS", d.ID)
+	}
+	return msg, nil
+}
+
+func (s *NATSSource) Ack(ctx context.Context, d Delivery) error {
+	msg, err := natsMsg(d)
+	if err != nil {
+		return err
+	}
+	if err := msg.DoubleAck(ctx); err != nil {
+		return fmt.Errorf("failed to ack job %s: %w", d.ID, err)
+	}
+	return nil
+}
+
+func (s *NATSSource) Retry(ctx context.Context, d Delivery) error {
+	msg, err := natsMsg(d)
+	if err != nil {
+		return err
+	}
+	if err := msg.NakWithDelay(s.cfg.RetryDelay); err != nil {
+		return fmt.Errorf("failed to retry job %s: %w", d.ID, err)
+	}
+	return nil
+}
+
+func (s *NATSSource) DeadLetter(ctx context.Context, d Delivery, reason string) error {
+	msg, err := natsMsg(d)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(newDeadLetter(d, reason))
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter %s: %w", d.ID, err)
+	}
+	if _, err := s.js.Publish(ctx, s.cfg.DeadLetterSubject, body); err != nil {
+		return fmt.Errorf("failed to dead-letter job %s: %w", d.ID, err)
+	}
+	if err := msg.Term(); err != nil {
+		return fmt.Errorf("failed to terminate job %s: %w", d.ID, err)
+	}
+	return nil
+}
+
+func (s *NATSSource) Publish(ctx context.Context, result Result) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result of job %s: %w", result.MessageID, err)
+	}
+	if _, err := s.js.Publish(ctx, s.cfg.ResultSubject, body); err != nil {
+		return fmt.Errorf("failed to publish result of job %s: %w", result.MessageID, err)
+	}
+	return nil
+}
+
+func (s *NATSSource) Close() error {
+	return s.conn.Drain()
+}
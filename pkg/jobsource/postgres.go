@@ -0,0 +1,160 @@
+package jobsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/postgre"
+	"tto_chromedp/pkg/utils"
+)
+
+// PostgresSource polls crawler.social_profiles with GetSocialProfileCrawlTTO. The row is the queue:
+// a crawled profile leaves the selection once UpdateTTOUser commits its done status, so Ack has
+// nothing left to do, and the dead-letter queue is the skipped status. Delivery attempts are counted
+// in memory only, and kept until the row leaves the selection: a profile acked while still pending
+// (e.g. without the postgres sink) comes back with its next attempt and is dead-lettered in the end.
+type PostgresSource struct {
+	repo       postgre.SocialProfileRepository
+	selection  models.CrawlSelection
+	poll       time.Duration
+	retryDelay time.Duration
+
+	mu        sync.Mutex
+	buffer    []models.SocialProfile
+	inFlight  map[int]bool
+	attempts  map[int]int
+	notBefore map[int]time.Time
+}
+
+func NewPostgresSource(repo postgre.SocialProfileRepository, selection models.CrawlSelection, poll, retryDelay time.Duration) *PostgresSource {
+	return &PostgresSource{
+		repo:       repo,
+		selection:  selection,
+		poll:       poll,
+		retryDelay: retryDelay,
+		inFlight:   make(map[int]bool),
+		attempts:   make(map[int]int),
+		notBefore:  make(map[int]time.Time),
+	}
+}
+
+func (s *PostgresSource) Name() string { return SOURCE_POSTGRES }
+
+func (s *PostgresSource) Receive(ctx context.Context) (Delivery, error) {
+	for {
+		if d, ok := s.next(); ok {
+			return d, nil
+		}
+		profiles, err := s.repo.GetSocialProfileCrawlTTO(ctx, s.selection)
+		if err != nil {
+			return Delivery{}, fmt.Errorf("failed to poll profiles to crawl: %w", err)
+		}
+		if s.fill(profiles) {
+			continue
+		}
+
+		timer := time.NewTimer(s.poll)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Delivery{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// next claims the first buffered profile.
+func (s *PostgresSource) next() (Delivery, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.buffer) > 0 {
+		profile := s.buffer[0]
+		s.buffer = s.buffer[1:]
+		if s.inFlight[profile.ID] {
+			continue
+		}
+		body, err := json.Marshal(Request{SocialProfileID: profile.ID, Handle: profile.UserName})
+		if err != nil {
+			continue
+		}
+		s.inFlight[profile.ID] = true
+		s.attempts[profile.ID]++
+		return Delivery{ID: strconv.Itoa(profile.ID), Body: body, Attempt: s.attempts[profile.ID]}, true
+	}
+	return Delivery{}, false
+}
+
+// fill buffers the polled profiles that are neither in flight nor waiting for a retry. It returns
+// whether any was buffered.
+func (s *PostgresSource) fill(profiles []models.SocialProfile) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	polled := make(map[int]bool, len(profiles))
+	for _, profile := range profiles {
+		polled[profile.ID] = true
+	}
+	// The profiles that left the selection are done with, forget their attempts
+	for id := range s.attempts {
+		if !polled[id] && !s.inFlight[id] {
+			delete(s.attempts, id)
+			delete(s.notBefore, id)
+		}
+	}
+	for _, profile := range profiles {
+		if s.inFlight[profile.ID] || now.Before(s.notBefore[profile.ID]) {
+			continue
+		}
+		s.buffer = append(s.buffer, profile)
+	}
+	return len(s.buffer) > 0
+}
+
+func (s *PostgresSource) Ack(ctx context.Context, d Delivery) error {
+	s.release(d)
+	return nil
+}
+
+func (s *PostgresSource) Retry(ctx context.Context, d Delivery) error {
+	s.release(d)
+	return nil
+}
+
+func (s *PostgresSource) DeadLetter(ctx context.Context, d Delivery, reason string) error {
+	id, err := strconv.Atoi(d.ID)
+	if err != nil {
+		return fmt.Errorf("invalid profile ID %q: %w", d.ID, err)
+	}
+	if err := s.repo.UpdateTTOCreatorStatus(ctx, id, utils.TTO_CREATOR_STATUS_SKIPPED); err != nil {
+		return err
+	}
+	s.release(d)
+	return nil
+}
+
+// Publish does nothing: the result is the row UpdateTTOUser wrote.
+func (s *PostgresSource) Publish(ctx context.Context, result Result) error {
+	return nil
+}
+
+func (s *PostgresSource) Close() error {
+	return nil
+}
+
+// release drops the claim of a delivery. Whether it was acked or retried, a profile still in the
+// selection is not handed out again before the retry delay.
+func (s *PostgresSource) release(d Delivery) {
+	id, err := strconv.Atoi(d.ID)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inFlight, id)
+	s.notBefore[id] = time.Now().Add(s.retryDelay)
+}
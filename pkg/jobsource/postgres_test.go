@@ -0,0 +1,87 @@
+package jobsource
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/postgre"
+	"tto_chromedp/pkg/utils"
+)
+
+// fakeProfiles serves a fixed pending selection. The methods the source does not use are left to
+// the nil embedded interface.
+type fakeProfiles struct {
+	postgre.SocialProfileRepository
+
+	mu       sync.Mutex
+	pending  []models.SocialProfile
+	statuses map[int]int
+}
+
+func (r *fakeProfiles) GetSocialProfileCrawlTTO(ctx context.Context, selection models.CrawlSelection) ([]models.SocialProfile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var profiles []models.SocialProfile
+	for _, profile := range r.pending {
+		if _, done := r.statuses[profile.ID]; !done {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles, nil
+}
+
+func (r *fakeProfiles) UpdateTTOCreatorStatus(ctx context.Context, userID int, status int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses[userID] = status
+	return nil
+}
+
+func TestPostgresSourceCountsAttemptsUntilTheRowLeaves(t *testing.T) {
+	repo := &fakeProfiles{pending: []models.SocialProfile{{ID: 7, UserName: "alice"}}, statuses: make(map[int]int)}
+	source := NewPostgresSource(repo, models.CrawlSelection{}, 0, 0)
+	ctx := context.Background()
+
+	// Acked or retried, a profile still pending comes back with its next attempt
+	for attempt := 1; attempt <= 3; attempt++ {
+		d, err := source.Receive(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.ID != "7" || d.Attempt != attempt {
+			t.Fatalf("delivery = %+v, want attempt %d of profile 7", d, attempt)
+		}
+		if attempt%2 == 1 {
+			err = source.Ack(ctx, d)
+		} else {
+			err = source.Retry(ctx, d)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d, err := source.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := source.DeadLetter(ctx, d, "failed"); err != nil {
+		t.Fatal(err)
+	}
+	if repo.statuses[7] != utils.TTO_CREATOR_STATUS_SKIPPED {
+		t.Errorf("status = %d, want skipped", repo.statuses[7])
+	}
+
+	// A poll without the profile forgets it: back in the selection (e.g. reset by hand), it starts over
+	source.fill(nil)
+	delete(repo.statuses, 7)
+	d, err = source.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Attempt != 1 {
+		t.Errorf("attempt = %d after the row left the selection, want 1", d.Attempt)
+	}
+}
@@ -0,0 +1,213 @@
+package jobsource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisConfig points at the streams of a Redis job queue.
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+
+	// Stream receives the jobs as entries with a "body" field holding a Request.
+	Stream           string
+	Group            string
+	ResultStream     string
+	DeadLetterStream string
+	RetryDelay       time.Duration
+	// ClaimIdle is how long a delivery stays pending before another consumer takes it over, so it
+	// must be longer than a crawl.
+	ClaimIdle time.Duration
+}
+
+// RedisSource consumes a Redis stream through a consumer group. Entries are XACKed when final; a
+// retried entry is parked in the sorted set <stream>:retry until its delay is over and then added
+// to the stream again with the next attempt, and the entries of a dead consumer are claimed back.
+type RedisSource struct {
+	cfg      RedisConfig
+	client   *redis.Client
+	consumer string
+	retryKey string
+}
+
+// redisRetry is a member of the retry sorted set.
+type redisRetry struct {
+	ID      string `json:"id"`
+	Body    string `json:"body"`
+	Attempt int    `json:"attempt"`
+}
+
+// NewRedisSource connects to Redis and creates the consumer group (and the stream) if needed.
+func NewRedisSource(ctx context.Context, cfg RedisConfig) (*RedisSource, error) {
+	client := redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB})
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %w", cfg.Addr, err)
+	}
+	err := client.XGroupCreateMkStream(ctx, cfg.Stream, cfg.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		client.Close()
+		return nil, fmt.Errorf("failed to create consumer group %s on %s: %w", cfg.Group, cfg.Stream, err)
+	}
+	return &RedisSource{cfg: cfg, client: client, consumer: consumerName(), retryKey: cfg.Stream + ":retry"}, nil
+}
+
+func (s *RedisSource) Name() string { return SOURCE_REDIS }
+
+func (s *RedisSource) Receive(ctx context.Context) (Delivery, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return Delivery{}, err
+		}
+		if err := s.promoteRetries(ctx); err != nil {
+			return Delivery{}, err
+		}
+
+		// Entries left pending by a consumer that died come first
+		claimed, _, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   s.cfg.Stream,
+			Group:    s.cfg.Group,
+			Consumer: s.consumer,
+			MinIdle:  s.cfg.ClaimIdle,
+			Start:    "0-0",
+			Count:    1,
+		}).Result()
+		if err != nil {
+			return Delivery{}, fmt.Errorf("failed to claim pending jobs of %s: %w", s.cfg.Stream, err)
+		}
+		if len(claimed) > 0 {
+			return redisDelivery(claimed[0]), nil
+		}
+
+		streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.cfg.Group,
+			Consumer: s.consumer,
+			Streams:  []string{s.cfg.Stream, ">"},
+			Count:    1,
+			Block:    5 * time.Second,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return Delivery{}, ctx.Err()
+			}
+			return Delivery{}, fmt.Errorf("failed to read jobs from %s: %w", s.cfg.Stream, err)
+		}
+		for _, stream := range streams {
+			if len(stream.Messages) > 0 {
+				return redisDelivery(stream.Messages[0]), nil
+			}
+		}
+	}
+}
+
+// promoteRetries adds the retried entries whose delay is over back to the stream. ZREM decides
+// which consumer moves an entry when several see it.
+func (s *RedisSource) promoteRetries(ctx context.Context) error {
+	due, err := s.client.ZRangeByScore(ctx, s.retryKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: 10,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read retried jobs of %s: %w", s.cfg.Stream, err)
+	}
+	for _, member := range due {
+		removed, err := s.client.ZRem(ctx, s.retryKey, member).Result()
+		if err != nil {
+			return fmt.Errorf("failed to take retried job of %s: %w", s.cfg.Stream, err)
+		}
+		if removed == 0 {
+			continue
+		}
+		var retry redisRetry
+		if err := json.Unmarshal([]byte(member), &retry); err != nil {
+			continue
+		}
+		if err := s.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: s.cfg.Stream,
+			Values: map[string]any{"body": retry.Body, "attempt": retry.Attempt},
+		}).Err(); err != nil {
+			return fmt.Errorf("failed to requeue job %s: %w", retry.ID, err)
+		}
+	}
+	return nil
+}
+
+func redisDelivery(msg redis.XMessage) Delivery {
+	d := Delivery{ID: msg.ID, Attempt: 1}
+	if body, ok := msg.Values["body"].(string); ok {
+		d.Body = []byte(body)
+	}
+	if attempt, ok := msg.Values["attempt"].(string); ok {
+		if n, err := strconv.Atoi(attempt); err == nil && n > 0 {
+			d.Attempt = n
+		}
+	}
+	return d
+}
+
+func (s *RedisSource) Ack(ctx context.Context, d Delivery) error {
+	if err := s.client.XAck(ctx, s.cfg.Stream, s.cfg.Group, d.ID).Err(); err != nil {
+		return fmt.Errorf("failed to ack job %s: %w", d.ID, err)
+	}
+	return nil
+}
+
+func (s *RedisSource) Retry(ctx context.Context, d Delivery) error {
+	member, err := json.Marshal(redisRetry{ID: d.ID, Body: string(d.Body), Attempt: d.Attempt + 1})
+	if err != nil {
+		return fmt.Errorf("failed to encode retried job %s: %w", d.ID, err)
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, s.retryKey, redis.Z{Score: float64(time.Now().Add(s.cfg.RetryDelay).UnixMilli()), Member: string(member)})
+		pipe.XAck(ctx, s.cfg.Stream, s.cfg.Group, d.ID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to retry job %s: %w", d.ID, err)
+	}
+	return nil
+}
+
+func (s *RedisSource) DeadLetter(ctx context.Context, d Delivery, reason string) error {
+	body, err := json.Marshal(newDeadLetter(d, reason))
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter %s: %w", d.ID, err)
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{Stream: s.cfg.DeadLetterStream, Values: map[string]any{"body": string(body)}})
+		pipe.XAck(ctx, s.cfg.Stream, s.cfg.Group, d.ID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter job %s: %w", d.ID, err)
+	}
+	return nil
+}
+
+func (s *RedisSource) Publish(ctx context.Context, result Result) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result of job %s: %w", result.MessageID, err)
+	}
+	if err := s.client.XAdd(ctx, &redis.XAddArgs{Stream: s.cfg.ResultStream, Values: map[string]any{"body": string(body)}}).Err(); err != nil {
+		return fmt.Errorf("failed to publish result of job %s: %w", result.MessageID, err)
+	}
+	return nil
+}
+
+func (s *RedisSource) Close() error {
+	return s.client.Close()
+}
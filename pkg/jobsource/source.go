@@ -0,0 +1,157 @@
+// Package jobsource abstracts where the crawl jobs of the consume mode come from: the Postgres poller
+// over GetSocialProfileCrawlTTO, a Redis stream or a NATS JetStream subject. A job is acked only once
+// its result is committed; a job that keeps failing, or that cannot be decoded or resolved, goes to
+// the dead-letter queue of the backend. Memory is an in-memory Source for tests and local lists.
+package jobsource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/postgre"
+	"tto_chromedp/pkg/utils"
+)
+
+const (
+	SOURCE_POSTGRES = "postgres"
+	SOURCE_REDIS    = "redis"
+	SOURCE_NATS     = "nats"
+
+	// DEFAULT_MAX_DELIVERIES is the number of deliveries of a job before it is dead-lettered.
+	DEFAULT_MAX_DELIVERIES = 3
+	// DEFAULT_RETRY_DELAY is how long a retried job waits before it is delivered again.
+	DEFAULT_RETRY_DELAY = time.Minute
+)
+
+// ErrDone is returned by Receive when a finite source has no job left.
+var ErrDone = errors.New("job source is exhausted")
+
+// Request is the body of a job message: the social profile to crawl, by ID or by handle.
+type Request struct {
+	SocialProfileID int    `json:"social_profile_id,omitempty"`
+	Handle          string `json:"handle,omitempty"`
+}
+
+// Delivery is one delivery of a job message.
+type Delivery struct {
+	// ID is the message ID of the backend (stream entry ID, stream sequence or profile ID).
+	ID   string
+	Body []byte
+	// Attempt is 1 on the first delivery.
+	Attempt int
+
+	// msg is the backend message the delivery is acked on, if any.
+	msg any
+}
+
+// Decode parses the body of the delivery.
+func (d Delivery) Decode() (Request, error) {
+	var req Request
+	if err := json.Unmarshal(d.Body, &req); err != nil {
+		return Request{}, fmt.Errorf("invalid job message %s: %w", d.ID, err)
+	}
+	if req.SocialProfileID == 0 && strings.TrimSpace(req.Handle) == "" {
+		return Request{}, fmt.Errorf("job message %s has neither social_profile_id nor handle", d.ID)
+	}
+	return req, nil
+}
+
+// Result is published on the output topic once a job is final.
+type Result struct {
+	MessageID   string               `json:"message_id"`
+	Request     Request              `json:"request"`
+	Profile     models.SocialProfile `json:"profile"`
+	Outcome     string               `json:"outcome"`
+	Entry       models.CrawlRunEntry `json:"entry"`
+	TTOUser     *models.TTOUser      `json:"tto_user,omitempty"`
+	PublishedAt time.Time            `json:"published_at"`
+}
+
+// DeadLetter is what goes to the dead-letter queue for a poison job.
+type DeadLetter struct {
+	MessageID string    `json:"message_id"`
+	Body      string    `json:"body"`
+	Attempt   int       `json:"attempt"`
+	Reason    string    `json:"reason"`
+	DeadAt    time.Time `json:"dead_at"`
+}
+
+// Source is a queue of crawl jobs with at-least-once delivery.
+type Source interface {
+	// Name is the backend name, used as metric label.
+	Name() string
+	// Receive blocks until the next delivery. It returns ctx.Err() when ctx is done, and ErrDone
+	// when a finite source is exhausted.
+	Receive(ctx context.Context) (Delivery, error)
+	// Ack removes a delivery for good. Only call it once the result is committed.
+	Ack(ctx context.Context, d Delivery) error
+	// Retry hands a delivery back, to be delivered again with the next Attempt after the retry delay.
+	Retry(ctx context.Context, d Delivery) error
+	// DeadLetter moves a poison delivery to the dead-letter queue and acks it.
+	DeadLetter(ctx context.Context, d Delivery, reason string) error
+	// Publish sends a result on the output topic of the source.
+	Publish(ctx context.Context, result Result) error
+	Close() error
+}
+
+// NewFromEnv builds the source of the given kind (postgres, redis or nats) from the environment.
+//
+// postgres polls the profiles of selection every TTO_PENDING_POLL. redis uses TTO_REDIS_ADDR,
+// TTO_REDIS_PASSWORD, TTO_REDIS_DB, TTO_REDIS_STREAM, TTO_REDIS_GROUP, TTO_REDIS_RESULT_STREAM,
+// TTO_REDIS_DLQ_STREAM and TTO_REDIS_CLAIM_IDLE. nats uses TTO_NATS_URL, TTO_NATS_STREAM, TTO_NATS_SUBJECT,
+// TTO_NATS_RESULT_SUBJECT, TTO_NATS_DLQ_SUBJECT, TTO_NATS_DURABLE and TTO_NATS_ACK_WAIT. All of them
+// wait TTO_SOURCE_RETRY_DELAY before a retried job is delivered again.
+func NewFromEnv(ctx context.Context, kind string, repo postgre.SocialProfileRepository, selection models.CrawlSelection) (Source, error) {
+	retryDelay := utils.GetEnvDuration("TTO_SOURCE_RETRY_DELAY", DEFAULT_RETRY_DELAY)
+	switch kind {
+	case SOURCE_POSTGRES:
+		return NewPostgresSource(repo, selection, utils.GetEnvDuration("TTO_PENDING_POLL", time.Minute), retryDelay), nil
+	case SOURCE_REDIS:
+		stream := utils.GetEnvString("TTO_REDIS_STREAM", "tto:crawl")
+		return NewRedisSource(ctx, RedisConfig{
+			Addr:             utils.GetEnvString("TTO_REDIS_ADDR", "localhost:6379"),
+			Password:         os.Getenv("TTO_REDIS_PASSWORD"),
+			DB:               utils.GetEnvInt("TTO_REDIS_DB", 0),
+			Stream:           stream,
+			Group:            utils.GetEnvString("TTO_REDIS_GROUP", "tto-crawler"),
+			ResultStream:     utils.GetEnvString("TTO_REDIS_RESULT_STREAM", stream+":results"),
+			DeadLetterStream: utils.GetEnvString("TTO_REDIS_DLQ_STREAM", stream+":dlq"),
+			RetryDelay:       retryDelay,
+			ClaimIdle:        utils.GetEnvDuration("TTO_REDIS_CLAIM_IDLE", 30*time.Minute),
+		})
+	case SOURCE_NATS:
+		subject := utils.GetEnvString("TTO_NATS_SUBJECT", "tto.crawl")
+		return NewNATSSource(ctx, NATSConfig{
+			URL:               utils.GetEnvString("TTO_NATS_URL", "nats://localhost:4222"),
+			Stream:            utils.GetEnvString("TTO_NATS_STREAM", "TTO_CRAWL"),
+			Subject:           subject,
+			ResultSubject:     utils.GetEnvString("TTO_NATS_RESULT_SUBJECT", subject+".results"),
+			DeadLetterSubject: utils.GetEnvString("TTO_NATS_DLQ_SUBJECT", subject+".dlq"),
+			Durable:           utils.GetEnvString("TTO_NATS_DURABLE", "tto-crawler"),
+			AckWait:           utils.GetEnvDuration("TTO_NATS_ACK_WAIT", 30*time.Minute),
+			RetryDelay:        retryDelay,
+		})
+	default:
+		return nil, fmt.Errorf("unknown job source %q (expected %s, %s or %s)", kind, SOURCE_POSTGRES, SOURCE_REDIS, SOURCE_NATS)
+	}
+}
+
+// newDeadLetter builds the dead-letter message of a delivery.
+func newDeadLetter(d Delivery, reason string) DeadLetter {
+	return DeadLetter{MessageID: d.ID, Body: string(d.Body), Attempt: d.Attempt, Reason: reason, DeadAt: time.Now()}
+}
+
+// consumerName identifies this process among the consumers of a group.
+func consumerName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "tto"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
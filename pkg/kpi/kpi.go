@@ -0,0 +1,113 @@
+// Package kpi derives campaign matching metrics from the videos captured on a creator card.
+package kpi
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/tto/api"
+)
+
+const secondsPerWeek = 7 * 24 * 60 * 60
+
+// Compute returns the KPIs of a creator, or nil when no video was captured. The recent videos are
+// used when present since the popular videos overstate the typical reach; the popular videos are
+// only used on their own as a fallback. A video listed twice is counted once.
+func Compute(creator api.Creator) *models.CreatorKPI {
+	perf := creator.StatisticData.VideoPerformance
+	videos := uniqueVideos(perf.RecentVideos)
+	if len(videos) == 0 {
+		videos = uniqueVideos(perf.PopularVideos)
+	}
+	if len(videos) == 0 {
+		return nil
+	}
+
+	var totalViews, totalEngagements int64
+	var sponsored, boosted int
+	views := make([]int64, 0, len(videos))
+	oldest, newest := int64(math.MaxInt64), int64(0)
+	for _, video := range videos {
+		v := video.Views.Int64()
+		views = append(views, v)
+		totalViews += v
+		totalEngagements += int64(video.Heart + video.Comment + video.Share)
+		if video.IsSponsoredVideo {
+			sponsored++
+		}
+		if video.IsBoosted {
+			boosted++
+		}
+		if created := video.CreateTime.Int64(); created > 0 {
+			oldest = min(oldest, created)
+			newest = max(newest, created)
+		}
+	}
+
+	count := float64(len(videos))
+	kpi := &models.CreatorKPI{
+		VideoCount:     len(videos),
+		AvgViews:       round(float64(totalViews) / count),
+		MedianViews:    median(views),
+		SponsoredRatio: round(float64(sponsored) / count),
+		BoostedRatio:   round(float64(boosted) / count),
+		ComputedAt:     time.Now(),
+	}
+	if totalViews > 0 {
+		kpi.EngagementRate = round(float64(totalEngagements) / float64(totalViews))
+	}
+	if len(videos) > 1 && newest > oldest {
+		kpi.PostsPerWeek = round(float64(len(videos)-1) / (float64(newest-oldest) / secondsPerWeek))
+	}
+
+	overall := creator.StatisticData.OverallPerformance
+	kpi.Benchmark = models.KPIBenchmark{
+		EngagementRate:      overall.EngagementRateBenchMark,
+		EngagementRateIndex: index(kpi.EngagementRate, overall.EngagementRateBenchMark),
+		EngagementRateRank:  overall.EngagementRateRank,
+		MedianViews:         overall.MedianBenchMarkViews,
+		MedianViewsIndex:    index(kpi.MedianViews, float64(overall.MedianBenchMarkViews)),
+		MedianViewsRank:     overall.MedianViewsRank,
+		FollowersGrowthRank: overall.FollowersGrowthRateRank,
+		VideoCompleteRank:   overall.VideoCompleteRateRank,
+	}
+	return kpi
+}
+
+func uniqueVideos(videos []api.VideoItem) []api.VideoItem {
+	seen := make(map[string]bool)
+	out := make([]api.VideoItem, 0, len(videos))
+	for _, video := range videos {
+		if video.ItemID != "" {
+			if seen[video.ItemID] {
+				continue
+			}
+			seen[video.ItemID] = true
+		}
+		out = append(out, video)
+	}
+	return out
+}
+
+func median(values []int64) float64 {
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return float64(sorted[mid-1]+sorted[mid]) / 2
+	}
+	return float64(sorted[mid])
+}
+
+func index(value, benchmark float64) float64 {
+	if benchmark <= 0 {
+		return 0
+	}
+	return round(value / benchmark)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
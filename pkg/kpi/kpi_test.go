@@ -0,0 +1,131 @@
+package kpi
+
+import (
+	"testing"
+	"time"
+
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/tto/api"
+)
+
+var published = time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+func video(id string, daysAfter int, views int64, engagements int) api.VideoItem {
+	return api.VideoItem{
+		ItemID:     id,
+		CreateTime: api.StringInt(published.AddDate(0, 0, daysAfter).Unix()),
+		Views:      api.StringInt(views),
+		Heart:      engagements,
+	}
+}
+
+func creatorWith(recent, popular []api.VideoItem) api.Creator {
+	var creator api.Creator
+	creator.StatisticData.VideoPerformance.RecentVideos = recent
+	creator.StatisticData.VideoPerformance.PopularVideos = popular
+	return creator
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values []int64
+		want   float64
+	}{
+		{[]int64{7}, 7},
+		{[]int64{300, 100, 200}, 200},
+		{[]int64{400, 100, 300, 200}, 250},
+		{[]int64{0, 0}, 0},
+	}
+	for _, tt := range tests {
+		if got := median(tt.values); got != tt.want {
+			t.Errorf("median(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	recent := []api.VideoItem{
+		video("a", 0, 100, 10),
+		video("b", 7, 300, 20),
+		video("c", 14, 200, 30),
+		video("d", 21, 0, 0),
+		video("b", 7, 300, 20), // listed twice
+	}
+	recent[0].IsSponsoredVideo = true
+	recent[1].IsBoosted = true
+	recent[2].IsBoosted = true
+	recent[2].Comment, recent[2].Share = 5, 5
+
+	creator := creatorWith(recent, []api.VideoItem{video("p", 0, 1_000_000, 0)})
+	creator.StatisticData.OverallPerformance.EngagementRateBenchMark = 0.05
+	creator.StatisticData.OverallPerformance.MedianBenchMarkViews = 300
+
+	got := Compute(creator)
+	if got == nil {
+		t.Fatal("Compute() = nil")
+	}
+	want := models.CreatorKPI{
+		VideoCount:     4,
+		AvgViews:       150,
+		MedianViews:    150,
+		EngagementRate: 0.1167, // 70 engagements / 600 views
+		PostsPerWeek:   1,      // 3 intervals over 3 weeks
+		SponsoredRatio: 0.25,
+		BoostedRatio:   0.5,
+		Benchmark: models.KPIBenchmark{
+			EngagementRate:      0.05,
+			EngagementRateIndex: 2.334,
+			MedianViews:         300,
+			MedianViewsIndex:    0.5,
+		},
+	}
+	got.ComputedAt = time.Time{}
+	if *got != want {
+		t.Fatalf("Compute() = %+v\nwant %+v", *got, want)
+	}
+}
+
+func TestComputeFallsBackToPopularVideos(t *testing.T) {
+	got := Compute(creatorWith(nil, []api.VideoItem{video("p", 0, 500, 50)}))
+	if got == nil || got.VideoCount != 1 || got.AvgViews != 500 || got.EngagementRate != 0.1 {
+		t.Fatalf("Compute() = %+v", got)
+	}
+	// A single video gives no posting interval
+	if got.PostsPerWeek != 0 {
+		t.Fatalf("posts per week = %v, want 0", got.PostsPerWeek)
+	}
+}
+
+func TestComputeWithoutVideos(t *testing.T) {
+	if got := Compute(creatorWith(nil, nil)); got != nil {
+		t.Fatalf("Compute() = %+v, want nil", got)
+	}
+}
+
+func TestComputeWithoutViews(t *testing.T) {
+	videos := []api.VideoItem{video("a", 0, 0, 5), {ItemID: "b", IsSponsoredVideo: true}}
+	creator := creatorWith(videos, nil)
+	creator.StatisticData.OverallPerformance.EngagementRateBenchMark = 0.05
+	creator.StatisticData.OverallPerformance.MedianBenchMarkViews = 300
+
+	got := Compute(creator)
+	if got == nil {
+		t.Fatal("Compute() = nil")
+	}
+	// No division by zero views, and a video without a timestamp gives no posting interval
+	if got.AvgViews != 0 || got.MedianViews != 0 || got.EngagementRate != 0 || got.PostsPerWeek != 0 {
+		t.Fatalf("Compute() = %+v", got)
+	}
+	if got.SponsoredRatio != 0.5 || got.BoostedRatio != 0 {
+		t.Fatalf("ratios = %v sponsored, %v boosted", got.SponsoredRatio, got.BoostedRatio)
+	}
+	if got.Benchmark.EngagementRateIndex != 0 || got.Benchmark.MedianViewsIndex != 0 {
+		t.Fatalf("benchmark = %+v", got.Benchmark)
+	}
+}
+
+func TestIndexWithoutBenchmark(t *testing.T) {
+	if got := index(0.1, 0); got != 0 {
+		t.Fatalf("index(0.1, 0) = %v, want 0", got)
+	}
+}
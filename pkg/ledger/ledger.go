@@ -0,0 +1,155 @@
+// Package ledger records the outcome of every KOL of a crawl run and reports it at exit as a
+// summary table, JSON and CSV files, and a row of crawler.crawl_runs.
+package ledger
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"tto_chromedp/pkg/models"
+)
+
+// ERROR_CLASS_PERSIST is the error class of a KOL crawled successfully whose data could not be stored.
+const ERROR_CLASS_PERSIST = "persist"
+
+// Ledger collects the entries of a run. It is safe for concurrent use.
+type Ledger struct {
+	mu  sync.Mutex
+	run models.CrawlRun
+}
+
+// New starts the ledger of a run.
+func New(runID, mode, account string) *Ledger {
+	return &Ledger{run: models.CrawlRun{
+		RunID:     runID,
+		Mode:      mode,
+		Account:   account,
+		Status:    models.CrawlRunRunning,
+		StartedAt: time.Now(),
+	}}
+}
+
+// Resume reopens the ledger of an interrupted run with the entries it had already recorded.
+func Resume(runID, mode, account string, startedAt time.Time, entries models.CrawlRunEntries) *Ledger {
+	l := New(runID, mode, account)
+	l.run.StartedAt = startedAt
+	l.run.Entries = append(models.CrawlRunEntries(nil), entries...)
+	return l
+}
+
+// Record adds the outcome of one KOL.
+func (l *Ledger) Record(entry models.CrawlRunEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.run.Entries = append(l.run.Entries, entry)
+}
+
+// Finish closes the run with the given status (models.CrawlRunFinished or models.CrawlRunAborted).
+func (l *Ledger) Finish(status string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.run.Status = status
+	l.run.FinishedAt = time.Now()
+}
+
+// Run returns a copy of the run.
+func (l *Ledger) Run() models.CrawlRun {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	run := l.run
+	run.Entries = append(models.CrawlRunEntries(nil), l.run.Entries...)
+	return run
+}
+
+// Counts renders the outcome counts as "outcome=n" pairs in the order of models.CrawlOutcomes.
+func (l *Ledger) Counts() string {
+	run := l.Run()
+	parts := make([]string, 0, len(models.CrawlOutcomes))
+	for _, outcome := range models.CrawlOutcomes {
+		parts = append(parts, fmt.Sprintf("%s=%d", outcome, run.Count(outcome)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// WriteTable prints one line per KOL followed by the outcome counts.
+func (l *Ledger) WriteTable(w io.Writer) error {
+	run := l.Run()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Run %s (%s, account %s): %s\n", run.RunID, run.Mode, run.Account, run.Status)
+	fmt.Fprintln(tw, "ID\tKOL\tOUTCOME\tATTEMPTS\tDURATION\tACCOUNT\tREGION\tERROR CLASS")
+	for _, entry := range run.Entries {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			entry.SocialProfileID, entry.UserName, entry.Outcome, entry.Attempts,
+			(time.Duration(entry.DurationMs) * time.Millisecond).Round(time.Second), entry.Account, entry.Region, entry.ErrorClass)
+	}
+	fmt.Fprintf(tw, "Total %d: %s\n", len(run.Entries), l.Counts())
+	return tw.Flush()
+}
+
+// WriteReports writes <run_id>.json and <run_id>.csv to dir and returns their paths.
+func (l *Ledger) WriteReports(dir string) (string, string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", fmt.Errorf("failed to create report directory %s: %w", dir, err)
+	}
+	run := l.Run()
+	jsonPath := filepath.Join(dir, run.RunID+".json")
+	csvPath := filepath.Join(dir, run.RunID+".csv")
+	if err := writeJSON(jsonPath, run); err != nil {
+		return "", "", err
+	}
+	if err := writeCSV(csvPath, run); err != nil {
+		return "", "", err
+	}
+	return jsonPath, csvPath, nil
+}
+
+func writeJSON(path string, run models.CrawlRun) error {
+	b, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run report: %w", err)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return fmt.Errorf("failed to write run report %s: %w", path, err)
+	}
+	return nil
+}
+
+func writeCSV(path string, run models.CrawlRun) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create run report %s: %w", path, err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"run_id", "social_profile_id", "username", "outcome", "attempts", "duration_ms", "account", "region", "error_class", "error", "started_at"})
+	for _, entry := range run.Entries {
+		w.Write([]string{
+			run.RunID,
+			strconv.Itoa(entry.SocialProfileID),
+			entry.UserName,
+			entry.Outcome,
+			strconv.Itoa(entry.Attempts),
+			strconv.FormatInt(entry.DurationMs, 10),
+			entry.Account,
+			entry.Region,
+			entry.ErrorClass,
+			entry.Error,
+			entry.StartedAt.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write run report %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,143 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tto_chromedp/pkg/models"
+)
+
+var startedAt = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func entry(id int, name, outcome string) models.CrawlRunEntry {
+	return models.CrawlRunEntry{
+		SocialProfileID: id,
+		UserName:        name,
+		Outcome:         outcome,
+		Attempts:        1,
+		DurationMs:      2500,
+		Account:         "tto",
+		Region:          "vn",
+		StartedAt:       startedAt,
+	}
+}
+
+func TestResumeMergesEarlierEntries(t *testing.T) {
+	earlier := models.CrawlRunEntries{entry(1, "alice", models.CrawlOutcomeSuccess), entry(2, "bob", models.CrawlOutcomeFailed)}
+	l := Resume("run1", "enrich", "tto", startedAt, earlier)
+	l.Record(entry(3, "carol", models.CrawlOutcomeSkipped))
+
+	run := l.Run()
+	if !run.StartedAt.Equal(startedAt) {
+		t.Fatalf("started at = %s, want the original %s", run.StartedAt, startedAt)
+	}
+	if run.Status != models.CrawlRunRunning {
+		t.Fatalf("status = %s, want running", run.Status)
+	}
+	if len(run.Entries) != 3 || run.Entries[0].UserName != "alice" || run.Entries[2].UserName != "carol" {
+		t.Fatalf("entries = %+v", run.Entries)
+	}
+
+	// The ledger keeps its own copy of the earlier entries
+	earlier[0].UserName = "changed"
+	if l.Run().Entries[0].UserName != "alice" {
+		t.Fatal("Resume shares the slice of the earlier entries")
+	}
+}
+
+func TestCounts(t *testing.T) {
+	l := New("run1", "enrich", "tto")
+	for i, outcome := range []string{models.CrawlOutcomeSuccess, models.CrawlOutcomeSuccess, models.CrawlOutcomePartial, models.CrawlOutcomeFailed, models.CrawlOutcomeAborted} {
+		l.Record(entry(i+1, "kol", outcome))
+	}
+	want := "success=2 partial=1 skipped=0 failed=1 aborted=1"
+	if got := l.Counts(); got != want {
+		t.Fatalf("Counts() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	l := New("run1", "enrich", "tto")
+	l.Record(entry(1, "alice", models.CrawlOutcomeSuccess))
+	failed := entry(22, "bob", models.CrawlOutcomeFailed)
+	failed.ErrorClass = ERROR_CLASS_PERSIST
+	l.Record(failed)
+	l.Finish(models.CrawlRunFinished)
+
+	var buf bytes.Buffer
+	if err := l.WriteTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"Run run1 (enrich, account tto): finished",
+		"ID  KOL    OUTCOME  ATTEMPTS  DURATION  ACCOUNT  REGION  ERROR CLASS",
+		"1   alice  success  1         3s        tto      vn      ",
+		"22  bob    failed   1         3s        tto      vn      persist",
+		"Total 2: success=1 partial=0 skipped=0 failed=1 aborted=0",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Fatalf("table =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteReports(t *testing.T) {
+	l := New("run1", "enrich", "tto")
+	ok := entry(1, "alice", models.CrawlOutcomeSuccess)
+	failed := entry(2, "bob, jr", models.CrawlOutcomeFailed)
+	failed.ErrorClass = "rate_limited"
+	failed.Error = `status "10009"`
+	l.Record(ok)
+	l.Record(failed)
+	l.Finish(models.CrawlRunFinished)
+
+	dir := filepath.Join(t.TempDir(), "reports")
+	jsonPath, csvPath, err := l.WriteReports(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jsonPath != filepath.Join(dir, "run1.json") || csvPath != filepath.Join(dir, "run1.csv") {
+		t.Fatalf("paths = %s, %s", jsonPath, csvPath)
+	}
+
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var run models.CrawlRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		t.Fatal(err)
+	}
+	if run.RunID != "run1" || run.Status != models.CrawlRunFinished || len(run.Entries) != 2 || run.Entries[1] != failed {
+		t.Fatalf("JSON report = %+v", run)
+	}
+
+	f, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRecords := [][]string{
+		{"run_id", "social_profile_id", "username", "outcome", "attempts", "duration_ms", "account", "region", "error_class", "error", "started_at"},
+		{"run1", "1", "alice", "success", "1", "2500", "tto", "vn", "", "", "2024-05-01T10:00:00Z"},
+		{"run1", "2", "bob, jr", "failed", "1", "2500", "tto", "vn", "rate_limited", `status "10009"`, "2024-05-01T10:00:00Z"},
+	}
+	if len(records) != len(wantRecords) {
+		t.Fatalf("CSV report has %d records, want %d: %v", len(records), len(wantRecords), records)
+	}
+	for i := range wantRecords {
+		if strings.Join(records[i], "|") != strings.Join(wantRecords[i], "|") {
+			t.Errorf("CSV record %d = %q, want %q", i, records[i], wantRecords[i])
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package logging sets up the structured (log/slog) logger of the crawler.
+//
+// Every record of a run carries run_id; the records of one creator add kol_id and kol, the browser
+// profile adds account and each step of the crawl adds stage. The logger travels in the context so
+// deep helpers (tab listeners, scrollers) log with the fields of the KOL they work for.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"tto_chromedp/pkg/utils"
+)
+
+const (
+	FORMAT_TEXT = "text"
+	FORMAT_JSON = "json"
+)
+
+// Field names shared by every log record.
+const (
+	KEY_RUN_ID  = "run_id"
+	KEY_KOL_ID  = "kol_id"
+	KEY_KOL     = "kol"
+	KEY_ACCOUNT = "account"
+	KEY_STAGE   = "stage"
+)
+
+// Config selects the handler and minimum level.
+type Config struct {
+	Format string
+	Level  slog.Level
+	Output io.Writer
+}
+
+// LoadConfigFromEnv reads TTO_LOG_FORMAT (text or json) and TTO_LOG_LEVEL (debug, info, warn, error).
+func LoadConfigFromEnv() Config {
+	cfg := Config{Format: utils.GetEnvString("TTO_LOG_FORMAT", FORMAT_TEXT), Output: os.Stderr}
+	if err := cfg.Level.UnmarshalText([]byte(utils.GetEnvString("TTO_LOG_LEVEL", "info"))); err != nil {
+		cfg.Level = slog.LevelInfo
+	}
+	return cfg
+}
+
+// Setup builds the logger of cfg, wrapped in the redaction handler, and installs it as the slog and
+// log default so the remaining log.Printf calls end up in the same stream.
+func Setup(cfg Config) (*slog.Logger, error) {
+	if cfg.Output == nil {
+		cfg.Output = os.Stderr
+	}
+	opts := &slog.HandlerOptions{Level: cfg.Level, ReplaceAttr: redactAttr}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case FORMAT_TEXT, "":
+		handler = slog.NewTextHandler(cfg.Output, opts)
+	case FORMAT_JSON:
+		handler = slog.NewJSONHandler(cfg.Output, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (expected %s or %s)", cfg.Format, FORMAT_TEXT, FORMAT_JSON)
+	}
+
+	logger := slog.New(&redactingHandler{next: handler})
+	slog.SetDefault(logger)
+	log.SetFlags(0)
+	return logger, nil
+}
+
+// NewRunID returns a short random ID identifying one run of the crawler.
+func NewRunID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102T150405")
+	}
+	return hex.EncodeToString(b)
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
+
+// With returns a copy of ctx whose logger has the extra attributes.
+func With(ctx context.Context, args ...any) context.Context {
+	return WithLogger(ctx, FromContext(ctx).With(args...))
+}
+
+// Stage returns the logger of ctx tagged with the given crawl stage.
+func Stage(ctx context.Context, stage string) *slog.Logger {
+	return FromContext(ctx).With(KEY_STAGE, stage)
+}
@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// withSecrets registers values for the duration of a test.
+func withSecrets(t *testing.T, values ...string) {
+	t.Helper()
+	secretsMu.Lock()
+	saved := secrets
+	secretsMu.Unlock()
+	t.Cleanup(func() {
+		secretsMu.Lock()
+		secrets = saved
+		secretsMu.Unlock()
+	})
+	RegisterSecret(values...)
+}
+
+func newTestLogger(t *testing.T, format string) (*slog.Logger, *bytes.Buffer) {
+	t.Helper()
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+	var buf bytes.Buffer
+	logger, err := Setup(Config{Format: format, Level: slog.LevelDebug, Output: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return logger, &buf
+}
+
+func TestSensitiveKeysAreMasked(t *testing.T) {
+	logger, buf := newTestLogger(t, FORMAT_JSON)
+	logger.Info("login",
+		"password", "hunter2",
+		"DB_Password", "hunter3",
+		"auth_token", "tok",
+		"Cookie", "sid=1",
+		"x_api_key", 12345,
+		slog.Group("proxy", "authorization", "Basic dXNlcjpwYXNz", "host", "proxy.local"),
+		KEY_ACCOUNT, "tto",
+	)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"password", "DB_Password", "auth_token", "Cookie", "x_api_key"} {
+		if record[key] != REDACTED {
+			t.Errorf("%s = %v, want %s", key, record[key], REDACTED)
+		}
+	}
+	proxy, _ := record["proxy"].(map[string]any)
+	if proxy["authorization"] != REDACTED || proxy["host"] != "proxy.local" {
+		t.Errorf("proxy group = %v", proxy)
+	}
+	if record[KEY_ACCOUNT] != "tto" {
+		t.Errorf("%s = %v, want it untouched", KEY_ACCOUNT, record[KEY_ACCOUNT])
+	}
+}
+
+func TestRegisteredSecretsAreScrubbed(t *testing.T) {
+	const password = "s3cr3t-pass"
+	const dsn = "postgres://crawler:s3cr3t-pass@db:5432/tto"
+	withSecrets(t, password, "  ", "abc")
+	logger, buf := newTestLogger(t, FORMAT_TEXT)
+
+	logger = logger.With("dsn", dsn)
+	logger.Error("failed to log in with "+password,
+		"detail", "password was "+password,
+		"error", fmt.Errorf("failed to connect to %s: %w", dsn, errors.New("timeout")),
+		"note", "abc is too short to be a secret",
+	)
+
+	out := buf.String()
+	if strings.Contains(out, password) {
+		t.Fatalf("secret logged:\n%s", out)
+	}
+	for _, want := range []string{
+		`msg="failed to log in with [REDACTED]"`,
+		`dsn=postgres://crawler:[REDACTED]@db:5432/tto`,
+		`detail="password was [REDACTED]"`,
+		`error="failed to connect to postgres://crawler:[REDACTED]@db:5432/tto: timeout"`,
+		`note="abc is too short to be a secret"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in:\n%s", want, out)
+		}
+	}
+}
+
+func TestRedactingHandlerKeepsContextLogger(t *testing.T) {
+	withSecrets(t, "hunter2")
+	logger, buf := newTestLogger(t, FORMAT_TEXT)
+	ctx := WithLogger(context.Background(), logger.With(KEY_RUN_ID, "run1"))
+
+	Stage(ctx, "login").WithGroup("browser").Warn("typed hunter2", "field", "hunter2")
+
+	out := buf.String()
+	if strings.Contains(out, "hunter2") {
+		t.Fatalf("secret logged:\n%s", out)
+	}
+	for _, want := range []string{"run_id=run1", "stage=login", "browser.field=[REDACTED]", `msg="typed [REDACTED]"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in:\n%s", want, out)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	withSecrets(t, "key-1234", "key-5678")
+	if got := Redact("keys key-1234 and key-5678"); got != "keys [REDACTED] and [REDACTED]" {
+		t.Fatalf("Redact() = %q", got)
+	}
+}
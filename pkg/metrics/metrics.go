@@ -0,0 +1,137 @@
+// Package metrics exposes the crawler health to Prometheus: KOL outcomes, stage latencies, captured
+// responses, open tabs, account cool-downs and database errors, plus a /healthz probe that fails when
+// a running crawl stops making progress.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Crawl stages timed by StageDuration.
+const (
+	STAGE_NAVIGATE = "navigate"
+	STAGE_SEARCH   = "search"
+	STAGE_DETAIL   = "detail"
+	STAGE_PARSE    = "parse"
+	STAGE_PERSIST  = "persist"
+)
+
+// Databases counted by DBErrors.
+const (
+	DB_POSTGRES = "postgres"
+	DB_MONGO    = "mongo"
+)
+
+// Results counted by SourceMessage.
+const (
+	SOURCE_ACKED         = "acked"
+	SOURCE_RETRIED       = "retried"
+	SOURCE_DEAD_LETTERED = "dead_lettered"
+)
+
+// Registry holds the crawler metrics and the Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	kolsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tto_kols_processed_total",
+		Help: "KOLs processed, by outcome class.",
+	}, []string{"outcome"})
+
+	stageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tto_stage_duration_seconds",
+		Help:    "Duration of each crawl stage.",
+		Buckets: []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 240},
+	}, []string{"stage"})
+
+	responses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tto_responses_total",
+		Help: "Captured creator card responses, by BaseResp status code (http_429 for throttled requests).",
+	}, []string{"status"})
+
+	activeTabs = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tto_active_tabs",
+		Help: "Browser tabs currently open.",
+	})
+
+	coolDowns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tto_account_cool_downs_total",
+		Help: "Cool-downs imposed by the rate limiter, by account (or _global).",
+	}, []string{"account"})
+
+	coolDownUntil = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tto_account_cool_down_until_timestamp_seconds",
+		Help: "Unix time at which the current cool-down of the account ends.",
+	}, []string{"account"})
+
+	dbErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tto_db_errors_total",
+		Help: "Database errors, by database and operation.",
+	}, []string{"db", "operation"})
+
+	sourceMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tto_source_messages_total",
+		Help: "Job messages handled by the consume mode, by source and result (acked, retried, dead_lettered).",
+	}, []string{"source", "result"})
+
+	lastProgress = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tto_last_progress_timestamp_seconds",
+		Help: "Unix time at which the last KOL finished processing.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		kolsProcessed, stageDuration, responses, activeTabs, coolDowns, coolDownUntil, dbErrors, sourceMessages, lastProgress,
+	)
+}
+
+// KOLProcessed counts one KOL with its outcome class and records the progress for /healthz.
+func KOLProcessed(outcome string) {
+	kolsProcessed.WithLabelValues(outcome).Inc()
+	markProgress()
+}
+
+// StageTimer starts timing a stage; call the returned func when the stage ends.
+func StageTimer(stage string) func() {
+	start := time.Now()
+	return func() {
+		stageDuration.WithLabelValues(stage).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ResponseCaptured counts a creator card response by its BaseResp status code.
+func ResponseCaptured(statusCode int) {
+	responses.WithLabelValues(strconv.Itoa(statusCode)).Inc()
+}
+
+// ResponseThrottled counts a creator card request answered with HTTP 429.
+func ResponseThrottled() {
+	responses.WithLabelValues("http_429").Inc()
+}
+
+// TabOpened and TabClosed track the open browser tabs.
+func TabOpened() { activeTabs.Inc() }
+func TabClosed() { activeTabs.Dec() }
+
+// AccountCoolDown records a cool-down of account until the given time.
+func AccountCoolDown(account string, until time.Time) {
+	coolDowns.WithLabelValues(account).Inc()
+	coolDownUntil.WithLabelValues(account).Set(float64(until.Unix()))
+}
+
+// DBError counts a failed database operation.
+func DBError(db, operation string) {
+	dbErrors.WithLabelValues(db, operation).Inc()
+}
+
+// SourceMessage counts a job message handled by the consume mode.
+func SourceMessage(source, result string) {
+	sourceMessages.WithLabelValues(source, result).Inc()
+}
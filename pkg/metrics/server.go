@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DEFAULT_STALL_AFTER is how long a running crawl may go without finishing a KOL before /healthz fails.
+const DEFAULT_STALL_AFTER = 30 * time.Minute
+
+var health = struct {
+	sync.Mutex
+	running      bool
+	lastProgress time.Time
+	stallAfter   time.Duration
+}{stallAfter: DEFAULT_STALL_AFTER}
+
+// SetStallAfter changes the progress timeout of /healthz.
+func SetStallAfter(d time.Duration) {
+	health.Lock()
+	defer health.Unlock()
+	if d > 0 {
+		health.stallAfter = d
+	}
+}
+
+// RunStarted and RunFinished bracket a crawl; /healthz only checks progress in between.
+func RunStarted() {
+	health.Lock()
+	defer health.Unlock()
+	health.running = true
+	health.lastProgress = time.Now()
+	lastProgress.SetToCurrentTime()
+}
+
+func RunFinished() {
+	health.Lock()
+	defer health.Unlock()
+	health.running = false
+}
+
+func markProgress() {
+	health.Lock()
+	defer health.Unlock()
+	health.lastProgress = time.Now()
+	lastProgress.SetToCurrentTime()
+}
+
+// healthz answers 200 unless a crawl is running and has not finished a KOL within the stall timeout.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	health.Lock()
+	running, since, stallAfter := health.running, time.Since(health.lastProgress), health.stallAfter
+	health.Unlock()
+
+	if running && since > stallAfter {
+		http.Error(w, fmt.Sprintf("stalled: no KOL finished for %s", since.Round(time.Second)), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// NewMux returns the handler serving /metrics and /healthz.
+func NewMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", healthz)
+	return mux
+}
+
+// Serve starts the metrics endpoint on addr in the background. The server stops when ctx is done.
+// The error is set when addr cannot be bound; a later failure of the server is sent on the channel,
+// which is closed once the server stopped.
+func Serve(ctx context.Context, addr string) (<-chan error, error) {
+	return ServeHandler(ctx, addr, NewMux())
+}
+
+// ServeHandler is Serve with a handler that extends NewMux, e.g. with the serve mode endpoints.
+func ServeHandler(ctx context.Context, addr string, handler http.Handler) (<-chan error, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		slog.Info("Serving metrics", "addr", listener.Addr().String())
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Metrics endpoint stopped", "addr", addr, "error", err)
+			errs <- fmt.Errorf("server on %s stopped: %w", addr, err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	return errs, nil
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestServeHandlerFailsWhenAddrIsTaken(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	if _, err := ServeHandler(context.Background(), taken.Addr().String(), http.NotFoundHandler()); err == nil {
+		t.Fatal("ServeHandler on a bound address returned no error")
+	}
+}
+
+func TestServeHandlerClosesErrorsOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errs, err := ServeHandler(ctx, "127.0.0.1:0", NewMux())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err, ok := <-errs; ok {
+		t.Fatalf("a shutdown reported %v, want the channel closed", err)
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// Status of a crawl run in crawler.crawl_runs.
+const (
+	CrawlRunRunning  = "running"
+	CrawlRunFinished = "finished"
+	CrawlRunAborted  = "aborted"
+)
+
+// Outcome of one KOL in a crawl run.
+const (
+	CrawlOutcomeSuccess = "success"
+	CrawlOutcomePartial = "partial"
+	CrawlOutcomeSkipped = "skipped"
+	CrawlOutcomeFailed  = "failed"
+	CrawlOutcomeAborted = "aborted"
+)
+
+// CrawlOutcomes lists every outcome in a stable order, used when printing summaries.
+var CrawlOutcomes = []string{
+	CrawlOutcomeSuccess,
+	CrawlOutcomePartial,
+	CrawlOutcomeSkipped,
+	CrawlOutcomeFailed,
+	CrawlOutcomeAborted,
+}
+
+// CrawlRun is the ledger of one run of the crawler, stored in crawler.crawl_runs.
+type CrawlRun struct {
+	RunID      string          `json:"run_id"`
+	Mode       string          `json:"mode"`
+	Account    string          `json:"account"`
+	Status     string          `json:"status"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+	Entries    CrawlRunEntries `json:"entries"`
+}
+
+// Count returns the number of entries with the given outcome.
+func (r *CrawlRun) Count(outcome string) int {
+	n := 0
+	for _, entry := range r.Entries {
+		if entry.Outcome == outcome {
+			n++
+		}
+	}
+	return n
+}
+
+// CrawlRunEntry is the outcome of one KOL in a crawl run. ErrorClass is an apierror class, or
+// ledger.ERROR_CLASS_PERSIST when the data was crawled but could not be stored.
+type CrawlRunEntry struct {
+	SocialProfileID int       `json:"social_profile_id"`
+	UserName        string    `json:"username"`
+	Outcome         string    `json:"outcome"`
+	Attempts        int       `json:"attempts"`
+	DurationMs      int64     `json:"duration_ms"`
+	Account         string    `json:"account"`
+	Region          string    `json:"region,omitempty"`
+	ErrorClass      string    `json:"error_class,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+}
+
+type CrawlRunEntries []CrawlRunEntry
+
+func (e CrawlRunEntries) Value() (driver.Value, error) {
+	if e == nil {
+		return "[]", nil
+	}
+	return marshalJSONB(e)
+}
+
+func (e *CrawlRunEntries) Scan(src interface{}) error {
+	return scanJSONB(src, e)
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// CreatorHealth is the account state of a creator compared between crawls, stored in
+// crawler.social_profiles.tiktokshop_health.
+type CreatorHealth struct {
+	FollowerCount int  `json:"follower_count"`
+	CreditScore   int  `json:"credit_score"`
+	CreditTier    int  `json:"credit_tier"`
+	IsBannedInTT  bool `json:"is_banned_in_tt"`
+}
+
+func (h *CreatorHealth) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	return marshalJSONB(h)
+}
+
+func (h *CreatorHealth) Scan(src interface{}) error {
+	return scanJSONB(src, h)
+}
+
+// CreatorEvent is a sharp change between the stored and the newly crawled data of a creator,
+// recorded in crawler.tto_creator_events.
+type CreatorEvent struct {
+	SocialProfileID int         `json:"social_profile_id"`
+	UserName        string      `json:"username"`
+	Type            string      `json:"type"`
+	Severity        string      `json:"severity"`
+	Message         string      `json:"message"`
+	Previous        interface{} `json:"previous"`
+	Current         interface{} `json:"current"`
+	DetectedAt      time.Time   `json:"detected_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// CreatorKPI are the metrics derived from the captured videos of a creator, stored in
+// crawler.social_profiles.tiktokshop_kpis for campaign matching.
+type CreatorKPI struct {
+	VideoCount     int     `json:"video_count"`
+	AvgViews       float64 `json:"avg_views"`
+	MedianViews    float64 `json:"median_views"`
+	EngagementRate float64 `json:"engagement_rate"`
+	// PostsPerWeek is measured between the oldest and newest captured recent video.
+	PostsPerWeek   float64 `json:"posts_per_week"`
+	SponsoredRatio float64 `json:"sponsored_ratio"`
+	BoostedRatio   float64 `json:"boosted_ratio"`
+
+	Benchmark  KPIBenchmark `json:"benchmark"`
+	ComputedAt time.Time    `json:"computed_at"`
+}
+
+// KPIBenchmark compares the creator with the OverallPerformance benchmarks of similar creators.
+// The *Index fields are creator value / benchmark value (1 means on par, 0 means no benchmark).
+type KPIBenchmark struct {
+	EngagementRate      float64 `json:"engagement_rate"`
+	EngagementRateIndex float64 `json:"engagement_rate_index"`
+	EngagementRateRank  float64 `json:"engagement_rate_rank"`
+	MedianViews         int     `json:"median_views"`
+	MedianViewsIndex    float64 `json:"median_views_index"`
+	MedianViewsRank     float64 `json:"median_views_rank"`
+	FollowersGrowthRank float64 `json:"followers_growth_rank"`
+	VideoCompleteRank   float64 `json:"video_complete_rank"`
+}
+
+func (k *CreatorKPI) Value() (driver.Value, error) {
+	if k == nil {
+		return nil, nil
+	}
+	return marshalJSONB(k)
+}
+
+func (k *CreatorKPI) Scan(src interface{}) error {
+	return scanJSONB(src, k)
+}
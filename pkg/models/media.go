@@ -0,0 +1,29 @@
+package models
+
+import "database/sql/driver"
+
+// MediaAssets are the stable URLs of the images of a creator, copied from the signed TTO URLs.
+type MediaAssets struct {
+	AvatarURL string
+	Covers    VideoCovers
+}
+
+// VideoCover is the stored cover image of one video.
+type VideoCover struct {
+	ItemID string `json:"item_id"`
+	URL    string `json:"url"`
+}
+
+// VideoCovers is stored as a JSONB array.
+type VideoCovers []VideoCover
+
+func (v VideoCovers) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return marshalJSONB([]VideoCover(v))
+}
+
+func (v *VideoCovers) Scan(src interface{}) error {
+	return scanJSONB(src, v)
+}
@@ -3,4 +3,26 @@ package models
 type SocialProfile struct {
 	ID       int    `json:"id"`
 	UserName string `json:"username"`
+	// Region is the explore page region the profile was last crawled with (empty if never crawled).
+	Region string `json:"region"`
+}
+
+// DiscoveredCreator is a creator found through the explore page filters that may not exist in
+// crawler.social_profiles yet.
+type DiscoveredCreator struct {
+	TtUID      string `json:"tt_uid"`
+	HandleName string `json:"handle_name"`
+	Region     string `json:"region"`
+}
+
+// CrawlSelection is the spec of the profiles picked for an enrich run. ProfileID restricts the run to a
+// single profile when it is not 0. RefreshAfterDays, when not 0, also picks the done profiles whose
+// tiktokshop_updated_at is older than that many days. Input is set instead when the run crawls a
+// handle list file, without a database.
+type CrawlSelection struct {
+	CreatorStatus    int    `json:"creator_status"`
+	ProfileID        int    `json:"profile_id,omitempty"`
+	RefreshAfterDays int    `json:"refresh_after_days,omitempty"`
+	Limit            int    `json:"limit"`
+	Input            string `json:"input,omitempty"`
 }
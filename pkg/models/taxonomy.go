@@ -0,0 +1,43 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// Status values of crawler.tto_label_review_queue.
+const (
+	LabelReviewPending  = "pending"
+	LabelReviewApproved = "approved"
+)
+
+// UnmappedLabel is a TTO label waiting in the review queue for a CMS mapping.
+type UnmappedLabel struct {
+	LabelID     string    `json:"label_id"`
+	LabelName   string    `json:"label_name"`
+	Kind        string    `json:"kind"`
+	SeenCount   int       `json:"seen_count"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+// BrandMention is a brand found in the sponsored videos of a creator.
+type BrandMention struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Videos int    `json:"videos"`
+}
+
+// BrandMentions is stored as a JSONB array.
+type BrandMentions []BrandMention
+
+func (b BrandMentions) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	return marshalJSONB([]BrandMention(b))
+}
+
+func (b *BrandMentions) Scan(src interface{}) error {
+	return scanJSONB(src, b)
+}
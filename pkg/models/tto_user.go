@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TTOUser is the TTO data parsed for one creator, as stored on crawler.social_profiles.
+type TTOUser struct {
+	CategoryContent ContentInterests `json:"content_interest"`
+	AgeDistri       AudienceShares   `json:"audience_age"`
+	RegionDistri    AudienceShares   `json:"audience_location"`
+	GenderDistri    AudienceShares   `json:"audience_gender"`
+	KolGrowth       KolGrowth        `json:"kol_growth"`
+	Brands          BrandMentions    `json:"tiktokshop_brands,omitempty"`
+	KPIs            *CreatorKPI      `json:"tiktokshop_kpis,omitempty"`
+	AvatarURL       string           `json:"tiktokshop_avatar_url,omitempty"`
+	VideoCovers     VideoCovers      `json:"tiktokshop_video_covers,omitempty"`
+	Health          *CreatorHealth   `json:"tiktokshop_health,omitempty"`
+
+	Region        string    `json:"tiktokshop_region,omitempty"`
+	UpdatedAt     time.Time `json:"tiktokshop_updated_at"`
+	CreatorStatus int       `json:"tiktokshop_creator_status"`
+}
+
+// AudienceShare is one bucket of an audience distribution (age interval, gender or country).
+type AudienceShare struct {
+	Name    string  `json:"name"`
+	ISOCode string  `json:"iso_code,omitempty"`
+	Value   float64 `json:"value"`
+}
+
+// ContentInterest is one content category of a creator with its share of the content.
+type ContentInterest struct {
+	ID      int     `json:"id"`
+	Name    string  `json:"name"`
+	LabelID string  `json:"label_id"`
+	Percent float64 `json:"percent"`
+}
+
+// GrowthPoint is the follower count and video views of a creator in one bucket. Time is the unix
+// start of the bucket, Videos the views of the videos posted in it (summed or averaged).
+type GrowthPoint struct {
+	Time         int64         `json:"time"`
+	Followers    int           `json:"followers"`
+	Videos       int           `json:"videos"`
+	VideoCount   int           `json:"video_count,omitempty"`
+	VideoDetails []VideoDetail `json:"video_details,omitempty"`
+}
+
+// VideoDetail is one video counted in a GrowthPoint.
+type VideoDetail struct {
+	ItemID string `json:"item_id"`
+	Time   int64  `json:"time"`
+	Views  int64  `json:"views"`
+}
+
+// AudienceShares is stored as a JSONB array.
+type AudienceShares []AudienceShare
+
+// ContentInterests is stored as a JSONB array.
+type ContentInterests []ContentInterest
+
+// KolGrowth is stored as a JSONB object of the form {"detail": [...]}.
+type KolGrowth struct {
+	Granularity string        `json:"granularity,omitempty"`
+	Detail      []GrowthPoint `json:"detail"`
+}
+
+func (a AudienceShares) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return marshalJSONB([]AudienceShare(a))
+}
+
+func (a *AudienceShares) Scan(src interface{}) error {
+	return scanJSONB(src, a)
+}
+
+func (c ContentInterests) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return marshalJSONB([]ContentInterest(c))
+}
+
+func (c *ContentInterests) Scan(src interface{}) error {
+	return scanJSONB(src, c)
+}
+
+func (k KolGrowth) Value() (driver.Value, error) {
+	if k.Detail == nil {
+		return nil, nil
+	}
+	return marshalJSONB(k)
+}
+
+func (k *KolGrowth) Scan(src interface{}) error {
+	return scanJSONB(src, k)
+}
+
+// marshalJSONB encodes v as a JSON string. A string (not []byte) is returned because lib/pq sends
+// []byte parameters as bytea, which PostgreSQL refuses to cast to jsonb.
+func marshalJSONB(v interface{}) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// scanJSONB decodes a JSONB column into dest. A NULL column leaves dest untouched.
+func scanJSONB(src interface{}, dest interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, dest)
+	}
+}
@@ -3,9 +3,13 @@ package mongodb
 import (
 	"context"
 	"fmt"
-	"log"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/metrics"
 	"tto_chromedp/pkg/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,14 +17,45 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	DEFAULT_COUNTRY_DATABASE  = "adserver"
+	DEFAULT_COUNTRY_CACHE_TTL = time.Hour
+)
+
+// unknownCountryCodes are the placeholder codes TTO uses for audiences it cannot place.
+var unknownCountryCodes = map[string]bool{
+	"":        true,
+	"OTHERS":  true,
+	"OTHER":   true,
+	"UNKNOWN": true,
+	"ZZ":      true,
+}
+
 type CountryDetailRepository interface {
-	// Implementation details would go here
+	// GetCountryCodes returns the ISO code to country name map, from the cache while it is fresh.
 	GetCountryCodes(ctx context.Context) (map[string]string, error)
+	// ResolveCountry returns the country name of an ISO code. Placeholder codes resolve to
+	// utils.COUNTRY_UNKNOWN; codes found nowhere also do, and are recorded as unresolved.
+	ResolveCountry(ctx context.Context, isoCode string) string
+	// UnresolvedCodes lists the codes ResolveCountry could not find since the repository was created.
+	UnresolvedCodes() []string
 }
 
 type countryRepository struct {
 	client     *mongo.Client
+	Database   string
 	Collection string
+	TTL        time.Duration
+
+	// load reads the collection; now is the clock of the cache. Tests replace both.
+	load func(ctx context.Context) (map[string]string, error)
+	now  func() time.Time
+
+	mu         sync.Mutex
+	codes      map[string]string
+	loadedAt   time.Time
+	loading    bool
+	unresolved map[string]bool
 }
 
 // Document structure matching your MongoDB data
@@ -29,16 +64,108 @@ type CountryData struct {
 	OfficialCountry string `bson:"official_country"`
 }
 
-func NewCountryDetailRepository(db *mongo.Client, collecttion string) CountryDetailRepository {
-	return &countryRepository{
+// NewCountryDetailRepository reads the country collection of the given database. An empty database
+// falls back to DEFAULT_COUNTRY_DATABASE and a zero ttl to DEFAULT_COUNTRY_CACHE_TTL. With a nil
+// client (runs without a database) only the bundled ISO 3166 table is used.
+func NewCountryDetailRepository(db *mongo.Client, database string, collecttion string, ttl time.Duration) CountryDetailRepository {
+	if database == "" {
+		database = DEFAULT_COUNTRY_DATABASE
+	}
+	if ttl <= 0 {
+		ttl = DEFAULT_COUNTRY_CACHE_TTL
+	}
+	cdr := &countryRepository{
 		client:     db,
+		Database:   database,
 		Collection: collecttion,
+		TTL:        ttl,
+		now:        time.Now,
+		unresolved: make(map[string]bool),
+	}
+	if db != nil {
+		cdr.load = cdr.loadCountryCodes
 	}
+	return cdr
 }
 
 func (cdr *countryRepository) GetCountryCodes(ctx context.Context) (map[string]string, error) {
+	current, err := cdr.refresh(ctx)
+	codes := make(map[string]string, len(current))
+	for code, name := range current {
+		codes[code] = name
+	}
+	return codes, err
+}
 
-	collection := cdr.client.Database("adserver").Collection(cdr.Collection)
+func (cdr *countryRepository) ResolveCountry(ctx context.Context, isoCode string) string {
+	code := strings.ToUpper(strings.TrimSpace(isoCode))
+	if unknownCountryCodes[code] {
+		return utils.COUNTRY_UNKNOWN
+	}
+
+	codes, err := cdr.refresh(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to refresh country codes, using the cached or bundled table", "error", err)
+	}
+	if name, ok := codes[code]; ok && name != utils.COUNTRY_UNKNOWN {
+		return name
+	}
+	if name, ok := isoCountryNames[code]; ok {
+		return name
+	}
+	cdr.mu.Lock()
+	cdr.unresolved[code] = true
+	cdr.mu.Unlock()
+	return utils.COUNTRY_UNKNOWN
+}
+
+func (cdr *countryRepository) UnresolvedCodes() []string {
+	cdr.mu.Lock()
+	defer cdr.mu.Unlock()
+
+	codes := make([]string, 0, len(cdr.unresolved))
+	for code := range cdr.unresolved {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// refresh returns the cached codes, reloading the collection first when the cache is empty or older
+// than the TTL. The query runs outside the lock and only one caller runs it; the others meanwhile get
+// the current cache. A failed load is cached for one TTL too: the previous codes are kept, or the
+// bundled table is used when there were none, so an unreachable Mongo is not queried on every call.
+// The error is only returned to the caller that ran the load. The returned map must not be modified.
+func (cdr *countryRepository) refresh(ctx context.Context) (map[string]string, error) {
+	cdr.mu.Lock()
+	if cdr.load == nil || cdr.loading || (cdr.codes != nil && cdr.now().Sub(cdr.loadedAt) < cdr.TTL) {
+		codes := cdr.codes
+		cdr.mu.Unlock()
+		return codes, nil
+	}
+	cdr.loading = true
+	cdr.mu.Unlock()
+
+	codes, err := cdr.load(ctx)
+
+	cdr.mu.Lock()
+	defer cdr.mu.Unlock()
+	cdr.loading = false
+	cdr.loadedAt = cdr.now()
+	if err != nil {
+		metrics.DBError(metrics.DB_MONGO, "load_country_codes")
+		if cdr.codes == nil {
+			cdr.codes = isoCountryNames
+		}
+		return cdr.codes, err
+	}
+	cdr.codes = codes
+	return cdr.codes, nil
+}
+
+func (cdr *countryRepository) loadCountryCodes(ctx context.Context) (map[string]string, error) {
+
+	collection := cdr.client.Database(cdr.Database).Collection(cdr.Collection)
 
 	options := options.Find().SetProjection(bson.M{
 		"iso_code_2":       1,
@@ -57,7 +184,7 @@ func (cdr *countryRepository) GetCountryCodes(ctx context.Context) (map[string]s
 		var result CountryData
 		if err := findCursor.Decode(&result); err != nil {
 			// Log the error but continue processing other documents
-			log.Printf("Warning: Failed to decode country data document: %v", err)
+			logging.FromContext(ctx).Warn("Failed to decode country data document", "error", err)
 			continue // Or return nil, fmt.Errorf(...) if you want to fail the whole operation
 		}
 
@@ -77,14 +204,3 @@ func (cdr *countryRepository) GetCountryCodes(ctx context.Context) (map[string]s
 
 	return countryCodes, nil
 }
-
-func processField(field interface{}) (bool, string) {
-	fmt.Println(field)
-	// The 'type' keyword inside the switch statement makes it a type switch.
-	switch field.(type) {
-	case string:
-		return true, field.(string)
-	default:
-		return false, ""
-	}
-}
@@ -0,0 +1,173 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"tto_chromedp/pkg/utils"
+)
+
+// fakeLoader counts the loads of the country collection and answers with codes or err.
+type fakeLoader struct {
+	mu    sync.Mutex
+	calls int
+	codes map[string]string
+	err   error
+}
+
+func (f *fakeLoader) load(ctx context.Context) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.codes, nil
+}
+
+func (f *fakeLoader) set(codes map[string]string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.codes, f.err = codes, err
+}
+
+func newTestRepository(loader *fakeLoader) (*countryRepository, *time.Time) {
+	cdr := NewCountryDetailRepository(nil, "", "countries", time.Hour).(*countryRepository)
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cdr.now = func() time.Time { return clock }
+	cdr.load = loader.load
+	return cdr, &clock
+}
+
+func TestResolveCountryCachesCollection(t *testing.T) {
+	loader := &fakeLoader{codes: map[string]string{"VN": "Socialist Republic of Viet Nam", "XX": utils.COUNTRY_UNKNOWN}}
+	cdr, clock := newTestRepository(loader)
+	ctx := context.Background()
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"vn", "Socialist Republic of Viet Nam"}, // the collection wins over the bundled table
+		{" US ", "United States"},                // missing from the collection: bundled table
+		{"XX", utils.COUNTRY_UNKNOWN},            // unknown in the collection and in the bundled table
+		{"others", utils.COUNTRY_UNKNOWN},        // placeholder, never recorded
+		{"", utils.COUNTRY_UNKNOWN},
+	}
+	for _, tt := range tests {
+		if got := cdr.ResolveCountry(ctx, tt.code); got != tt.want {
+			t.Errorf("ResolveCountry(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+	if loader.calls != 1 {
+		t.Fatalf("loaded %d times within the TTL, want 1", loader.calls)
+	}
+	if got := cdr.UnresolvedCodes(); !reflect.DeepEqual(got, []string{"XX"}) {
+		t.Fatalf("unresolved = %v, want [XX]", got)
+	}
+
+	*clock = clock.Add(time.Hour)
+	cdr.ResolveCountry(ctx, "VN")
+	if loader.calls != 2 {
+		t.Fatalf("loaded %d times after the TTL, want 2", loader.calls)
+	}
+}
+
+func TestResolveCountryCachesFailure(t *testing.T) {
+	loader := &fakeLoader{err: errors.New("server selection timeout")}
+	cdr, clock := newTestRepository(loader)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if got := cdr.ResolveCountry(ctx, "VN"); got != isoCountryNames["VN"] {
+			t.Fatalf("ResolveCountry(VN) = %q, want the bundled name", got)
+		}
+	}
+	if loader.calls != 1 {
+		t.Fatalf("unreachable Mongo queried %d times within the TTL, want 1", loader.calls)
+	}
+
+	// Once the TTL is over the collection is tried again, and used when it answers
+	*clock = clock.Add(time.Hour)
+	loader.set(map[string]string{"VN": "Viet Nam"}, nil)
+	if got := cdr.ResolveCountry(ctx, "VN"); got != "Viet Nam" {
+		t.Fatalf("ResolveCountry(VN) after recovery = %q", got)
+	}
+
+	// A later failure keeps the codes already loaded
+	*clock = clock.Add(time.Hour)
+	loader.set(nil, errors.New("down again"))
+	if got := cdr.ResolveCountry(ctx, "VN"); got != "Viet Nam" {
+		t.Fatalf("ResolveCountry(VN) after a failed refresh = %q, want the cached name", got)
+	}
+	if loader.calls != 3 {
+		t.Fatalf("loaded %d times, want 3", loader.calls)
+	}
+}
+
+func TestGetCountryCodesReturnsLoadError(t *testing.T) {
+	loader := &fakeLoader{err: errors.New("down")}
+	cdr, _ := newTestRepository(loader)
+	codes, err := cdr.GetCountryCodes(context.Background())
+	if err == nil {
+		t.Fatal("GetCountryCodes hid the load error")
+	}
+	if codes["VN"] != isoCountryNames["VN"] {
+		t.Fatalf("codes after a failed load = %d entries, want the bundled table", len(codes))
+	}
+	// The copy belongs to the caller
+	codes["VN"] = "changed"
+	if isoCountryNames["VN"] == "changed" {
+		t.Fatal("GetCountryCodes returned the bundled table itself")
+	}
+}
+
+func TestResolveCountryDoesNotWaitForALoad(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	cdr, _ := newTestRepository(&fakeLoader{})
+	cdr.load = func(ctx context.Context) (map[string]string, error) {
+		close(started)
+		<-release
+		return map[string]string{"VN": "Viet Nam"}, nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		cdr.ResolveCountry(context.Background(), "VN")
+		close(done)
+	}()
+	<-started
+
+	// Meanwhile the other callers answer from the bundled table instead of queueing behind the query
+	resolved := make(chan string)
+	go func() { resolved <- cdr.ResolveCountry(context.Background(), "TH") }()
+	select {
+	case got := <-resolved:
+		if got != isoCountryNames["TH"] {
+			t.Fatalf("ResolveCountry(TH) = %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ResolveCountry waited for the load in progress")
+	}
+
+	close(release)
+	<-done
+	if got := cdr.ResolveCountry(context.Background(), "VN"); got != "Viet Nam" {
+		t.Fatalf("ResolveCountry(VN) after the load = %q", got)
+	}
+}
+
+func TestResolveCountryWithoutClient(t *testing.T) {
+	cdr := NewCountryDetailRepository(nil, "", "", 0)
+	if got := cdr.ResolveCountry(context.Background(), "VN"); got != isoCountryNames["VN"] {
+		t.Fatalf("ResolveCountry(VN) = %q, want the bundled name", got)
+	}
+	if codes, err := cdr.GetCountryCodes(context.Background()); err != nil || len(codes) != 0 {
+		t.Fatalf("GetCountryCodes() = %d codes, %v", len(codes), err)
+	}
+}
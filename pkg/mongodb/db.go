@@ -3,7 +3,7 @@ package mongodb
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/url"
 	"time"
 
@@ -28,7 +28,7 @@ func ConnectMongoDB(uri string) (*mongo.Client, error) {
 	// If authSource is not specified, explicitly set it to "admin".
 	if u.Query().Get("authSource") == "" {
 		uri += "?authSource=admin"
-		log.Println("`authSource` not specified, appending `?authSource=admin` to the URI.")
+		slog.Info("`authSource` not specified, appending `?authSource=admin` to the URI")
 	}
 
 	// Set client options
@@ -0,0 +1,60 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"tto_chromedp/pkg/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DEFAULT_TTO_USER_DATABASE   = "crawler"
+	DEFAULT_TTO_USER_COLLECTION = "tto_creators"
+)
+
+// TTOUserRepository stores the parsed TTO data of creators, one document per social profile.
+type TTOUserRepository interface {
+	// UpsertTTOUser replaces the document of the profile with the new data.
+	UpsertTTOUser(ctx context.Context, profile models.SocialProfile, user *models.TTOUser) error
+}
+
+type ttoUserRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTTOUserRepository(client *mongo.Client, database, collection string) TTOUserRepository {
+	return &ttoUserRepository{collection: client.Database(database).Collection(collection)}
+}
+
+// UpsertTTOUser keeps the JSON field names of TTOUser under "tto_user", so the documents read the
+// same as the JSONB columns of crawler.social_profiles.
+func (r *ttoUserRepository) UpsertTTOUser(ctx context.Context, profile models.SocialProfile, user *models.TTOUser) error {
+	b, err := json.Marshal(user)
+	if err != nil {
+		return fmt.Errorf("failed to encode TTO data of profile %d: %w", profile.ID, err)
+	}
+	var data bson.M
+	if err := bson.UnmarshalExtJSON(b, false, &data); err != nil {
+		return fmt.Errorf("failed to convert TTO data of profile %d: %w", profile.ID, err)
+	}
+
+	doc := bson.M{
+		"social_profile_id": profile.ID,
+		"username":          profile.UserName,
+		"region":            user.Region,
+		"updated_at":        user.UpdatedAt,
+		"stored_at":         time.Now(),
+		"tto_user":          data,
+	}
+	_, err = r.collection.ReplaceOne(ctx, bson.M{"social_profile_id": profile.ID}, doc, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to upsert TTO data of profile %d: %w", profile.ID, err)
+	}
+	return nil
+}
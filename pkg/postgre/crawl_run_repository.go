@@ -0,0 +1,60 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"tto_chromedp/pkg/models"
+)
+
+type CrawlRunRepository interface {
+	StartCrawlRun(ctx context.Context, run models.CrawlRun) error
+	FinishCrawlRun(ctx context.Context, run models.CrawlRun) error
+}
+
+type crawlRunRepository struct {
+	db *sql.DB
+}
+
+func NewCrawlRunRepository(db *sql.DB) CrawlRunRepository {
+	return &crawlRunRepository{db: db}
+}
+
+// StartCrawlRun inserts the row of a run as soon as it starts, so a run that dies without
+// reaching FinishCrawlRun still shows up as running. A resumed run flips its row back to running.
+func (r *crawlRunRepository) StartCrawlRun(ctx context.Context, run models.CrawlRun) error {
+	query := `
+		INSERT INTO crawler.crawl_runs (run_id, mode, account, status, started_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (run_id) DO UPDATE SET status = EXCLUDED.status, finished_at = NULL;`
+	if _, err := r.db.ExecContext(ctx, query, run.RunID, run.Mode, run.Account, run.Status, run.StartedAt); err != nil {
+		return fmt.Errorf("failed to insert crawl run %s: %w", run.RunID, err)
+	}
+	return nil
+}
+
+// FinishCrawlRun stores the final status, the outcome counts and the entries of a run.
+func (r *crawlRunRepository) FinishCrawlRun(ctx context.Context, run models.CrawlRun) error {
+	query := `
+		UPDATE crawler.crawl_runs SET
+			status = $2,
+			finished_at = $3,
+			total = $4,
+			succeeded = $5,
+			partial = $6,
+			skipped = $7,
+			failed = $8,
+			aborted = $9,
+			entries = $10
+		WHERE run_id = $1;`
+	if _, err := r.db.ExecContext(ctx, query,
+		run.RunID, run.Status, run.FinishedAt, len(run.Entries),
+		run.Count(models.CrawlOutcomeSuccess), run.Count(models.CrawlOutcomePartial), run.Count(models.CrawlOutcomeSkipped),
+		run.Count(models.CrawlOutcomeFailed), run.Count(models.CrawlOutcomeAborted),
+		run.Entries,
+	); err != nil {
+		return fmt.Errorf("failed to update crawl run %s: %w", run.RunID, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package postgre
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"tto_chromedp/pkg/models"
+)
+
+func TestFinishCrawlRunCountsAbortedApart(t *testing.T) {
+	db, rec := openRecordingDB(t)
+	run := models.CrawlRun{
+		RunID:      "run1",
+		Status:     models.CrawlRunAborted,
+		FinishedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Entries: models.CrawlRunEntries{
+			{SocialProfileID: 1, Outcome: models.CrawlOutcomeSuccess},
+			{SocialProfileID: 2, Outcome: models.CrawlOutcomeSuccess},
+			{SocialProfileID: 3, Outcome: models.CrawlOutcomePartial},
+			{SocialProfileID: 4, Outcome: models.CrawlOutcomeSkipped},
+			{SocialProfileID: 5, Outcome: models.CrawlOutcomeFailed},
+			{SocialProfileID: 6, Outcome: models.CrawlOutcomeAborted},
+			{SocialProfileID: 7, Outcome: models.CrawlOutcomeAborted},
+		},
+	}
+	if err := NewCrawlRunRepository(db).FinishCrawlRun(context.Background(), run); err != nil {
+		t.Fatal(err)
+	}
+
+	execs := rec.recorded()
+	if len(execs) != 1 {
+		t.Fatalf("executed %d statements, want 1", len(execs))
+	}
+	if !strings.Contains(execs[0].Query, "aborted = $9") {
+		t.Fatalf("query does not store the aborted count: %s", execs[0].Query)
+	}
+	args := execs[0].Args
+	// total, succeeded, partial, skipped, failed, aborted
+	want := []int64{7, 2, 1, 1, 1, 2}
+	for i, w := range want {
+		if got := args[3+i]; got != w {
+			t.Errorf("arg $%d = %v, want %d", 4+i, got, w)
+		}
+	}
+}
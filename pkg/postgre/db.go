@@ -3,14 +3,19 @@ package postgre
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 
+	"tto_chromedp/pkg/logging"
+
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq" // PostgreSQL driver
 )
 
+// DB_LOG_STAGE is the stage the connection messages are logged under.
+const DB_LOG_STAGE = "db"
+
 // Define the structure for the database credentials
 type DBCredentials struct {
 	Host     string
@@ -23,12 +28,14 @@ type DBCredentials struct {
 
 // initDB creates a DSN (Data Source Name) string and establishes the database connection.
 func InitDB(creds DBCredentials) (*sql.DB, error) {
-	fmt.Println("enter here")
 	// DSN format: "user=USER password=PASSWORD host=HOST port=PORT dbname=DBNAME sslmode=disable"
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		creds.Host, creds.Port, creds.User, creds.Password, creds.DBName, creds.SSLMode)
 
-	fmt.Println("Attempting to connect to PostgreSQL...", creds.Host, creds.Port, creds.DBName, creds.User, creds.Password, creds.SSLMode)
+	// The password must never reach the logs, whatever message it ends up in
+	logging.RegisterSecret(creds.Password)
+	logger := slog.Default().With(logging.KEY_STAGE, DB_LOG_STAGE)
+	logger.Info("Attempting to connect to PostgreSQL", "host", creds.Host, "port", creds.Port, "database", creds.DBName, "user", creds.User, "sslmode", creds.SSLMode)
 
 	// Open the connection. The database connection is not established immediately here.
 	db, err := sql.Open("postgres", dsn)
@@ -47,14 +54,16 @@ func InitDB(creds DBCredentials) (*sql.DB, error) {
 	db.SetMaxIdleConns(25)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
-	log.Println("Successfully connected to PostgreSQL database!")
+	logger.Info("Successfully connected to PostgreSQL database")
 	return db, nil
 }
 
 func main() {
+	logger := slog.Default().With(logging.KEY_STAGE, DB_LOG_STAGE)
+
 	// --- Load Environment Variables ---
 	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: Could not load .env file: %v", err)
+		logger.Warn("Could not load .env file", "error", err)
 	}
 
 	// --- 1. Get Credentials from Environment ---
@@ -69,13 +78,15 @@ func main() {
 
 	// Simple check for required credentials
 	if creds.User == "" || creds.DBName == "" {
-		log.Fatalf("Fatal: PG_USER and PG_DBNAME must be set in the environment or .env file.")
+		logger.Error("PG_USER and PG_DBNAME must be set in the environment or .env file")
+		os.Exit(1)
 	}
 
 	// --- 2. Initialize Database Connection ---
 	db, err := InitDB(creds)
 	if err != nil {
-		log.Fatalf("Fatal: Database initialization failed: %v", err)
+		logger.Error("Database initialization failed", "error", err)
+		os.Exit(1)
 	}
 	defer db.Close() // Ensure the connection is closed when main exits
 
@@ -87,8 +98,10 @@ func main() {
 
 	err = db.QueryRow(query).Scan(&serverTime)
 	if err != nil {
-		log.Fatalf("Fatal: Failed to execute query: %v", err)
+		db.Close()
+		logger.Error("Failed to execute query", "error", err)
+		os.Exit(1)
 	}
 
-	log.Printf("PostgreSQL Server Time: %s", serverTime.Format(time.RFC3339))
+	logger.Info("PostgreSQL server time", "time", serverTime.Format(time.RFC3339))
 }
@@ -5,10 +5,12 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
+	"tto_chromedp/pkg/logging"
 	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/utils"
 
 	"github.com/lib/pq"
 )
@@ -16,8 +18,19 @@ import (
 type SocialProfileRepository interface {
 	UpsertContentInterestsAndGetIDs(contentInterests []string, userID int) (map[string]int, error)
 	UpsertBrandsAndGetIDs(brandNames []string, userID int, clientID int) (map[string]int, error)
-	UpdateTTOUser(ctx context.Context, userID int, identityData map[string]interface{}) error
-	GetSocialProfileCrawlTTO() ([]models.SocialProfile, error)
+	GetContentInterestMapping(ctx context.Context, labelIDs []string) (map[string]int, error)
+	EnqueueUnmappedLabels(ctx context.Context, labels []models.UnmappedLabel) error
+	ListPendingLabels(ctx context.Context) ([]models.UnmappedLabel, error)
+	ApproveLabelMapping(ctx context.Context, labelID string, contentInterestID int) error
+	UpdateTTOUser(ctx context.Context, userID int, user *models.TTOUser) error
+	GetTTOUser(ctx context.Context, userID int) (*models.TTOUser, error)
+	InsertCreatorEvents(ctx context.Context, events []models.CreatorEvent) error
+	UpdateTTOCreatorStatus(ctx context.Context, userID int, status int) error
+	RecordTTOCrawlFailure(ctx context.Context, userID int, backoff time.Duration, maxFailures int) (int, error)
+	GetSocialProfileCrawlTTO(ctx context.Context, selection models.CrawlSelection) ([]models.SocialProfile, error)
+	GetSocialProfileByID(ctx context.Context, profileID int) (*models.SocialProfile, error)
+	GetSocialProfileByUserName(ctx context.Context, userName string) (*models.SocialProfile, error)
+	InsertDiscoveredProfiles(ctx context.Context, creators []models.DiscoveredCreator) (int, error)
 	Close() error
 }
 
@@ -115,6 +128,133 @@ func (sp *socialProfileRepository) UpsertContentInterestsAndGetIDs(contentIntere
 	return contentMap, nil
 }
 
+// GetContentInterestMapping returns the cms.content_interest ID of each TTO label ID found in the
+// curated crawler.tto_content_interest_mapping table. Unmapped label IDs are absent from the result.
+func (sp *socialProfileRepository) GetContentInterestMapping(ctx context.Context, labelIDs []string) (map[string]int, error) {
+	mapping := make(map[string]int)
+	if len(labelIDs) == 0 {
+		return mapping, nil
+	}
+
+	selectQuery := "SELECT label_id, content_interest_id FROM crawler.tto_content_interest_mapping WHERE label_id = ANY($1);"
+	rows, err := sp.db.QueryContext(ctx, selectQuery, pq.Array(labelIDs))
+	if err != nil {
+		return nil, fmt.Errorf("content interest mapping query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var labelID string
+		var contentInterestID int
+		if err := rows.Scan(&labelID, &contentInterestID); err != nil {
+			return nil, fmt.Errorf("failed to scan content interest mapping row: %w", err)
+		}
+		mapping[labelID] = contentInterestID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during content interest mapping iteration: %w", err)
+	}
+
+	return mapping, nil
+}
+
+// EnqueueUnmappedLabels adds TTO labels without a CMS mapping to the review queue, or bumps their
+// seen counter if they are already queued.
+func (sp *socialProfileRepository) EnqueueUnmappedLabels(ctx context.Context, labels []models.UnmappedLabel) error {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start review queue transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insertQuery := `
+		INSERT INTO crawler.tto_label_review_queue (label_id, label_name, kind, seen_count, status, first_seen_at, last_seen_at)
+		VALUES ($1, $2, $3, 1, $4, $5, $5)
+		ON CONFLICT (label_id) DO UPDATE
+		SET label_name = EXCLUDED.label_name,
+			seen_count = crawler.tto_label_review_queue.seen_count + 1,
+			last_seen_at = EXCLUDED.last_seen_at;`
+
+	currentTime := time.Now()
+	for _, label := range labels {
+		if _, err := tx.ExecContext(ctx, insertQuery, label.LabelID, label.LabelName, label.Kind, models.LabelReviewPending, currentTime); err != nil {
+			return fmt.Errorf("failed to enqueue label %s: %w", label.LabelID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit review queue transaction: %w", err)
+	}
+	return nil
+}
+
+// ListPendingLabels returns the labels still waiting for review, most frequently seen first.
+func (sp *socialProfileRepository) ListPendingLabels(ctx context.Context) ([]models.UnmappedLabel, error) {
+	selectQuery := `
+		SELECT label_id, label_name, kind, seen_count, first_seen_at, last_seen_at
+		FROM crawler.tto_label_review_queue
+		WHERE status = $1
+		ORDER BY seen_count DESC, label_name;`
+
+	rows, err := sp.db.QueryContext(ctx, selectQuery, models.LabelReviewPending)
+	if err != nil {
+		return nil, fmt.Errorf("review queue query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var labels []models.UnmappedLabel
+	for rows.Next() {
+		var label models.UnmappedLabel
+		if err := rows.Scan(&label.LabelID, &label.LabelName, &label.Kind, &label.SeenCount, &label.FirstSeenAt, &label.LastSeenAt); err != nil {
+			return labels, fmt.Errorf("failed to scan review queue row: %w", err)
+		}
+		labels = append(labels, label)
+	}
+	if err := rows.Err(); err != nil {
+		return labels, fmt.Errorf("error during review queue iteration: %w", err)
+	}
+	return labels, nil
+}
+
+// ApproveLabelMapping maps a queued TTO label to an existing cms.content_interest row and marks it reviewed.
+func (sp *socialProfileRepository) ApproveLabelMapping(ctx context.Context, labelID string, contentInterestID int) error {
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start label approval transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var labelName string
+	err = tx.QueryRowContext(ctx, "SELECT label_name FROM crawler.tto_label_review_queue WHERE label_id = $1;", labelID).Scan(&labelName)
+	if err != nil {
+		return fmt.Errorf("label %s is not in the review queue: %w", labelID, err)
+	}
+
+	currentTime := time.Now()
+	mappingQuery := `
+		INSERT INTO crawler.tto_content_interest_mapping (label_id, label_name, content_interest_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (label_id) DO UPDATE
+		SET content_interest_id = EXCLUDED.content_interest_id, label_name = EXCLUDED.label_name, updated_at = EXCLUDED.updated_at;`
+	if _, err := tx.ExecContext(ctx, mappingQuery, labelID, labelName, contentInterestID, currentTime); err != nil {
+		return fmt.Errorf("failed to save mapping for label %s: %w", labelID, err)
+	}
+
+	statusQuery := "UPDATE crawler.tto_label_review_queue SET status = $1 WHERE label_id = $2;"
+	if _, err := tx.ExecContext(ctx, statusQuery, models.LabelReviewApproved, labelID); err != nil {
+		return fmt.Errorf("failed to mark label %s as approved: %w", labelID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit label approval: %w", err)
+	}
+	return nil
+}
+
 // UpsertBrandsAndGetIDs inserts new brand names or does nothing if they exist,
 // then retrieves the IDs for all given names.
 func (sp *socialProfileRepository) UpsertBrandsAndGetIDs(brandNames []string, userID int, clientID int) (map[string]int, error) {
@@ -194,108 +334,199 @@ func (sp *socialProfileRepository) UpsertBrandsAndGetIDs(brandNames []string, us
 	return brandMap, nil
 }
 
-// UpdateTTSUser updates the social_profiles table with the processed identity data for a user.
-func (sp *socialProfileRepository) UpdateTTOUser(ctx context.Context, userID int, identityData map[string]interface{}) error {
-	// Map identity_data keys to database column names
-	dbMapping := map[string]string{
-		"content_interest":          "content_interest",
-		"audience_age":              "audience_age",
-		"audience_location":         "audience_location",
-		"audience_gender":           "audience_gender",
-		"kol_growth":                "kol_growth",
-		"tiktokshop_updated_at":     "tiktokshop_updated_at",
-		"tiktokshop_creator_status": "tiktokshop_creator_status",
-	}
-
-	// Build the SET part of the query dynamically
-	var setClauses []string
-	var params []interface{}
-	placeholderIdx := 1
+// GetTTOUser reads the TTO data stored for a creator, as last written by UpdateTTOUser.
+// UpdatedAt is zero when the creator was never crawled.
+func (sp *socialProfileRepository) GetTTOUser(ctx context.Context, userID int) (*models.TTOUser, error) {
+	selectQuery := `
+		SELECT content_interest, audience_age, audience_location, audience_gender, kol_growth,
+			COALESCE(tiktokshop_region, ''), tiktokshop_updated_at, COALESCE(tiktokshop_creator_status, $2),
+			tiktokshop_brands, tiktokshop_kpis, COALESCE(tiktokshop_avatar_url, ''), tiktokshop_video_covers,
+			tiktokshop_health
+		FROM crawler.social_profiles
+		WHERE id = $1;`
+
+	user := &models.TTOUser{KPIs: &models.CreatorKPI{}, Health: &models.CreatorHealth{}}
+	var updatedAt sql.NullTime
+	var kpis, health []byte
+	err := sp.db.QueryRowContext(ctx, selectQuery, userID, utils.TTO_CREATOR_STATUS_PENDING).Scan(
+		&user.CategoryContent,
+		&user.AgeDistri,
+		&user.RegionDistri,
+		&user.GenderDistri,
+		&user.KolGrowth,
+		&user.Region,
+		&updatedAt,
+		&user.CreatorStatus,
+		&user.Brands,
+		&kpis,
+		&user.AvatarURL,
+		&user.VideoCovers,
+		&health,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TTO data of profile %d: %w", userID, err)
+	}
 
-	for dataKey, dbCol := range dbMapping {
-		value, ok := identityData[dataKey]
-		if !ok || value == nil {
-			continue // Skip if key is missing or value is nil
-		}
+	user.UpdatedAt = updatedAt.Time
+	// Optional sections stay nil when the column is NULL
+	if kpis == nil {
+		user.KPIs = nil
+	} else if err := user.KPIs.Scan(kpis); err != nil {
+		return nil, fmt.Errorf("failed to decode stored KPIs of profile %d: %w", userID, err)
+	}
+	if health == nil {
+		user.Health = nil
+	} else if err := user.Health.Scan(health); err != nil {
+		return nil, fmt.Errorf("failed to decode stored health of profile %d: %w", userID, err)
+	}
+	return user, nil
+}
 
-		var paramValue interface{}
-
-		// Check if the value is a complex type (map or slice) that needs JSON marshaling
-		if _, isMap := value.(map[string]interface{}); isMap {
-			// Convert complex types to JSON byte slice
-			jsonValue, err := json.Marshal(value)
-			if err != nil {
-				log.Printf("Warning: Failed to marshal key %s to JSON: %v. Skipping.", dataKey, err)
-				continue
-			}
-			paramValue = jsonValue
-		} else if _, isSlice := value.([]interface{}); isSlice {
-			// Convert complex types to JSON byte slice
-			jsonValue, err := json.Marshal(value)
-			if err != nil {
-				log.Printf("Warning: Failed to marshal key %s to JSON: %v. Skipping.", dataKey, err)
-				continue
-			}
-			paramValue = jsonValue
-		} else {
-			// Use standard value for simple types, including time.Time objects
-			paramValue = value
-		}
+// InsertCreatorEvents records the changes detected for creators in crawler.tto_creator_events.
+func (sp *socialProfileRepository) InsertCreatorEvents(ctx context.Context, events []models.CreatorEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
 
-		params = append(params, paramValue)
-		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", dbCol, placeholderIdx))
-		placeholderIdx++
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start events transaction: %w", err)
 	}
+	defer tx.Rollback()
+
+	insertQuery := `
+		INSERT INTO crawler.tto_creator_events (social_profile_id, event_type, severity, message, previous_value, current_value, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);`
 
-	if len(setClauses) == 0 {
-		return nil // Nothing to update
+	for _, event := range events {
+		previous, err := json.Marshal(event.Previous)
+		if err != nil {
+			return fmt.Errorf("failed to encode previous value of %s event: %w", event.Type, err)
+		}
+		current, err := json.Marshal(event.Current)
+		if err != nil {
+			return fmt.Errorf("failed to encode current value of %s event: %w", event.Type, err)
+		}
+		if _, err := tx.ExecContext(ctx, insertQuery,
+			event.SocialProfileID, event.Type, event.Severity, event.Message, string(previous), string(current), event.DetectedAt,
+		); err != nil {
+			return fmt.Errorf("failed to insert %s event for profile %d: %w", event.Type, event.SocialProfileID, err)
+		}
 	}
 
-	// Append the WHERE clause parameter (user_id) and updated_at
-	setClauses = append(setClauses, fmt.Sprintf("updated_at = $%d", placeholderIdx))
-	params = append(params, time.Now())
-	placeholderIdx++
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit events transaction: %w", err)
+	}
+	return nil
+}
 
-	updateQuery := fmt.Sprintf(`
+// UpdateTTOUser updates the social_profiles table with the parsed TTO data of a creator.
+// JSONB sections that are nil keep their stored value.
+func (sp *socialProfileRepository) UpdateTTOUser(ctx context.Context, userID int, user *models.TTOUser) error {
+	updateQuery := `
 		UPDATE crawler.social_profiles
-		SET %s
-		WHERE id = $%d;`, strings.Join(setClauses, ", "), placeholderIdx)
-	params = append(params, userID)
-	log.Printf("Executing update query: %s with params (excluding userID): %v", updateQuery, params[:len(params)-1]) // Log query for debugging
-
-	// Execute the query within a transaction for safety
-	tx, err := sp.db.Begin()
+		SET content_interest = COALESCE($1, content_interest),
+			audience_age = COALESCE($2, audience_age),
+			audience_location = COALESCE($3, audience_location),
+			audience_gender = COALESCE($4, audience_gender),
+			kol_growth = COALESCE($5, kol_growth),
+			tiktokshop_region = COALESCE(NULLIF($6, ''), tiktokshop_region),
+			tiktokshop_updated_at = $7,
+			tiktokshop_creator_status = $8,
+			updated_at = $9,
+			tiktokshop_brands = COALESCE($11, tiktokshop_brands),
+			tiktokshop_kpis = COALESCE($12, tiktokshop_kpis),
+			tiktokshop_avatar_url = COALESCE(NULLIF($13, ''), tiktokshop_avatar_url),
+			tiktokshop_video_covers = COALESCE($14, tiktokshop_video_covers),
+			tiktokshop_health = COALESCE($15, tiktokshop_health),
+			tiktokshop_crawl_failures = 0,
+			tiktokshop_next_attempt_at = NULL
+		WHERE id = $10;`
+
+	tx, err := sp.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to start update transaction: %w", err)
 	}
-	// Use an anonymous function in defer to ensure tx.Rollback() only runs if Commit fails or an error occurs before it.
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			panic(r) // Re-throw the panic
-		} else if err != nil { // Check if err was set before the commit
-			tx.Rollback()
-		}
-	}()
+	defer tx.Rollback()
 
-	_, err = tx.Exec(updateQuery, params...)
+	_, err = tx.ExecContext(ctx, updateQuery,
+		user.CategoryContent,
+		user.AgeDistri,
+		user.RegionDistri,
+		user.GenderDistri,
+		user.KolGrowth,
+		user.Region,
+		user.UpdatedAt,
+		user.CreatorStatus,
+		time.Now(),
+		userID,
+		user.Brands,
+		user.KPIs,
+		user.AvatarURL,
+		user.VideoCovers,
+		user.Health,
+	)
 	if err != nil {
-		// The defer function will handle rollback since err is set here
 		return fmt.Errorf("failed to execute update query: %w", err)
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		// The defer function will handle rollback since err is set here
+	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit update transaction: %w", err)
 	}
 
 	return nil
 }
 
-func (sp *socialProfileRepository) GetSocialProfileCrawlTTO() ([]models.SocialProfile, error) {
-	sqlQuery := `SELECT id, username FROM crawler.social_profiles WHERE tiktokshop_creator_status = $1 and id=$2 LIMIT $3;`
-	rows, err := sp.db.QueryContext(context.Background(), sqlQuery, -1, 187, 200)
+// UpdateTTOCreatorStatus sets only the TTO crawl status of a profile, e.g. to mark it as skipped.
+func (sp *socialProfileRepository) UpdateTTOCreatorStatus(ctx context.Context, userID int, status int) error {
+	updateQuery := `
+		UPDATE crawler.social_profiles
+		SET tiktokshop_creator_status = $1, tiktokshop_updated_at = $2, updated_at = $2
+		WHERE id = $3;`
+
+	if _, err := sp.db.ExecContext(ctx, updateQuery, status, time.Now(), userID); err != nil {
+		return fmt.Errorf("failed to update TTO creator status: %w", err)
+	}
+	return nil
+}
+
+// RecordTTOCrawlFailure counts a failed or partial crawl of the profile and keeps it out of the crawl
+// selection for backoff, doubled at each consecutive failure. The profile is skipped for good once it
+// reaches maxFailures (0 for no limit). It returns the number of consecutive failures.
+func (sp *socialProfileRepository) RecordTTOCrawlFailure(ctx context.Context, userID int, backoff time.Duration, maxFailures int) (int, error) {
+	// The SET expressions see the failures before the increment: the first failure waits backoff
+	updateQuery := `
+		UPDATE crawler.social_profiles
+		SET tiktokshop_crawl_failures = tiktokshop_crawl_failures + 1,
+			tiktokshop_next_attempt_at = NOW() + make_interval(secs => $2::double precision * power(2, LEAST(tiktokshop_crawl_failures, 10))),
+			tiktokshop_creator_status = CASE WHEN $3 > 0 AND tiktokshop_crawl_failures + 1 >= $3 THEN $4 ELSE tiktokshop_creator_status END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING tiktokshop_crawl_failures;`
+
+	var failures int
+	err := sp.db.QueryRowContext(ctx, updateQuery, userID, backoff.Seconds(), maxFailures, utils.TTO_CREATOR_STATUS_SKIPPED).Scan(&failures)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record TTO crawl failure of profile %d: %w", userID, err)
+	}
+	return failures, nil
+}
+
+func (sp *socialProfileRepository) GetSocialProfileCrawlTTO(ctx context.Context, selection models.CrawlSelection) ([]models.SocialProfile, error) {
+	// Pending profiles come first, then the stale ones from the oldest crawl. The profiles backing off
+	// after failed crawls wait for their next attempt, unless asked for by ID.
+	sqlQuery := `
+		SELECT id, username, COALESCE(tiktokshop_region, '')
+		FROM crawler.social_profiles
+		WHERE (
+			tiktokshop_creator_status = $1
+			OR ($4 > 0 AND tiktokshop_creator_status = $5 AND tiktokshop_updated_at < NOW() - make_interval(days => $4))
+		) AND ($2 = 0 OR id = $2)
+		AND ($2 <> 0 OR tiktokshop_next_attempt_at IS NULL OR tiktokshop_next_attempt_at <= NOW())
+		ORDER BY (tiktokshop_creator_status = $1) DESC, tiktokshop_updated_at NULLS FIRST, id
+		LIMIT $3;`
+	rows, err := sp.db.QueryContext(ctx, sqlQuery,
+		selection.CreatorStatus, selection.ProfileID, selection.Limit, selection.RefreshAfterDays, utils.TTO_CREATOR_STATUS_DONE)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query social profiles: %w", err)
 	}
@@ -304,7 +535,7 @@ func (sp *socialProfileRepository) GetSocialProfileCrawlTTO() ([]models.SocialPr
 	var profiles []models.SocialProfile
 	for rows.Next() {
 		var profile models.SocialProfile
-		if err := rows.Scan(&profile.ID, &profile.UserName); err != nil {
+		if err := rows.Scan(&profile.ID, &profile.UserName, &profile.Region); err != nil {
 			// Return profiles found so far along with the error
 			return profiles, fmt.Errorf("failed to scan social profile row: %w", err)
 		}
@@ -315,10 +546,93 @@ func (sp *socialProfileRepository) GetSocialProfileCrawlTTO() ([]models.SocialPr
 		return profiles, fmt.Errorf("error during rows iteration: %w", err)
 	}
 
-	log.Printf("Fetched %d social profiles for TTO crawling.", len(profiles))
+	slog.Info("Fetched social profiles for TTO crawling", "count", len(profiles))
 	return profiles, nil
 }
 
+// GetSocialProfileByID reads one profile. The error wraps sql.ErrNoRows when it does not exist.
+func (sp *socialProfileRepository) GetSocialProfileByID(ctx context.Context, profileID int) (*models.SocialProfile, error) {
+	selectQuery := `
+		SELECT id, username, COALESCE(tiktokshop_region, '')
+		FROM crawler.social_profiles
+		WHERE id = $1;`
+
+	profile := &models.SocialProfile{}
+	if err := sp.db.QueryRowContext(ctx, selectQuery, profileID).Scan(&profile.ID, &profile.UserName, &profile.Region); err != nil {
+		return nil, fmt.Errorf("failed to read social profile %d: %w", profileID, err)
+	}
+	return profile, nil
+}
+
+// GetSocialProfileByUserName reads one profile by its TikTok handle, compared case-insensitively.
+// The error wraps sql.ErrNoRows when it does not exist.
+func (sp *socialProfileRepository) GetSocialProfileByUserName(ctx context.Context, userName string) (*models.SocialProfile, error) {
+	selectQuery := `
+		SELECT id, username, COALESCE(tiktokshop_region, '')
+		FROM crawler.social_profiles
+		WHERE LOWER(username) = LOWER($1)
+		ORDER BY id
+		LIMIT 1;`
+
+	profile := &models.SocialProfile{}
+	if err := sp.db.QueryRowContext(ctx, selectQuery, userName).Scan(&profile.ID, &profile.UserName, &profile.Region); err != nil {
+		return nil, fmt.Errorf("failed to read social profile %s: %w", userName, err)
+	}
+	return profile, nil
+}
+
+// insertDiscoveredProfileQuery stores a missing ttUID as NULL rather than an empty string, which would
+// otherwise match every later creator without a ttUID and drop them as duplicates.
+const insertDiscoveredProfileQuery = `
+		INSERT INTO crawler.social_profiles (
+			username, tiktokshop_tt_uid, tiktokshop_region, tiktokshop_creator_status, created_at, updated_at
+		)
+		SELECT $1, NULLIF($2, ''), $3, $4, $5, $5
+		WHERE NOT EXISTS (
+			SELECT 1 FROM crawler.social_profiles
+			WHERE tiktokshop_tt_uid = NULLIF($2, '') OR LOWER(username) = LOWER($1)
+		);`
+
+// InsertDiscoveredProfiles inserts creators found by discovery mode as pending profiles.
+// A creator is skipped when a profile with the same ttUID or the same username (case-insensitive)
+// already exists. It returns the number of rows actually inserted.
+func (sp *socialProfileRepository) InsertDiscoveredProfiles(ctx context.Context, creators []models.DiscoveredCreator) (int, error) {
+	if len(creators) == 0 {
+		return 0, nil
+	}
+
+	tx, err := sp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start discovery transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, insertDiscoveredProfileQuery)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare discovery insert: %w", err)
+	}
+	defer stmt.Close()
+
+	currentTime := time.Now()
+	inserted := 0
+	for _, creator := range creators {
+		res, err := stmt.ExecContext(ctx, creator.HandleName, creator.TtUID, creator.Region, utils.TTO_CREATOR_STATUS_PENDING, currentTime)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert discovered creator %s: %w", creator.HandleName, err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			inserted += int(n)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit discovery transaction: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Inserted discovered creators as pending profiles", "inserted", inserted, "discovered", len(creators))
+	return inserted, nil
+}
+
 func (sp *socialProfileRepository) Close() error {
 	return sp.db.Close()
 }
@@ -0,0 +1,102 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"tto_chromedp/pkg/models"
+)
+
+func TestInsertDiscoveredProfileQueryNullsEmptyUID(t *testing.T) {
+	// Every use of the ttUID parameter must go through NULLIF, or a stored '' matches later creators
+	bare := regexp.MustCompile(`(^|[^(])\$2\b`)
+	if loc := bare.FindStringIndex(insertDiscoveredProfileQuery); loc != nil {
+		t.Fatalf("$2 used without NULLIF at %q", insertDiscoveredProfileQuery[loc[0]:])
+	}
+	if n := len(regexp.MustCompile(`NULLIF\(\$2, ''\)`).FindAllString(insertDiscoveredProfileQuery, -1)); n != 2 {
+		t.Fatalf("NULLIF($2, '') used %d times, want 2 (insert and NOT EXISTS)", n)
+	}
+}
+
+func TestInsertDiscoveredProfilesWithoutUID(t *testing.T) {
+	db, rec := openRecordingDB(t)
+	repo := NewSocialProfileRepository(db)
+
+	creators := []models.DiscoveredCreator{
+		{HandleName: "first", Region: "vn"},
+		{HandleName: "second", Region: "vn"},
+		{TtUID: "123", HandleName: "third", Region: "row"},
+	}
+	inserted, err := repo.InsertDiscoveredProfiles(context.Background(), creators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inserted != len(creators) {
+		t.Fatalf("inserted = %d, want %d", inserted, len(creators))
+	}
+	execs := rec.recorded()
+	if len(execs) != len(creators) {
+		t.Fatalf("executed %d statements, want %d", len(execs), len(creators))
+	}
+	for i, exec := range execs {
+		if exec.Query != insertDiscoveredProfileQuery {
+			t.Fatalf("statement %d is not the discovery insert: %s", i, exec.Query)
+		}
+		if exec.Args[0] != creators[i].HandleName || exec.Args[1] != creators[i].TtUID {
+			t.Errorf("statement %d args = %v", i, exec.Args[:2])
+		}
+	}
+}
+
+// TestInsertDiscoveredProfilesPostgres runs the insert against the database of TTO_TEST_PG_DSN, which
+// must have the migrations applied. The rows it creates are deleted afterwards.
+func TestInsertDiscoveredProfilesPostgres(t *testing.T) {
+	dsn := os.Getenv("TTO_TEST_PG_DSN")
+	if dsn == "" {
+		t.Skip("TTO_TEST_PG_DSN not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	suffix := time.Now().UnixNano()
+	creators := []models.DiscoveredCreator{
+		{HandleName: fmt.Sprintf("nouid_a_%d", suffix), Region: "vn"},
+		{HandleName: fmt.Sprintf("nouid_b_%d", suffix), Region: "vn"},
+	}
+	t.Cleanup(func() {
+		for _, creator := range creators {
+			db.Exec(`DELETE FROM crawler.social_profiles WHERE username = $1`, creator.HandleName)
+		}
+	})
+
+	repo := NewSocialProfileRepository(db)
+	for round := 0; round < 2; round++ {
+		inserted, err := repo.InsertDiscoveredProfiles(context.Background(), creators)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := len(creators)
+		if round > 0 {
+			want = 0
+		}
+		if inserted != want {
+			t.Fatalf("round %d inserted %d, want %d", round, inserted, want)
+		}
+	}
+	var empty int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM crawler.social_profiles WHERE username IN ($1, $2) AND tiktokshop_tt_uid = ''`,
+		creators[0].HandleName, creators[1].HandleName).Scan(&empty); err != nil {
+		t.Fatal(err)
+	}
+	if empty != 0 {
+		t.Fatalf("%d rows stored with an empty ttUID", empty)
+	}
+}
@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tto_chromedp/pkg/utils"
+)
+
+// Config holds the token-bucket settings shared by the global bucket and every per-account bucket.
+type Config struct {
+	// GlobalPerMinute and GlobalBurst size the bucket shared by all accounts (all traffic leaves from the same IP).
+	GlobalPerMinute float64
+	GlobalBurst     int
+	// AccountPerMinute and AccountBurst size the bucket kept for each TTO account.
+	AccountPerMinute float64
+	AccountBurst     int
+
+	// DailyCap limits the number of actions per account per day (0 disables the cap).
+	DailyCap int
+	// GlobalDailyCap limits the number of actions across all accounts per day (0 disables the cap).
+	GlobalDailyCap int
+
+	// QuietHours is the window, in VN_TIMEZONE, during which no request is allowed.
+	QuietHours QuietHours
+
+	// BackoffInitial, BackoffMax and BackoffFactor drive the cool-down applied after throttling signals.
+	BackoffInitial time.Duration
+	BackoffMax     time.Duration
+	BackoffFactor  float64
+
+	// StatePath is where the limiter state is persisted between runs (empty disables persistence).
+	StatePath string
+}
+
+// QuietHours is a [Start, End) window of hours of the day. Start > End wraps around midnight.
+type QuietHours struct {
+	Start int
+	End   int
+}
+
+// Enabled reports whether the quiet window covers any hour at all.
+func (q QuietHours) Enabled() bool {
+	return q.Start != q.End
+}
+
+// Contains reports whether the given hour (0-23) falls inside the quiet window.
+func (q QuietHours) Contains(hour int) bool {
+	if !q.Enabled() {
+		return false
+	}
+	if q.Start < q.End {
+		return hour >= q.Start && hour < q.End
+	}
+	return hour >= q.Start || hour < q.End
+}
+
+// ParseQuietHours parses a window in the form "23-6". An empty string disables quiet hours.
+func ParseQuietHours(value string) (QuietHours, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return QuietHours{}, nil
+	}
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q, expected START-END", value)
+	}
+	start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || start < 0 || start > 23 {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours start %q", parts[0])
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || end < 0 || end > 23 {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours end %q", parts[1])
+	}
+	return QuietHours{Start: start, End: end}, nil
+}
+
+// DefaultConfig returns conservative settings that roughly match the pace of the old fixed sleeps.
+func DefaultConfig() Config {
+	return Config{
+		GlobalPerMinute:  12,
+		GlobalBurst:      3,
+		AccountPerMinute: 6,
+		AccountBurst:     2,
+		DailyCap:         400,
+		GlobalDailyCap:   1000,
+		BackoffInitial:   30 * time.Second,
+		BackoffMax:       30 * time.Minute,
+		BackoffFactor:    2,
+		StatePath:        "./profiles/ratelimit_state.json",
+	}
+}
+
+// LoadConfigFromEnv builds a Config from TTO_RATE_* environment variables on top of DefaultConfig.
+func LoadConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+	cfg.GlobalPerMinute = utils.GetEnvFloat("TTO_RATE_GLOBAL_PER_MINUTE", cfg.GlobalPerMinute)
+	cfg.GlobalBurst = utils.GetEnvInt("TTO_RATE_GLOBAL_BURST", cfg.GlobalBurst)
+	cfg.AccountPerMinute = utils.GetEnvFloat("TTO_RATE_ACCOUNT_PER_MINUTE", cfg.AccountPerMinute)
+	cfg.AccountBurst = utils.GetEnvInt("TTO_RATE_ACCOUNT_BURST", cfg.AccountBurst)
+	cfg.DailyCap = utils.GetEnvInt("TTO_RATE_DAILY_CAP", cfg.DailyCap)
+	cfg.GlobalDailyCap = utils.GetEnvInt("TTO_RATE_GLOBAL_DAILY_CAP", cfg.GlobalDailyCap)
+	cfg.BackoffInitial = utils.GetEnvDuration("TTO_RATE_BACKOFF_INITIAL", cfg.BackoffInitial)
+	cfg.BackoffMax = utils.GetEnvDuration("TTO_RATE_BACKOFF_MAX", cfg.BackoffMax)
+	cfg.BackoffFactor = utils.GetEnvFloat("TTO_RATE_BACKOFF_FACTOR", cfg.BackoffFactor)
+	cfg.StatePath = utils.GetEnvString("TTO_RATE_STATE_PATH", cfg.StatePath)
+
+	quiet, err := ParseQuietHours(utils.GetEnvString("TTO_RATE_QUIET_HOURS", ""))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.QuietHours = quiet
+
+	if cfg.GlobalPerMinute <= 0 || cfg.AccountPerMinute <= 0 {
+		return cfg, fmt.Errorf("rate per minute must be positive (global=%v, account=%v)", cfg.GlobalPerMinute, cfg.AccountPerMinute)
+	}
+	if cfg.GlobalBurst < 1 {
+		cfg.GlobalBurst = 1
+	}
+	if cfg.AccountBurst < 1 {
+		cfg.AccountBurst = 1
+	}
+	if cfg.BackoffFactor < 1 {
+		cfg.BackoffFactor = 1
+	}
+	return cfg, nil
+}
@@ -0,0 +1,268 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/metrics"
+	"tto_chromedp/pkg/utils"
+)
+
+// Action identifies the kind of request being rate limited.
+type Action string
+
+const (
+	ActionNavigate Action = "navigate"
+	ActionSearch   Action = "search"
+
+	globalKey = "_global"
+)
+
+var (
+	// ErrDailyCapReached is returned when the account or global daily budget is spent.
+	ErrDailyCapReached = errors.New("ratelimit: daily cap reached")
+	// ErrQuietHours is returned when a request is attempted inside the configured quiet window.
+	ErrQuietHours = errors.New("ratelimit: inside quiet hours")
+)
+
+// Limiter is a token-bucket limiter keyed by account, with a global bucket on top.
+// Every call to Wait consumes a token from both the account bucket and the global bucket.
+type Limiter struct {
+	mu       sync.Mutex
+	cfg      Config
+	location *time.Location
+	buckets  map[string]*bucket
+	now      func() time.Time
+}
+
+// bucket is the persisted state of a single token bucket.
+type bucket struct {
+	Tokens        float64   `json:"tokens"`
+	LastRefill    time.Time `json:"last_refill"`
+	Day           string    `json:"day"`
+	DayCount      int       `json:"day_count"`
+	BackoffLevel  int       `json:"backoff_level"`
+	CoolDownUntil time.Time `json:"cool_down_until"`
+}
+
+// NewLimiter creates a Limiter and restores any state saved at cfg.StatePath.
+func NewLimiter(ctx context.Context, cfg Config) (*Limiter, error) {
+	location, err := time.LoadLocation(utils.VN_TIMEZONE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load location %s: %w", utils.VN_TIMEZONE, err)
+	}
+
+	l := &Limiter{
+		cfg:      cfg,
+		location: location,
+		buckets:  make(map[string]*bucket),
+		now:      time.Now,
+	}
+
+	if cfg.StatePath != "" {
+		buckets, err := loadState(cfg.StatePath)
+		if err != nil {
+			return nil, err
+		}
+		if buckets != nil {
+			l.buckets = buckets
+			logging.FromContext(ctx).Info("Restored rate limiter state", "buckets", len(buckets), "path", cfg.StatePath)
+		}
+	}
+
+	return l, nil
+}
+
+// Wait blocks until both the account and global buckets have a token, then consumes one from each.
+// It returns ErrQuietHours or ErrDailyCapReached immediately instead of blocking until the next window.
+func (l *Limiter) Wait(ctx context.Context, account string, action Action) error {
+	for {
+		delay, err := l.reserve(account)
+		if err != nil {
+			return fmt.Errorf("%s for account %q: %w", action, account, err)
+		}
+		if delay <= 0 {
+			return l.save()
+		}
+
+		logging.FromContext(ctx).Info("Rate limiter delaying action", "action", action, logging.KEY_ACCOUNT, account, "delay", delay.Round(time.Millisecond))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve either consumes a token (returning 0) or returns how long the caller must wait before retrying.
+func (l *Limiter) reserve(account string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	local := now.In(l.location)
+	if l.cfg.QuietHours.Contains(local.Hour()) {
+		return 0, ErrQuietHours
+	}
+
+	day := local.Format(utils.DATE_FORMAT_YYYYMMDD)
+	global := l.bucketFor(globalKey, float64(l.cfg.GlobalBurst), now)
+	acct := l.bucketFor(account, float64(l.cfg.AccountBurst), now)
+
+	l.refill(global, l.cfg.GlobalPerMinute, float64(l.cfg.GlobalBurst), day, now)
+	l.refill(acct, l.cfg.AccountPerMinute, float64(l.cfg.AccountBurst), day, now)
+
+	if l.cfg.GlobalDailyCap > 0 && global.DayCount >= l.cfg.GlobalDailyCap {
+		return 0, ErrDailyCapReached
+	}
+	if l.cfg.DailyCap > 0 && acct.DayCount >= l.cfg.DailyCap {
+		return 0, ErrDailyCapReached
+	}
+
+	var delay time.Duration
+	for _, b := range []*bucket{global, acct} {
+		if now.Before(b.CoolDownUntil) {
+			delay = maxDuration(delay, b.CoolDownUntil.Sub(now))
+		}
+	}
+	delay = maxDuration(delay, tokenDelay(global, l.cfg.GlobalPerMinute))
+	delay = maxDuration(delay, tokenDelay(acct, l.cfg.AccountPerMinute))
+	if delay > 0 {
+		return delay, nil
+	}
+
+	global.Tokens--
+	acct.Tokens--
+	global.DayCount++
+	acct.DayCount++
+	return 0, nil
+}
+
+// ReportThrottled records a throttling signal (a non-zero BaseResp.StatusCode or an HTTP 429) and
+// extends the cool-down exponentially. HTTP 429 is applied to the global bucket as well, since it
+// is enforced per IP rather than per account.
+func (l *Limiter) ReportThrottled(ctx context.Context, account string, httpStatus int, apiStatusCode int) {
+	logger := logging.FromContext(ctx)
+	l.mu.Lock()
+	now := l.now()
+	keys := []string{account}
+	if httpStatus == http.StatusTooManyRequests {
+		keys = append(keys, globalKey)
+	}
+	for _, key := range keys {
+		burst := float64(l.cfg.AccountBurst)
+		if key == globalKey {
+			burst = float64(l.cfg.GlobalBurst)
+		}
+		b := l.bucketFor(key, burst, now)
+		b.BackoffLevel++
+		coolDown := l.backoffFor(b.BackoffLevel)
+		b.CoolDownUntil = now.Add(coolDown)
+		b.Tokens = 0
+		metrics.AccountCoolDown(key, b.CoolDownUntil)
+		logger.Warn("Rate limiter throttled, cooling down",
+			"http_status", httpStatus, "api_status", apiStatusCode, "bucket", key, "cool_down", coolDown, "level", b.BackoffLevel)
+	}
+	l.mu.Unlock()
+
+	if err := l.save(); err != nil {
+		logger.Warn("Failed to persist rate limiter state", "error", err)
+	}
+}
+
+// ReportSuccess resets the backoff level of the account and the global bucket after a clean response,
+// and persists the reset so that a restart does not bring the old backoff back.
+func (l *Limiter) ReportSuccess(ctx context.Context, account string) {
+	l.mu.Lock()
+	changed := false
+	for _, key := range []string{account, globalKey} {
+		if b, ok := l.buckets[key]; ok && b.BackoffLevel != 0 {
+			b.BackoffLevel = 0
+			changed = true
+		}
+	}
+	l.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if err := l.save(); err != nil {
+		logging.FromContext(ctx).Warn("Failed to persist rate limiter state", "error", err)
+	}
+}
+
+// CoolDownUntil returns the time until which the account is cooling down (zero if it is not).
+func (l *Limiter) CoolDownUntil(account string) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[account]; ok && l.now().Before(b.CoolDownUntil) {
+		return b.CoolDownUntil
+	}
+	return time.Time{}
+}
+
+// bucketFor returns the bucket for key, creating a full one if it does not exist yet. Callers must hold l.mu.
+func (l *Limiter) bucketFor(key string, burst float64, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{Tokens: burst, LastRefill: now}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// refill adds the tokens earned since the last refill and resets the daily counter on a new day.
+func (l *Limiter) refill(b *bucket, perMinute, burst float64, day string, now time.Time) {
+	if b.Day != day {
+		b.Day = day
+		b.DayCount = 0
+	}
+	elapsed := now.Sub(b.LastRefill)
+	if elapsed > 0 {
+		b.Tokens = math.Min(burst, b.Tokens+elapsed.Minutes()*perMinute)
+		b.LastRefill = now
+	}
+}
+
+// backoffFor returns the cool-down duration for the given backoff level.
+func (l *Limiter) backoffFor(level int) time.Duration {
+	d := float64(l.cfg.BackoffInitial) * math.Pow(l.cfg.BackoffFactor, float64(level-1))
+	if l.cfg.BackoffMax > 0 && d > float64(l.cfg.BackoffMax) {
+		return l.cfg.BackoffMax
+	}
+	return time.Duration(d)
+}
+
+// save persists the limiter state if a state path is configured.
+func (l *Limiter) save() error {
+	if l.cfg.StatePath == "" {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return saveState(l.cfg.StatePath, l.buckets)
+}
+
+// tokenDelay returns how long until the bucket holds at least one token.
+func tokenDelay(b *bucket, perMinute float64) time.Duration {
+	if b.Tokens >= 1 {
+		return 0
+	}
+	missing := 1 - b.Tokens
+	return time.Duration(missing / perMinute * float64(time.Minute))
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
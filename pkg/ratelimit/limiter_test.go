@@ -0,0 +1,303 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeClock is the injected clock of the tests; it only moves when advanced.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.t }
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// vnTime returns the instant of the given wall clock time in Ho Chi Minh City (UTC+7).
+func vnTime(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.FixedZone("ICT", 7*3600))
+}
+
+func testConfig() Config {
+	return Config{
+		GlobalPerMinute:  60,
+		GlobalBurst:      10,
+		AccountPerMinute: 6,
+		AccountBurst:     2,
+		BackoffInitial:   30 * time.Second,
+		BackoffMax:       2 * time.Minute,
+		BackoffFactor:    2,
+	}
+}
+
+func newTestLimiter(t *testing.T, cfg Config, start time.Time) (*Limiter, *fakeClock) {
+	t.Helper()
+	l, err := NewLimiter(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{t: start}
+	l.now = clock.Now
+	return l, clock
+}
+
+// reservation is one reserve call of account, after advancing the clock, and the delay it must get.
+type reservation struct {
+	account string
+	advance time.Duration
+	want    time.Duration
+}
+
+func TestTokenBuckets(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   func(*Config)
+		steps []reservation
+	}{
+		{
+			name: "account burst then refill",
+			steps: []reservation{
+				{"a", 0, 0},
+				{"a", 0, 0},
+				{"a", 0, 10 * time.Second},
+				{"a", 5 * time.Second, 5 * time.Second},
+				{"a", 5 * time.Second, 0},
+			},
+		},
+		{
+			name: "accounts have their own bucket",
+			steps: []reservation{
+				{"a", 0, 0},
+				{"a", 0, 0},
+				{"b", 0, 0},
+				{"a", 0, 10 * time.Second},
+			},
+		},
+		{
+			name: "global bucket is shared by all accounts",
+			cfg:  func(c *Config) { c.GlobalPerMinute, c.GlobalBurst = 2, 2 },
+			steps: []reservation{
+				{"a", 0, 0},
+				{"b", 0, 0},
+				{"c", 0, 30 * time.Second},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			if tt.cfg != nil {
+				tt.cfg(&cfg)
+			}
+			l, clock := newTestLimiter(t, cfg, vnTime(2024, 5, 1, 12, 0))
+			for i, step := range tt.steps {
+				clock.Advance(step.advance)
+				delay, err := l.reserve(step.account)
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+				if delay != step.want {
+					t.Fatalf("step %d (%s): delay = %s, want %s", i, step.account, delay, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDailyCaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      func(*Config)
+		accounts []string
+	}{
+		{name: "account cap", cfg: func(c *Config) { c.DailyCap = 3 }, accounts: []string{"a", "a", "a"}},
+		{name: "global cap", cfg: func(c *Config) { c.GlobalDailyCap = 3 }, accounts: []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.AccountPerMinute, cfg.AccountBurst = 600, 10
+			tt.cfg(&cfg)
+			// 23:00 in VN: the day rolls over one hour later, although it is still the same UTC day
+			l, clock := newTestLimiter(t, cfg, vnTime(2024, 5, 1, 23, 0))
+			for i, account := range tt.accounts {
+				if delay, err := l.reserve(account); err != nil || delay != 0 {
+					t.Fatalf("reservation %d: delay %s, error %v", i, delay, err)
+				}
+				clock.Advance(time.Second)
+			}
+			if _, err := l.reserve(tt.accounts[0]); !errors.Is(err, ErrDailyCapReached) {
+				t.Fatalf("over the cap: error = %v, want ErrDailyCapReached", err)
+			}
+
+			clock.t = vnTime(2024, 5, 1, 23, 59)
+			if _, err := l.reserve(tt.accounts[0]); !errors.Is(err, ErrDailyCapReached) {
+				t.Fatalf("same VN day: error = %v, want ErrDailyCapReached", err)
+			}
+			clock.t = vnTime(2024, 5, 2, 0, 1)
+			if delay, err := l.reserve(tt.accounts[0]); err != nil || delay != 0 {
+				t.Fatalf("next VN day: delay %s, error %v", delay, err)
+			}
+		})
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	cfg := testConfig()
+	cfg.QuietHours = QuietHours{Start: 23, End: 6}
+	tests := []struct {
+		at      time.Time
+		blocked bool
+	}{
+		{vnTime(2024, 5, 1, 22, 59), false},
+		{vnTime(2024, 5, 1, 23, 0), true},
+		{vnTime(2024, 5, 2, 3, 0), true},
+		{vnTime(2024, 5, 2, 5, 59), true},
+		{vnTime(2024, 5, 2, 6, 0), false},
+		// 17:30 UTC is 00:30 in VN
+		{time.Date(2024, 5, 1, 17, 30, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		l, _ := newTestLimiter(t, cfg, tt.at)
+		_, err := l.reserve("a")
+		if blocked := errors.Is(err, ErrQuietHours); blocked != tt.blocked {
+			t.Errorf("%s: blocked = %v (error %v), want %v", tt.at, blocked, err, tt.blocked)
+		}
+	}
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	tests := []struct {
+		q    QuietHours
+		hour int
+		want bool
+	}{
+		{QuietHours{}, 0, false},
+		{QuietHours{Start: 1, End: 5}, 0, false},
+		{QuietHours{Start: 1, End: 5}, 1, true},
+		{QuietHours{Start: 1, End: 5}, 5, false},
+		{QuietHours{Start: 22, End: 2}, 23, true},
+		{QuietHours{Start: 22, End: 2}, 1, true},
+		{QuietHours{Start: 22, End: 2}, 2, false},
+	}
+	for _, tt := range tests {
+		if got := tt.q.Contains(tt.hour); got != tt.want {
+			t.Errorf("%+v.Contains(%d) = %v, want %v", tt.q, tt.hour, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    QuietHours
+		wantErr bool
+	}{
+		{value: "", want: QuietHours{}},
+		{value: "23-6", want: QuietHours{Start: 23, End: 6}},
+		{value: " 1 - 5 ", want: QuietHours{Start: 1, End: 5}},
+		{value: "23", wantErr: true},
+		{value: "24-6", wantErr: true},
+		{value: "a-6", wantErr: true},
+		{value: "1-2-3", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuietHours(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseQuietHours(%q) = %+v, %v", tt.value, got, err)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		httpStatus int
+		reports    int
+		want       time.Duration
+		wantGlobal bool
+	}{
+		{name: "first signal", httpStatus: http.StatusOK, reports: 1, want: 30 * time.Second},
+		{name: "doubles", httpStatus: http.StatusOK, reports: 2, want: time.Minute},
+		{name: "capped at max", httpStatus: http.StatusOK, reports: 5, want: 2 * time.Minute},
+		{name: "http 429 cools the global bucket too", httpStatus: http.StatusTooManyRequests, reports: 1, want: 30 * time.Second, wantGlobal: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := vnTime(2024, 5, 1, 12, 0)
+			l, _ := newTestLimiter(t, testConfig(), start)
+			for i := 0; i < tt.reports; i++ {
+				l.ReportThrottled(context.Background(), "a", tt.httpStatus, 10009)
+			}
+			if got := l.CoolDownUntil("a").Sub(start); got != tt.want {
+				t.Fatalf("cool-down = %s, want %s", got, tt.want)
+			}
+			delay, err := l.reserve("other")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cooling := delay > 0; cooling != tt.wantGlobal {
+				t.Fatalf("other account delayed %s, global cool-down expected: %v", delay, tt.wantGlobal)
+			}
+		})
+	}
+}
+
+func TestReportSuccessResetsBackoff(t *testing.T) {
+	start := vnTime(2024, 5, 1, 12, 0)
+	l, clock := newTestLimiter(t, testConfig(), start)
+	l.ReportThrottled(context.Background(), "a", http.StatusOK, 10009)
+	l.ReportThrottled(context.Background(), "a", http.StatusOK, 10009)
+	l.ReportSuccess(context.Background(), "a")
+
+	// The backoff starts over, the cool-down in progress is kept
+	if l.CoolDownUntil("a").IsZero() {
+		t.Fatal("success cleared the cool-down in progress")
+	}
+	clock.Advance(time.Hour)
+	l.ReportThrottled(context.Background(), "a", http.StatusOK, 10009)
+	if got := l.CoolDownUntil("a").Sub(clock.Now()); got != 30*time.Second {
+		t.Fatalf("cool-down after a success = %s, want the initial 30s", got)
+	}
+}
+
+func TestStatePersistence(t *testing.T) {
+	cfg := testConfig()
+	cfg.StatePath = filepath.Join(t.TempDir(), "state", "ratelimit.json")
+	start := vnTime(2024, 5, 1, 12, 0)
+
+	reload := func() *Limiter {
+		t.Helper()
+		l, _ := newTestLimiter(t, cfg, start)
+		return l
+	}
+
+	l := reload()
+	l.ReportThrottled(context.Background(), "a", http.StatusOK, 10009)
+	l.ReportThrottled(context.Background(), "a", http.StatusOK, 10009)
+
+	restored := reload()
+	if got := restored.buckets["a"].BackoffLevel; got != 2 {
+		t.Fatalf("restored backoff level = %d, want 2", got)
+	}
+	if got := restored.CoolDownUntil("a").Sub(start); got != time.Minute {
+		t.Fatalf("restored cool-down = %s, want 1m", got)
+	}
+
+	// A success must be saved, or a restart brings the old backoff back
+	restored.ReportSuccess(context.Background(), "a")
+	if got := reload().buckets["a"].BackoffLevel; got != 0 {
+		t.Fatalf("backoff level after success and restart = %d, want 0", got)
+	}
+
+	// Wait saves the consumed tokens and the day count
+	l = reload()
+	if err := l.Wait(context.Background(), "b", ActionSearch); err != nil {
+		t.Fatal(err)
+	}
+	if got := reload().buckets["b"].DayCount; got != 1 {
+		t.Fatalf("restored day count = %d, want 1", got)
+	}
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// loadState reads the persisted buckets from path. A missing file is not an error.
+func loadState(path string) (map[string]*bucket, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate limiter state %s: %w", path, err)
+	}
+
+	var buckets map[string]*bucket
+	if err := json.Unmarshal(data, &buckets); err != nil {
+		return nil, fmt.Errorf("failed to decode rate limiter state %s: %w", path, err)
+	}
+	return buckets, nil
+}
+
+// saveState writes the buckets to path atomically (temp file + rename) so a crash never leaves a truncated file.
+func saveState(path string, buckets map[string]*bucket) error {
+	data, err := json.MarshalIndent(buckets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode rate limiter state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create rate limiter state directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write rate limiter state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace rate limiter state: %w", err)
+	}
+	return nil
+}
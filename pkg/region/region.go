@@ -0,0 +1,88 @@
+package region
+
+import (
+	"strings"
+
+	"tto_chromedp/pkg/utils"
+)
+
+// DEFAULT_REGION is the explore page market used when nothing more specific is known ("rest of world").
+const DEFAULT_REGION = "row"
+
+// Config decides which explore page regions are tried for a creator, and in which order.
+type Config struct {
+	// Default is tried after the profile's own region.
+	Default string
+	// Fallbacks are tried, in order, when the previous regions found nothing.
+	Fallbacks []string
+	// Markets lists the regions that have their own market on the explore page.
+	// A profile region outside this set is searched with Default instead.
+	Markets map[string]bool
+}
+
+// LoadConfigFromEnv reads TTO_DEFAULT_REGION, TTO_FALLBACK_REGIONS and TTO_REGION_MARKETS (comma separated).
+func LoadConfigFromEnv() Config {
+	return Config{
+		Default:   Normalize(utils.GetEnvString("TTO_DEFAULT_REGION", DEFAULT_REGION)),
+		Fallbacks: splitList(utils.GetEnvString("TTO_FALLBACK_REGIONS", DEFAULT_REGION)),
+		Markets:   toSet(splitList(utils.GetEnvString("TTO_REGION_MARKETS", "vn,th,id,my,ph,sg,us,gb"))),
+	}
+}
+
+// Candidates returns the ordered, de-duplicated list of regions to search for a profile.
+// The profile's own region goes first when it is a known market, then the default, then the fallbacks.
+// DEFAULT_REGION is always the last resort.
+func (c Config) Candidates(profileRegion string) []string {
+	var ordered []string
+	seen := make(map[string]bool)
+	add := func(r string) {
+		r = Normalize(r)
+		if r == "" || seen[r] {
+			return
+		}
+		seen[r] = true
+		ordered = append(ordered, r)
+	}
+
+	if r := Normalize(profileRegion); r != "" && (r == DEFAULT_REGION || c.Markets[r]) {
+		add(r)
+	}
+	add(c.Default)
+	for _, r := range c.Fallbacks {
+		add(r)
+	}
+	add(DEFAULT_REGION)
+	return ordered
+}
+
+// FromCreator picks the region to remember for a creator from the API fields, preferring the
+// store region over the living region.
+func FromCreator(storeRegion, livingRegion string) string {
+	if r := Normalize(storeRegion); r != "" {
+		return r
+	}
+	return Normalize(livingRegion)
+}
+
+// Normalize lowercases and trims a region code.
+func Normalize(r string) string {
+	return strings.ToLower(strings.TrimSpace(r))
+}
+
+func splitList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if r := Normalize(part); r != "" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
@@ -0,0 +1,89 @@
+package region
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCandidates(t *testing.T) {
+	markets := toSet([]string{"vn", "th"})
+	tests := []struct {
+		name          string
+		cfg           Config
+		profileRegion string
+		want          []string
+	}{
+		{
+			name: "defaults without a profile region",
+			cfg:  Config{Default: DEFAULT_REGION, Fallbacks: []string{DEFAULT_REGION}, Markets: markets},
+			want: []string{"row"},
+		},
+		{
+			name:          "known market goes first",
+			cfg:           Config{Default: DEFAULT_REGION, Fallbacks: []string{DEFAULT_REGION}, Markets: markets},
+			profileRegion: "vn",
+			want:          []string{"vn", "row"},
+		},
+		{
+			name:          "profile region is normalised",
+			cfg:           Config{Default: DEFAULT_REGION, Markets: markets},
+			profileRegion: "  VN ",
+			want:          []string{"vn", "row"},
+		},
+		{
+			name:          "region without a market is searched with the default",
+			cfg:           Config{Default: DEFAULT_REGION, Markets: markets},
+			profileRegion: "fr",
+			want:          []string{"row"},
+		},
+		{
+			name:          "default, fallbacks, then row, without duplicates",
+			cfg:           Config{Default: "th", Fallbacks: []string{"vn", "th", "row"}, Markets: markets},
+			profileRegion: "vn",
+			want:          []string{"vn", "th", "row"},
+		},
+		{
+			name: "row is always the last resort",
+			cfg:  Config{Default: "th", Fallbacks: []string{"vn"}},
+			want: []string{"th", "vn", "row"},
+		},
+		{
+			name: "empty config",
+			want: []string{"row"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.Candidates(tt.profileRegion); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Candidates(%q) = %v, want %v", tt.profileRegion, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromCreator(t *testing.T) {
+	tests := []struct {
+		store, living, want string
+	}{
+		{"VN", "TH", "vn"},
+		{"", "TH", "th"},
+		{"  ", " th ", "th"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := FromCreator(tt.store, tt.living); got != tt.want {
+			t.Errorf("FromCreator(%q, %q) = %q, want %q", tt.store, tt.living, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("TTO_DEFAULT_REGION", " VN ")
+	t.Setenv("TTO_FALLBACK_REGIONS", "th, ,row")
+	t.Setenv("TTO_REGION_MARKETS", "vn,th")
+
+	cfg := LoadConfigFromEnv()
+	if cfg.Default != "vn" || !reflect.DeepEqual(cfg.Fallbacks, []string{"th", "row"}) || !cfg.Markets["th"] || cfg.Markets["us"] {
+		t.Fatalf("LoadConfigFromEnv() = %+v", cfg)
+	}
+}
@@ -0,0 +1,328 @@
+// Package scheduler runs crawl jobs on cron expressions and intervals in VN_TIMEZONE for the serve
+// mode. Jobs never overlap: a job that comes due while another one runs starts right after it, and
+// several missed ticks of the same job collapse into a single run. When several jobs are due, the one
+// that has been waiting longest goes first, so a frequent job cannot starve the others.
+//
+// One-off tasks (the on-demand crawls of the HTTP API) go through the same single worker: they run
+// before the due jobs, and a running job can let them through between two profiles with RunTasks.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/utils"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Job is a named crawl selection run on a schedule.
+type Job struct {
+	Name      string
+	Spec      string
+	Selection models.CrawlSelection
+
+	schedule cron.Schedule
+}
+
+// Task is a one-off piece of work submitted with Submit.
+type Task func(ctx context.Context)
+
+// RunFunc runs a job. It returns nil when the selection was empty and nothing ran.
+type RunFunc func(ctx context.Context, job Job) (*models.CrawlRun, error)
+
+// RunResult is the outcome of the last run of a job.
+type RunResult struct {
+	Job        string         `json:"job"`
+	RunID      string         `json:"run_id,omitempty"`
+	Status     string         `json:"status"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Counts     map[string]int `json:"counts,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// Result statuses that are not a models.CrawlRun status.
+const (
+	RESULT_IDLE  = "idle" // the selection was empty
+	RESULT_ERROR = "error"
+)
+
+// JobStatus is the schedule of a job as shown by the status endpoint.
+type JobStatus struct {
+	Name      string                `json:"name"`
+	Spec      string                `json:"spec"`
+	Selection models.CrawlSelection `json:"selection"`
+	NextRun   time.Time             `json:"next_run"`
+	LastRun   *RunResult            `json:"last_run,omitempty"`
+}
+
+// Status is the state of the scheduler.
+type Status struct {
+	Running      string      `json:"running,omitempty"`
+	RunningSince *time.Time  `json:"running_since,omitempty"`
+	QueuedTasks  int         `json:"queued_tasks"`
+	Jobs         []JobStatus `json:"jobs"`
+	LastRun      *RunResult  `json:"last_run,omitempty"`
+}
+
+// Scheduler triggers the jobs and runs them one at a time.
+type Scheduler struct {
+	location *time.Location
+	run      RunFunc
+	now      func() time.Time
+
+	mu   sync.Mutex
+	jobs []*Job
+	next map[string]time.Time
+	// due holds the time each due job came due, until it runs
+	due          map[string]time.Time
+	tasks        []Task
+	lastRuns     map[string]*RunResult
+	lastRun      *RunResult
+	running      string
+	runningSince time.Time
+	wake         chan struct{}
+}
+
+// New creates a Scheduler running jobs with run.
+func New(run RunFunc) (*Scheduler, error) {
+	location, err := time.LoadLocation(utils.VN_TIMEZONE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load location %s: %w", utils.VN_TIMEZONE, err)
+	}
+	return &Scheduler{
+		location: location,
+		run:      run,
+		now:      time.Now,
+		next:     make(map[string]time.Time),
+		due:      make(map[string]time.Time),
+		lastRuns: make(map[string]*RunResult),
+		wake:     make(chan struct{}, 1),
+	}, nil
+}
+
+// Add registers a job. spec is a standard 5-field cron expression evaluated in VN_TIMEZONE, or
+// "@every <duration>". A spec that never fires (e.g. "0 0 30 2 *") is rejected.
+func (s *Scheduler) Add(job Job) error {
+	schedule, err := cron.ParseStandard(job.Spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for job %s: %w", job.Spec, job.Name, err)
+	}
+	next := schedule.Next(s.now().In(s.location))
+	if next.IsZero() {
+		return fmt.Errorf("schedule %q of job %s never fires", job.Spec, job.Name)
+	}
+	job.schedule = schedule
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.next[job.Name]; ok {
+		return fmt.Errorf("duplicate job %s", job.Name)
+	}
+	s.jobs = append(s.jobs, &job)
+	s.next[job.Name] = next
+	return nil
+}
+
+// Trigger marks a job as due now. It runs as soon as no other job is running.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	if _, ok := s.next[name]; !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("unknown job %s", name)
+	}
+	if _, ok := s.due[name]; !ok {
+		s.due[name] = s.now()
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Submit queues a one-off task. It runs as soon as the worker is free or the running job calls
+// RunTasks.
+func (s *Scheduler) Submit(task Task) {
+	s.mu.Lock()
+	s.tasks = append(s.tasks, task)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RunTasks runs the queued tasks. It must only be called from the worker, i.e. from Run or from the
+// RunFunc of the running job.
+func (s *Scheduler) RunTasks(ctx context.Context) {
+	for {
+		s.mu.Lock()
+		if len(s.tasks) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		task := s.tasks[0]
+		s.tasks = s.tasks[1:]
+		s.mu.Unlock()
+
+		task(ctx)
+	}
+}
+
+// Run triggers and runs the jobs until ctx is done or stop is closed. The job in progress when stop
+// is closed finishes on its own terms (it watches the same shutdown).
+func (s *Scheduler) Run(ctx context.Context, stop <-chan struct{}) {
+	logger := logging.Stage(ctx, "scheduler")
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		s.RunTasks(ctx)
+		job := s.nextDue(s.now())
+		if job != nil {
+			s.execute(ctx, job)
+			continue
+		}
+
+		wait := s.earliest().Sub(s.now())
+		logger.Debug("Waiting for the next job", "in", wait.Round(time.Second))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-stop:
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// nextDue marks the jobs whose time has come as due, and returns the job that has been due longest,
+// the first added on a tie (nil if none). A job already due keeps its original due time.
+func (s *Scheduler) nextDue(now time.Time) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if next := s.next[job.Name]; !now.Before(next) {
+			if _, ok := s.due[job.Name]; !ok {
+				s.due[job.Name] = next
+			}
+			s.next[job.Name] = job.schedule.Next(now.In(s.location))
+		}
+	}
+	var oldest *Job
+	for _, job := range s.jobs {
+		since, ok := s.due[job.Name]
+		if ok && (oldest == nil || since.Before(s.due[oldest.Name])) {
+			oldest = job
+		}
+	}
+	if oldest != nil {
+		delete(s.due, oldest.Name)
+	}
+	return oldest
+}
+
+// earliest returns the next time a job comes due, or an hour from now when there are no jobs.
+func (s *Scheduler) earliest() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.next) == 0 {
+		return s.now().Add(time.Hour)
+	}
+	var earliest time.Time
+	for _, next := range s.next {
+		if earliest.IsZero() || next.Before(earliest) {
+			earliest = next
+		}
+	}
+	return earliest
+}
+
+func (s *Scheduler) execute(ctx context.Context, job *Job) {
+	logger := logging.Stage(ctx, "scheduler")
+	started := s.now()
+	s.mu.Lock()
+	s.running = job.Name
+	s.runningSince = started
+	s.mu.Unlock()
+
+	run, err := s.run(ctx, *job)
+
+	result := &RunResult{Job: job.Name, StartedAt: started, FinishedAt: s.now()}
+	switch {
+	case err != nil:
+		result.Status = RESULT_ERROR
+		result.Error = err.Error()
+		logger.Error("Scheduled job failed", "job", job.Name, "error", err)
+	case run == nil:
+		result.Status = RESULT_IDLE
+		logger.Debug("Scheduled job had nothing to crawl", "job", job.Name)
+	default:
+		result.RunID = run.RunID
+		result.Status = run.Status
+		result.Counts = make(map[string]int)
+		for _, outcome := range models.CrawlOutcomes {
+			if n := run.Count(outcome); n > 0 {
+				result.Counts[outcome] = n
+			}
+		}
+		logger.Info("Scheduled job finished", "job", job.Name, "run", run.RunID, "status", run.Status)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = ""
+	s.runningSince = time.Time{}
+	s.lastRuns[job.Name] = result
+	// An idle poll is not interesting enough to hide the last real run
+	if result.Status != RESULT_IDLE || s.lastRun == nil {
+		s.lastRun = result
+	}
+}
+
+// Status returns the current state of the scheduler.
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := Status{Running: s.running, QueuedTasks: len(s.tasks), LastRun: s.lastRun}
+	if s.running != "" {
+		since := s.runningSince
+		status.RunningSince = &since
+	}
+	for _, job := range s.jobs {
+		status.Jobs = append(status.Jobs, JobStatus{
+			Name:      job.Name,
+			Spec:      job.Spec,
+			Selection: job.Selection,
+			NextRun:   s.next[job.Name].In(s.location),
+			LastRun:   s.lastRuns[job.Name],
+		})
+	}
+	sort.Slice(status.Jobs, func(i, j int) bool { return status.Jobs[i].NextRun.Before(status.Jobs[j].NextRun) })
+	return status
+}
+
+// ServeHTTP serves the status as JSON.
+func (s *Scheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Status())
+}
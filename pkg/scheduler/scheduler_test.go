@@ -0,0 +1,196 @@
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/utils"
+)
+
+// fakeClock is read by the scheduler and moved forward by the jobs, so Run never waits on a timer.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func vnTime(t *testing.T, hour, min, sec int) time.Time {
+	t.Helper()
+	location, err := time.LoadLocation(utils.VN_TIMEZONE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return time.Date(2024, 5, 1, hour, min, sec, 0, location)
+}
+
+func newTestScheduler(t *testing.T, clock *fakeClock, run RunFunc) *Scheduler {
+	t.Helper()
+	s, err := New(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = clock.Now
+	return s
+}
+
+func addJobs(t *testing.T, s *Scheduler, jobs ...Job) {
+	t.Helper()
+	for _, job := range jobs {
+		if err := s.Add(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAddRejectsInvalidSpecs(t *testing.T) {
+	clock := &fakeClock{now: vnTime(t, 1, 0, 0)}
+	s := newTestScheduler(t, clock, nil)
+	for _, spec := range []string{"", "every minute", "0 0 30 2 *", "0 0 31 4 *"} {
+		if err := s.Add(Job{Name: spec, Spec: spec}); err == nil {
+			t.Errorf("Add(%q) accepted", spec)
+		}
+	}
+	addJobs(t, s, Job{Name: "pending", Spec: "@every 1m"})
+	if err := s.Add(Job{Name: "pending", Spec: "@every 5m"}); err == nil {
+		t.Error("duplicate job accepted")
+	}
+}
+
+func TestLongestDueJobRunsFirst(t *testing.T) {
+	clock := &fakeClock{now: vnTime(t, 1, 58, 30)}
+	stop := make(chan struct{})
+	var ran []string
+	s := newTestScheduler(t, clock, func(ctx context.Context, job Job) (*models.CrawlRun, error) {
+		ran = append(ran, job.Name)
+		clock.Advance(10 * time.Minute)
+		if len(ran) == 3 {
+			close(stop)
+		}
+		return nil, nil
+	})
+	// pending is added first and is due at 01:59:30, refresh at 02:00
+	addJobs(t, s, Job{Name: "pending", Spec: "@every 1m"}, Job{Name: "refresh", Spec: "0 2 * * *"})
+	clock.Advance(time.Minute)
+
+	s.Run(context.Background(), stop)
+
+	// pending is due again at 02:00:30 when its first run ends, after refresh
+	want := []string{"pending", "refresh", "pending"}
+	if !reflect.DeepEqual(ran, want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestMissedTicksCollapse(t *testing.T) {
+	start := vnTime(t, 9, 0, 0)
+	clock := &fakeClock{now: start}
+	s := newTestScheduler(t, clock, nil)
+	addJobs(t, s, Job{Name: "pending", Spec: "@every 1m"})
+
+	// Ten ticks went by during a long run: one run, and the next tick counts from now
+	clock.Advance(10 * time.Minute)
+	if job := s.nextDue(clock.Now()); job == nil || job.Name != "pending" {
+		t.Fatalf("nextDue() = %v, want pending", job)
+	}
+	if job := s.nextDue(clock.Now()); job != nil {
+		t.Fatalf("nextDue() = %s again for the missed ticks", job.Name)
+	}
+	if next := s.Status().Jobs[0].NextRun; !next.Equal(start.Add(11 * time.Minute)) {
+		t.Fatalf("next run = %s, want %s", next, start.Add(11*time.Minute))
+	}
+
+	// A trigger on a job already due does not queue a second run
+	if err := s.Trigger("pending"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Trigger("pending"); err != nil {
+		t.Fatal(err)
+	}
+	if job := s.nextDue(clock.Now()); job == nil {
+		t.Fatal("triggered job not due")
+	}
+	if job := s.nextDue(clock.Now()); job != nil {
+		t.Fatal("two triggers ran the job twice")
+	}
+	if err := s.Trigger("missing"); err == nil {
+		t.Fatal("unknown job triggered")
+	}
+}
+
+func TestJobsNeverOverlap(t *testing.T) {
+	clock := &fakeClock{now: vnTime(t, 9, 0, 0)}
+	stop := make(chan struct{})
+	var s *Scheduler
+	var ran []string
+	running := 0
+	s = newTestScheduler(t, clock, func(ctx context.Context, job Job) (*models.CrawlRun, error) {
+		running++
+		defer func() { running-- }()
+		if running > 1 {
+			t.Errorf("%s started while another job was running", job.Name)
+		}
+		ran = append(ran, job.Name)
+		if job.Name == "nightly" {
+			// An operator triggers the report while the crawl runs
+			if err := s.Trigger("report"); err != nil {
+				t.Error(err)
+			}
+			clock.Advance(2 * time.Hour)
+		}
+		if job.Name == "report" {
+			close(stop)
+		}
+		return &models.CrawlRun{RunID: job.Name, Status: models.CrawlRunFinished}, nil
+	})
+	addJobs(t, s, Job{Name: "nightly", Spec: "0 9 * * *"}, Job{Name: "report", Spec: "0 23 * * *"})
+	if err := s.Trigger("nightly"); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Run(context.Background(), stop)
+
+	if want := []string{"nightly", "report"}; !reflect.DeepEqual(ran, want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+	status := s.Status()
+	if status.Running != "" || status.LastRun == nil || status.LastRun.Job != "report" {
+		t.Fatalf("status = %+v", status)
+	}
+}
+
+func TestTasksInterleaveWithJobs(t *testing.T) {
+	clock := &fakeClock{now: vnTime(t, 9, 0, 0)}
+	stop := make(chan struct{})
+	var s *Scheduler
+	var events []string
+	task := func(name string) Task {
+		return func(ctx context.Context) { events = append(events, name) }
+	}
+	s = newTestScheduler(t, clock, func(ctx context.Context, job Job) (*models.CrawlRun, error) {
+		events = append(events, "crawl: first profile")
+		// An on-demand crawl arrives mid-run and goes through between two profiles
+		s.Submit(task("on-demand"))
+		s.RunTasks(ctx)
+		events = append(events, "crawl: second profile")
+		close(stop)
+		return nil, nil
+	})
+	addJobs(t, s, Job{Name: "crawl", Spec: "@every 1h"})
+	s.Submit(task("queued"))
+	clock.Advance(time.Hour)
+
+	s.Run(context.Background(), stop)
+
+	want := []string{"queued", "crawl: first profile", "on-demand", "crawl: second profile"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events %v, want %v", events, want)
+	}
+	if status := s.Status(); status.QueuedTasks != 0 || status.LastRun.Status != RESULT_IDLE {
+		t.Fatalf("status = %+v", status)
+	}
+}
@@ -0,0 +1,95 @@
+// Package shutdown turns SIGINT/SIGTERM into a two-step stop: the first signal asks the run to stop
+// taking new work, and the work context is cancelled once the grace period is over (or on a second
+// signal), which closes the browsers started from it.
+package shutdown
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"tto_chromedp/pkg/logging"
+)
+
+// DEFAULT_GRACE is how long in-flight work may run after the first signal when TTO_SHUTDOWN_GRACE is not set.
+const DEFAULT_GRACE = 60 * time.Second
+
+// FLUSH_TIMEOUT bounds the writes done after the work context is cancelled.
+const FLUSH_TIMEOUT = 30 * time.Second
+
+// Shutdown tracks a requested stop.
+type Shutdown struct {
+	requested context.Context
+	work      context.Context
+	cancel    context.CancelFunc
+	signals   chan os.Signal
+}
+
+// Notify starts listening for SIGINT and SIGTERM. Call Stop once the run is over.
+func Notify(parent context.Context, grace time.Duration) *Shutdown {
+	requested, request := context.WithCancel(parent)
+	work, cancelWork := context.WithCancel(parent)
+	s := &Shutdown{
+		requested: requested,
+		work:      work,
+		cancel: func() {
+			request()
+			cancelWork()
+		},
+		signals: make(chan os.Signal, 2),
+	}
+	signal.Notify(s.signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		logger := logging.FromContext(parent)
+		select {
+		case sig := <-s.signals:
+			logger.Warn("Shutdown requested, finishing in-flight work", "signal", sig.String(), "grace", grace)
+			request()
+		case <-work.Done():
+			return
+		}
+
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+		select {
+		case sig := <-s.signals:
+			logger.Warn("Second signal, stopping now", "signal", sig.String())
+		case <-timer.C:
+			logger.Warn("Grace period over, cancelling in-flight work", "grace", grace)
+		case <-work.Done():
+			return
+		}
+		cancelWork()
+	}()
+	return s
+}
+
+// Context is cancelled when the grace period after a signal is over. Browsers and requests use it.
+func (s *Shutdown) Context() context.Context {
+	return s.work
+}
+
+// Requested is closed on the first signal; loops check it before taking new work.
+func (s *Shutdown) Requested() <-chan struct{} {
+	return s.requested.Done()
+}
+
+// IsRequested reports whether a stop was requested.
+func (s *Shutdown) IsRequested() bool {
+	return s.requested.Err() != nil
+}
+
+// Stop releases the signal handler and cancels the work context.
+func (s *Shutdown) Stop() {
+	signal.Stop(s.signals)
+	s.cancel()
+}
+
+// FlushContext returns a context for the final writes of a run: it keeps the values of ctx but not its
+// cancellation, so pending writes still go through after the work context is cancelled.
+func FlushContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), FLUSH_TIMEOUT)
+}
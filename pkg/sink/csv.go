@@ -0,0 +1,179 @@
+package sink
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tto_chromedp/pkg/distribution"
+	"tto_chromedp/pkg/models"
+)
+
+// CSV_TOP_LOCATIONS is the number of audience countries flattened into columns.
+const CSV_TOP_LOCATIONS = 5
+
+// CSVSink writes one flattened row per creator. The age and gender buckets get a column each, the
+// audience locations the CSV_TOP_LOCATIONS largest, and the content interests and brands are joined
+// into one cell.
+type CSVSink struct {
+	path string
+
+	mu     sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+}
+
+// NewCSVSink creates the directory of path and opens the file for appending. The header is written
+// when the file is new.
+func NewCSVSink(path string) (*CSVSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat export %s: %w", path, err)
+	}
+	s := &CSVSink{path: path, file: file, writer: csv.NewWriter(file)}
+	if info.Size() == 0 {
+		if err := s.writeRow(csvHeader()); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func csvHeader() []string {
+	header := []string{
+		"social_profile_id", "username", "region", "updated_at", "creator_status", "avatar_url",
+		"follower_count", "credit_score", "credit_tier", "is_banned_in_tt",
+		"video_count", "avg_views", "median_views", "engagement_rate", "posts_per_week", "sponsored_ratio", "boosted_ratio",
+	}
+	for _, bucket := range distribution.AGE_BUCKETS {
+		header = append(header, "age_"+string(bucket))
+	}
+	for _, gender := range distribution.GENDERS {
+		header = append(header, "gender_"+string(gender))
+	}
+	for i := 1; i <= CSV_TOP_LOCATIONS; i++ {
+		header = append(header, fmt.Sprintf("location_%d", i), fmt.Sprintf("location_%d_share", i))
+	}
+	return append(header, "content_interests", "brands")
+}
+
+func (s *CSVSink) Write(ctx context.Context, profile models.SocialProfile, user *models.TTOUser) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeRow(csvRow(profile, user))
+}
+
+// writeRow writes and flushes one row. The caller holds mu, or owns s.
+func (s *CSVSink) writeRow(row []string) error {
+	if err := s.writer.Write(row); err != nil {
+		return fmt.Errorf("failed to write export %s: %w", s.path, err)
+	}
+	s.writer.Flush()
+	if err := s.writer.Error(); err != nil {
+		return fmt.Errorf("failed to write export %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func csvRow(profile models.SocialProfile, user *models.TTOUser) []string {
+	row := []string{
+		strconv.Itoa(profile.ID),
+		profile.UserName,
+		user.Region,
+		formatTime(user.UpdatedAt),
+		strconv.Itoa(user.CreatorStatus),
+		user.AvatarURL,
+	}
+
+	if health := user.Health; health != nil {
+		row = append(row, strconv.Itoa(health.FollowerCount), strconv.Itoa(health.CreditScore), strconv.Itoa(health.CreditTier), strconv.FormatBool(health.IsBannedInTT))
+	} else {
+		row = append(row, "", "", "", "")
+	}
+	if kpis := user.KPIs; kpis != nil {
+		row = append(row,
+			strconv.Itoa(kpis.VideoCount),
+			formatFloat(kpis.AvgViews),
+			formatFloat(kpis.MedianViews),
+			formatFloat(kpis.EngagementRate),
+			formatFloat(kpis.PostsPerWeek),
+			formatFloat(kpis.SponsoredRatio),
+			formatFloat(kpis.BoostedRatio),
+		)
+	} else {
+		row = append(row, "", "", "", "", "", "", "")
+	}
+
+	ages := sharesByName(user.AgeDistri)
+	for _, bucket := range distribution.AGE_BUCKETS {
+		row = append(row, ages[string(bucket)])
+	}
+	genders := sharesByName(user.GenderDistri)
+	for _, gender := range distribution.GENDERS {
+		row = append(row, genders[string(gender)])
+	}
+	// The locations are sorted by share by the normalizer
+	for i := 0; i < CSV_TOP_LOCATIONS; i++ {
+		if i < len(user.RegionDistri) {
+			location := user.RegionDistri[i]
+			name := location.ISOCode
+			if name == "" {
+				name = location.Name
+			}
+			row = append(row, name, formatFloat(location.Value))
+		} else {
+			row = append(row, "", "")
+		}
+	}
+
+	interests := make([]string, 0, len(user.CategoryContent))
+	for _, interest := range user.CategoryContent {
+		interests = append(interests, fmt.Sprintf("%s:%s", interest.Name, formatFloat(interest.Percent)))
+	}
+	brands := make([]string, 0, len(user.Brands))
+	for _, brand := range user.Brands {
+		brands = append(brands, brand.Name)
+	}
+	return append(row, strings.Join(interests, ";"), strings.Join(brands, ";"))
+}
+
+func sharesByName(shares models.AudienceShares) map[string]string {
+	values := make(map[string]string, len(shares))
+	for _, share := range shares {
+		values[share.Name] = formatFloat(share.Value)
+	}
+	return values
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (s *CSVSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writer.Flush()
+	return s.file.Close()
+}
@@ -0,0 +1,115 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tto_chromedp/pkg/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+func fullUser() *models.TTOUser {
+	return &models.TTOUser{
+		Region:        "vn",
+		UpdatedAt:     time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC),
+		CreatorStatus: 2,
+		AvatarURL:     "https://cdn.example.com/avatar.jpg",
+		Health:        &models.CreatorHealth{FollowerCount: 125000, CreditScore: 92, CreditTier: 4},
+		KPIs: &models.CreatorKPI{
+			VideoCount:     4,
+			AvgViews:       150,
+			MedianViews:    150,
+			EngagementRate: 0.1167,
+			PostsPerWeek:   1,
+			SponsoredRatio: 0.25,
+			BoostedRatio:   0.5,
+		},
+		// Out of order and partial: the columns follow distribution.AGE_BUCKETS and GENDERS
+		AgeDistri:    models.AudienceShares{{Name: "25-34", Value: 0.45}, {Name: "18-24", Value: 0.55}},
+		GenderDistri: models.AudienceShares{{Name: "female", Value: 0.7}, {Name: "male", Value: 0.3}},
+		// Six buckets: only the five largest get a column
+		RegionDistri: models.AudienceShares{
+			{Name: "Vietnam", ISOCode: "VN", Value: 0.8},
+			{Name: "United States", ISOCode: "US", Value: 0.06},
+			{Name: "Thailand", ISOCode: "TH", Value: 0.05},
+			{Name: "Unresolved", Value: 0.04},
+			{Name: "Japan", ISOCode: "JP", Value: 0.03},
+			{Name: "Other", ISOCode: "OTHER", Value: 0.02},
+		},
+		CategoryContent: models.ContentInterests{{Name: "Beauty", Percent: 0.6}, {Name: "Food & Drinks", Percent: 0.4}},
+		Brands:          models.BrandMentions{{Name: "Brand, Inc."}, {Name: "Other Brand"}},
+	}
+}
+
+// TestCSVGolden writes a full and a bare creator and compares the export with the golden file, so
+// any change of the columns shows up in the diff. Run with -update to accept a change.
+func TestCSVGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exports", "run1.csv")
+	s, err := NewCSVSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := s.Write(ctx, models.SocialProfile{ID: 12, UserName: "alice"}, fullUser()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reopened export is appended to without a second header
+	s, err = NewCSVSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(ctx, models.SocialProfile{ID: 13, UserName: "bob"}, &models.TTOUser{Region: "th"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "export.golden.csv")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("export does not match %s:\n%s", golden, got)
+	}
+}
+
+func TestCSVRowMatchesHeader(t *testing.T) {
+	header := csvHeader()
+	for _, user := range []*models.TTOUser{fullUser(), {}} {
+		if row := csvRow(models.SocialProfile{ID: 1}, user); len(row) != len(header) {
+			t.Fatalf("row has %d columns, header %d", len(row), len(header))
+		}
+	}
+}
+
+func TestCSVSinkReportsWriteErrors(t *testing.T) {
+	s, err := NewCSVSink(filepath.Join(t.TempDir(), "run1.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.file.Close()
+	if err := s.Write(context.Background(), models.SocialProfile{ID: 1}, fullUser()); err == nil {
+		t.Fatal("write to a closed export succeeded")
+	}
+}
@@ -0,0 +1,54 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"tto_chromedp/pkg/models"
+)
+
+// JSONLSink appends one Record per line to a file.
+type JSONLSink struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewJSONLSink creates the directory of path and opens the file for appending.
+func NewJSONLSink(path string) (*JSONLSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export %s: %w", path, err)
+	}
+	return &JSONLSink{path: path, file: file}, nil
+}
+
+func (s *JSONLSink) Write(ctx context.Context, profile models.SocialProfile, user *models.TTOUser) error {
+	line, err := json.Marshal(Record{SocialProfileID: profile.ID, UserName: profile.UserName, TTOUser: user})
+	if err != nil {
+		return fmt.Errorf("failed to encode result of %s: %w", profile.UserName, err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// A single write per line, a crash never leaves half a record behind another one
+	if _, err := s.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write export %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func (s *JSONLSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
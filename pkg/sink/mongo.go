@@ -0,0 +1,31 @@
+package sink
+
+import (
+	"context"
+
+	"tto_chromedp/pkg/metrics"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/mongodb"
+)
+
+// MongoSink upserts one document per profile in a Mongo collection.
+type MongoSink struct {
+	repo mongodb.TTOUserRepository
+}
+
+func NewMongoSink(repo mongodb.TTOUserRepository) *MongoSink {
+	return &MongoSink{repo: repo}
+}
+
+func (s *MongoSink) Write(ctx context.Context, profile models.SocialProfile, user *models.TTOUser) error {
+	if err := s.repo.UpsertTTOUser(ctx, profile, user); err != nil {
+		metrics.DBError(metrics.DB_MONGO, "upsert_tto_user")
+		return err
+	}
+	return nil
+}
+
+// Close does nothing, the client belongs to the caller.
+func (s *MongoSink) Close() error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package sink
+
+import (
+	"context"
+
+	"tto_chromedp/pkg/metrics"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/postgre"
+)
+
+// PostgresSink updates crawler.social_profiles with UpdateTTOUser.
+type PostgresSink struct {
+	repo postgre.SocialProfileRepository
+}
+
+func NewPostgresSink(repo postgre.SocialProfileRepository) *PostgresSink {
+	return &PostgresSink{repo: repo}
+}
+
+func (s *PostgresSink) Write(ctx context.Context, profile models.SocialProfile, user *models.TTOUser) error {
+	if err := s.repo.UpdateTTOUser(ctx, profile.ID, user); err != nil {
+		metrics.DBError(metrics.DB_POSTGRES, "update_tto_user")
+		return err
+	}
+	return nil
+}
+
+// Close does nothing, the connection belongs to the caller.
+func (s *PostgresSink) Close() error {
+	return nil
+}
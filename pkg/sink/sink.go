@@ -0,0 +1,160 @@
+// Package sink writes the parsed result of a crawl to its destinations: the Postgres updater of
+// crawler.social_profiles, JSONL and CSV files for one-off exports, and a Mongo collection. Several
+// sinks run at once through Fanout.
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/mongodb"
+	"tto_chromedp/pkg/postgre"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	SINK_POSTGRES = "postgres"
+	SINK_JSONL    = "jsonl"
+	SINK_CSV      = "csv"
+	SINK_MONGO    = "mongo"
+
+	// DEFAULT_OUTPUT_DIR receives the <run_id>.jsonl and <run_id>.csv exports.
+	DEFAULT_OUTPUT_DIR = "exports"
+)
+
+// ResultSink stores the parsed data of crawled profiles.
+type ResultSink interface {
+	// Write stores the data of one profile. It returns once the data is committed.
+	Write(ctx context.Context, profile models.SocialProfile, user *models.TTOUser) error
+	Close() error
+}
+
+// Record is one line of the JSONL export: the profile followed by the TTOUser fields.
+type Record struct {
+	SocialProfileID int    `json:"social_profile_id"`
+	UserName        string `json:"username"`
+	*models.TTOUser
+}
+
+// Options are what the sinks need besides their name.
+type Options struct {
+	// RunID names the export files.
+	RunID     string
+	OutputDir string
+
+	Postgres postgre.SocialProfileRepository
+
+	Mongo           *mongo.Client
+	MongoDatabase   string
+	MongoCollection string
+}
+
+// ParseNames splits a comma separated sink list (e.g. the -sink flag) and checks the names.
+func ParseNames(list string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		switch name {
+		case SINK_POSTGRES, SINK_JSONL, SINK_CSV, SINK_MONGO:
+		default:
+			return nil, fmt.Errorf("unknown result sink %q (expected %s, %s, %s or %s)", name, SINK_POSTGRES, SINK_JSONL, SINK_CSV, SINK_MONGO)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no result sink selected")
+	}
+	return names, nil
+}
+
+// New opens the sinks of names and returns them behind a Fanout.
+func New(names []string, opts Options) (*Fanout, error) {
+	fanout := &Fanout{}
+	for _, name := range names {
+		var s ResultSink
+		var err error
+		switch name {
+		case SINK_POSTGRES:
+			if opts.Postgres == nil {
+				err = errors.New("the postgres sink needs a database connection")
+				break
+			}
+			s = NewPostgresSink(opts.Postgres)
+		case SINK_JSONL:
+			s, err = NewJSONLSink(filepath.Join(opts.OutputDir, opts.RunID+".jsonl"))
+		case SINK_CSV:
+			s, err = NewCSVSink(filepath.Join(opts.OutputDir, opts.RunID+".csv"))
+		case SINK_MONGO:
+			if opts.Mongo == nil {
+				err = errors.New("the mongo sink needs a MongoDB connection")
+				break
+			}
+			s = NewMongoSink(mongodb.NewTTOUserRepository(opts.Mongo, opts.MongoDatabase, opts.MongoCollection))
+		default:
+			err = fmt.Errorf("unknown result sink %q", name)
+		}
+		if err != nil {
+			fanout.Close()
+			return nil, err
+		}
+		fanout.add(name, s)
+	}
+	return fanout, nil
+}
+
+// Fanout writes every result to all of its sinks.
+type Fanout struct {
+	names []string
+	sinks []ResultSink
+}
+
+func (f *Fanout) add(name string, s ResultSink) {
+	f.names = append(f.names, name)
+	f.sinks = append(f.sinks, s)
+}
+
+// Has reports whether the sink name is part of the fan-out.
+func (f *Fanout) Has(name string) bool {
+	for _, n := range f.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Names lists the sinks in the order they write.
+func (f *Fanout) Names() []string {
+	return append([]string(nil), f.names...)
+}
+
+// Write writes to every sink, even when one fails, and joins their errors.
+func (f *Fanout) Write(ctx context.Context, profile models.SocialProfile, user *models.TTOUser) error {
+	var errs []error
+	for i, s := range f.sinks {
+		if err := s.Write(ctx, profile, user); err != nil {
+			errs = append(errs, fmt.Errorf("%s sink: %w", f.names[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f *Fanout) Close() error {
+	var errs []error
+	for i, s := range f.sinks {
+		if err := s.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s sink: %w", f.names[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,94 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tto_chromedp/pkg/models"
+)
+
+// fakeSink records the profiles it stores and fails with err.
+type fakeSink struct {
+	err    error
+	wrote  []int
+	closed bool
+}
+
+func (s *fakeSink) Write(ctx context.Context, profile models.SocialProfile, user *models.TTOUser) error {
+	s.wrote = append(s.wrote, profile.ID)
+	return s.err
+}
+
+func (s *fakeSink) Close() error {
+	s.closed = true
+	return s.err
+}
+
+func TestParseNames(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{list: "postgres", want: []string{SINK_POSTGRES}},
+		{list: " CSV, jsonl,csv,, mongo ", want: []string{SINK_CSV, SINK_JSONL, SINK_MONGO}},
+		{list: "postgres,kafka", wantErr: true},
+		{list: " , ", wantErr: true},
+		{list: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseNames(tt.list)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseNames(%q) = %v, %v, want %v (error %v)", tt.list, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFanoutWritesEverySink(t *testing.T) {
+	failing := &fakeSink{err: errors.New("disk full")}
+	ok := &fakeSink{}
+	fanout := &Fanout{}
+	fanout.add(SINK_CSV, failing)
+	fanout.add(SINK_JSONL, ok)
+
+	err := fanout.Write(context.Background(), models.SocialProfile{ID: 7}, &models.TTOUser{})
+	if err == nil || !strings.Contains(err.Error(), "csv sink: disk full") {
+		t.Fatalf("Write() = %v, want the csv sink error", err)
+	}
+	// A failing sink does not keep the others from writing
+	if !reflect.DeepEqual(ok.wrote, []int{7}) {
+		t.Fatalf("second sink wrote %v", ok.wrote)
+	}
+	if err := fanout.Close(); err == nil || !ok.closed || !failing.closed {
+		t.Fatalf("Close() = %v, closed %v and %v", err, failing.closed, ok.closed)
+	}
+
+	if !fanout.Has(SINK_JSONL) || fanout.Has(SINK_POSTGRES) {
+		t.Fatal("Has() does not match the sinks")
+	}
+	if names := fanout.Names(); !reflect.DeepEqual(names, []string{SINK_CSV, SINK_JSONL}) {
+		t.Fatalf("Names() = %v", names)
+	}
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	fanout, err := New([]string{SINK_JSONL, SINK_CSV}, Options{RunID: "run1", OutputDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fanout.Close()
+	if names := fanout.Names(); !reflect.DeepEqual(names, []string{SINK_JSONL, SINK_CSV}) {
+		t.Fatalf("Names() = %v", names)
+	}
+
+	// The sinks that need a connection refuse to open without one
+	for _, name := range []string{SINK_POSTGRES, SINK_MONGO} {
+		if _, err := New([]string{SINK_JSONL, name}, Options{RunID: "run2", OutputDir: dir}); err == nil {
+			t.Errorf("%s sink opened without a connection", name)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package taxonomy
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"tto_chromedp/pkg/tto/api"
+)
+
+var (
+	mentionPattern = regexp.MustCompile(`@([\p{L}\p{N}_.]{2,})`)
+	hashtagPattern = regexp.MustCompile(`#([\p{L}\p{N}_]{2,})`)
+)
+
+// genericHashtags are hashtags that mark a video as sponsored or chase reach, and never name a brand.
+var genericHashtags = map[string]bool{
+	"ad": true, "ads": true, "sponsored": true, "sponsor": true, "partner": true, "paidpartnership": true,
+	"collab": true, "gifted": true, "quangcao": true, "taitro": true, "hoptac": true,
+	"fyp": true, "fy": true, "foryou": true, "foryoupage": true, "fypage": true, "xuhuong": true,
+	"trending": true, "trend": true, "viral": true, "tiktok": true, "tiktokshop": true,
+	"tiktokmademebuyit": true, "review": true, "unboxing": true, "sale": true, "freeship": true,
+}
+
+// BrandCandidate is a brand named in the sponsored videos of a creator, with the number of those
+// videos that name it.
+type BrandCandidate struct {
+	Name   string
+	Videos int
+}
+
+// ExtractBrands returns the @mentions and hashtags of the sponsored videos, most frequent first.
+// A video listed several times (recent, popular) is counted once, and generic hashtags are ignored.
+func ExtractBrands(videoLists ...[]api.VideoItem) []BrandCandidate {
+	seenVideos := make(map[string]bool)
+	counts := make(map[string]*BrandCandidate)
+	var order []string
+
+	for _, videos := range videoLists {
+		for _, video := range videos {
+			if !video.IsSponsoredVideo || video.Title == "" {
+				continue
+			}
+			if video.ItemID != "" {
+				if seenVideos[video.ItemID] {
+					continue
+				}
+				seenVideos[video.ItemID] = true
+			}
+
+			inVideo := make(map[string]bool)
+			for _, name := range brandTokens(video.Title) {
+				key := strings.ToLower(name)
+				if inVideo[key] {
+					continue
+				}
+				inVideo[key] = true
+				if c, ok := counts[key]; ok {
+					c.Videos++
+					continue
+				}
+				counts[key] = &BrandCandidate{Name: name, Videos: 1}
+				order = append(order, key)
+			}
+		}
+	}
+
+	result := make([]BrandCandidate, 0, len(order))
+	for _, key := range order {
+		result = append(result, *counts[key])
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Videos > result[j].Videos })
+	return result
+}
+
+// brandTokens returns the mentioned accounts and the non generic hashtags of a title.
+func brandTokens(title string) []string {
+	var tokens []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(title, -1) {
+		tokens = append(tokens, strings.Trim(m[1], "._"))
+	}
+	for _, m := range hashtagPattern.FindAllStringSubmatch(title, -1) {
+		if !genericHashtags[strings.ToLower(m[1])] {
+			tokens = append(tokens, m[1])
+		}
+	}
+	return tokens
+}
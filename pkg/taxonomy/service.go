@@ -0,0 +1,165 @@
+// Package taxonomy maps TTO labels and sponsored video brands onto the CMS taxonomy.
+//
+// Content labels are only resolved through the curated crawler.tto_content_interest_mapping table.
+// Labels missing from it go to crawler.tto_label_review_queue instead of creating new
+// cms.content_interest rows, so the CMS taxonomy only grows through review.
+package taxonomy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"tto_chromedp/pkg/interest"
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/postgre"
+	"tto_chromedp/pkg/tto/api"
+	"tto_chromedp/pkg/utils"
+)
+
+const (
+	LABEL_KIND_CONTENT = "content"
+	DEFAULT_MAX_BRANDS = 10
+)
+
+// Config identifies who the CMS rows created by the crawler belong to.
+type Config struct {
+	// UserID is written as created_by/updated_by of the brands.
+	UserID int
+	// ClientID owns the brands. Brand extraction is disabled while it is 0.
+	ClientID int
+	// MaxBrands keeps only the most mentioned brands of a creator.
+	MaxBrands int
+}
+
+// LoadConfigFromEnv reads TTO_CMS_USER_ID, TTO_CMS_CLIENT_ID and TTO_MAX_BRANDS.
+func LoadConfigFromEnv() Config {
+	return Config{
+		UserID:    utils.GetEnvInt("TTO_CMS_USER_ID", 0),
+		ClientID:  utils.GetEnvInt("TTO_CMS_CLIENT_ID", 0),
+		MaxBrands: utils.GetEnvInt("TTO_MAX_BRANDS", DEFAULT_MAX_BRANDS),
+	}
+}
+
+// Service resolves weighted labels and brand candidates to CMS IDs.
+type Service struct {
+	cfg  Config
+	repo postgre.SocialProfileRepository
+}
+
+// NewService creates a Service. Without a repository (runs without a database) the TTO labels and
+// brands are kept as they are, with no CMS ID.
+func NewService(cfg Config, repo postgre.SocialProfileRepository) *Service {
+	if cfg.ClientID == 0 && repo != nil {
+		slog.Warn("TTO_CMS_CLIENT_ID is not set, sponsored brands will not be saved")
+	}
+	return &Service{cfg: cfg, repo: repo}
+}
+
+// MapContentInterests keeps the weighted labels that have a curated mapping and queues the others for
+// review. The shares of the mapped labels are rescaled to sum to 1, the scale of interest.Weigh. It
+// returns nil when the mapping cannot be read or no label is mapped, so that UpdateTTOUser keeps the
+// stored interests instead of overwriting them with an empty list.
+func (s *Service) MapContentInterests(ctx context.Context, weighted []interest.Weighted) (models.ContentInterests, error) {
+	if len(weighted) == 0 {
+		return nil, nil
+	}
+
+	var result models.ContentInterests
+	if s.repo == nil {
+		for _, w := range weighted {
+			result = append(result, models.ContentInterest{Name: w.Label.LabelName, LabelID: w.Label.LabelID, Percent: w.Percent})
+		}
+		return result, nil
+	}
+
+	labelIDs := make([]string, 0, len(weighted))
+	for _, w := range weighted {
+		labelIDs = append(labelIDs, w.Label.LabelID)
+	}
+	mapping, err := s.repo.GetContentInterestMapping(ctx, labelIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch content interest mapping: %w", err)
+	}
+
+	var unmapped []models.UnmappedLabel
+	total := 0.0
+	for _, w := range weighted {
+		id, ok := mapping[w.Label.LabelID]
+		if !ok {
+			unmapped = append(unmapped, models.UnmappedLabel{LabelID: w.Label.LabelID, LabelName: w.Label.LabelName, Kind: LABEL_KIND_CONTENT})
+			continue
+		}
+		result = append(result, models.ContentInterest{
+			ID:      id,
+			Name:    w.Label.LabelName,
+			LabelID: w.Label.LabelID,
+			Percent: w.Percent,
+		})
+		total += w.Percent
+	}
+
+	if len(unmapped) > 0 {
+		logging.FromContext(ctx).Info("Queueing TTO labels without a content interest mapping for review", "labels", len(unmapped))
+		if err := s.repo.EnqueueUnmappedLabels(ctx, unmapped); err != nil {
+			logging.FromContext(ctx).Error("Failed to queue unmapped labels", "error", err)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+	if total > 0 {
+		sum := 0.0
+		largest := 0
+		for i := range result {
+			result[i].Percent = math.Round(result[i].Percent/total*10000) / 10000
+			sum += result[i].Percent
+			if result[i].Percent > result[largest].Percent {
+				largest = i
+			}
+		}
+		// Same scale and rounding as interest.Weigh: the remainder goes to the largest share
+		result[largest].Percent = math.Round((result[largest].Percent+1-sum)*10000) / 10000
+	}
+	return result, nil
+}
+
+// ResolveBrands extracts the brands of the creator's sponsored videos and upserts them under the
+// configured client (without a repository they get no ID). It returns nil when brand extraction is
+// disabled or nothing was found.
+func (s *Service) ResolveBrands(ctx context.Context, creator api.Creator) (models.BrandMentions, error) {
+	if s.cfg.ClientID == 0 && s.repo != nil {
+		return nil, nil
+	}
+
+	videos := creator.StatisticData.VideoPerformance
+	candidates := ExtractBrands(videos.RecentVideos, videos.PopularVideos, creator.RecentItems)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	if s.cfg.MaxBrands > 0 && len(candidates) > s.cfg.MaxBrands {
+		candidates = candidates[:s.cfg.MaxBrands]
+	}
+
+	ids := map[string]int{}
+	if s.repo != nil {
+		names := make([]string, 0, len(candidates))
+		for _, c := range candidates {
+			names = append(names, c.Name)
+		}
+		var err error
+		ids, err = s.repo.UpsertBrandsAndGetIDs(names, s.cfg.UserID, s.cfg.ClientID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upsert brands: %w", err)
+		}
+	}
+
+	brands := make(models.BrandMentions, 0, len(candidates))
+	for _, c := range candidates {
+		brands = append(brands, models.BrandMention{ID: ids[c.Name], Name: c.Name, Videos: c.Videos})
+	}
+	return brands, nil
+}
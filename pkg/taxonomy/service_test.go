@@ -0,0 +1,135 @@
+package taxonomy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"tto_chromedp/pkg/interest"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/postgre"
+	"tto_chromedp/pkg/tto/api"
+)
+
+// fakeRepo serves a fixed label mapping and records the queued labels; the other repository methods
+// are never called.
+type fakeRepo struct {
+	postgre.SocialProfileRepository
+	mapping    map[string]int
+	mappingErr error
+	queued     []models.UnmappedLabel
+}
+
+func (r *fakeRepo) GetContentInterestMapping(ctx context.Context, labelIDs []string) (map[string]int, error) {
+	if r.mappingErr != nil {
+		return nil, r.mappingErr
+	}
+	found := make(map[string]int)
+	for _, id := range labelIDs {
+		if cmsID, ok := r.mapping[id]; ok {
+			found[id] = cmsID
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeRepo) EnqueueUnmappedLabels(ctx context.Context, labels []models.UnmappedLabel) error {
+	r.queued = append(r.queued, labels...)
+	return nil
+}
+
+func weighted(pairs ...any) []interest.Weighted {
+	var out []interest.Weighted
+	for i := 0; i < len(pairs); i += 2 {
+		id := pairs[i].(string)
+		out = append(out, interest.Weighted{Label: api.Label{LabelID: id, LabelName: "name " + id}, Percent: pairs[i+1].(float64)})
+	}
+	return out
+}
+
+func TestMapContentInterestsReturnsNilToKeepStoredValue(t *testing.T) {
+	tests := []struct {
+		name string
+		repo *fakeRepo
+		in   []interest.Weighted
+	}{
+		{name: "no labels", repo: &fakeRepo{}, in: nil},
+		{name: "mapping error", repo: &fakeRepo{mappingErr: errors.New("db down")}, in: weighted("a", 1.0)},
+		{name: "empty mapping table", repo: &fakeRepo{mapping: map[string]int{}}, in: weighted("a", 0.6, "b", 0.4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := NewService(Config{ClientID: 1}, tt.repo).MapContentInterests(context.Background(), tt.in)
+			if got != nil {
+				t.Fatalf("got %#v, want nil", got)
+			}
+			// nil must reach the database as NULL, so that COALESCE keeps the stored interests
+			if v, err := got.Value(); v != nil || err != nil {
+				t.Fatalf("Value() = %v, %v, want NULL", v, err)
+			}
+		})
+	}
+}
+
+func TestMapContentInterestsRenormalisesMappedShares(t *testing.T) {
+	repo := &fakeRepo{mapping: map[string]int{"a": 10, "b": 20, "c": 30}}
+	got, err := NewService(Config{ClientID: 1}, repo).MapContentInterests(context.Background(),
+		weighted("a", 0.3, "b", 0.2, "x", 0.4, "c", 0.1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]struct {
+		id      int
+		percent float64
+	}{
+		"a": {10, 0.5},
+		"b": {20, 0.3333},
+		"c": {30, 0.1667},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d interests, want %d: %#v", len(got), len(want), got)
+	}
+	sum := 0.0
+	for _, ci := range got {
+		w, ok := want[ci.LabelID]
+		if !ok {
+			t.Fatalf("unexpected label %s", ci.LabelID)
+		}
+		if ci.ID != w.id || math.Abs(ci.Percent-w.percent) > 1e-9 {
+			t.Errorf("label %s = {ID %d, Percent %v}, want {ID %d, Percent %v}", ci.LabelID, ci.ID, ci.Percent, w.id, w.percent)
+		}
+		sum += ci.Percent
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("shares sum to %v, want 1", sum)
+	}
+}
+
+func TestMapContentInterestsQueuesUnmappedLabels(t *testing.T) {
+	repo := &fakeRepo{mapping: map[string]int{"a": 10}}
+	if _, err := NewService(Config{ClientID: 1}, repo).MapContentInterests(context.Background(),
+		weighted("a", 0.5, "x", 0.3, "y", 0.2)); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.queued) != 2 {
+		t.Fatalf("queued %d labels, want 2: %#v", len(repo.queued), repo.queued)
+	}
+	for i, id := range []string{"x", "y"} {
+		label := repo.queued[i]
+		if label.LabelID != id || label.LabelName != "name "+id || label.Kind != LABEL_KIND_CONTENT {
+			t.Errorf("queued[%d] = %#v", i, label)
+		}
+	}
+}
+
+func TestMapContentInterestsWithoutRepository(t *testing.T) {
+	got, err := NewService(Config{}, nil).MapContentInterests(context.Background(), weighted("a", 0.75, "b", 0.25))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != 0 || got[0].Percent != 0.75 || got[1].Percent != 0.25 {
+		t.Fatalf("got %#v, want the weighted labels unchanged", got)
+	}
+}
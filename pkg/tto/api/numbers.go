@@ -0,0 +1,84 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StringInt is an integer the API sends as a JSON string ("12345"). It also accepts a bare number,
+// an empty string and null (both decode to 0).
+type StringInt int64
+
+func (n *StringInt) UnmarshalJSON(data []byte) error {
+	s, err := numberText(data)
+	if err != nil || s == "" {
+		*n = 0
+		return err
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		// Some counters come as "1.2e+06" or "12.0"
+		f, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil {
+			return fmt.Errorf("invalid integer %q: %w", s, err)
+		}
+		v = int64(f)
+	}
+	*n = StringInt(v)
+	return nil
+}
+
+func (n StringInt) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(strconv.FormatInt(int64(n), 10))), nil
+}
+
+// Int64 returns the value as an int64.
+func (n StringInt) Int64() int64 {
+	return int64(n)
+}
+
+// StringFloat is a decimal the API sends as a JSON string ("12.50"), used for the *Rate100K prices.
+// It also accepts a bare number, an empty string and null (both decode to 0).
+type StringFloat float64
+
+func (f *StringFloat) UnmarshalJSON(data []byte) error {
+	s, err := numberText(data)
+	if err != nil || s == "" {
+		*f = 0
+		return err
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid decimal %q: %w", s, err)
+	}
+	*f = StringFloat(v)
+	return nil
+}
+
+func (f StringFloat) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(strconv.FormatFloat(float64(f), 'f', -1, 64))), nil
+}
+
+// Float64 returns the value as a float64.
+func (f StringFloat) Float64() float64 {
+	return float64(f)
+}
+
+// numberText returns the text of a JSON string or number, trimmed. null decodes to "".
+func numberText(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return "", nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(s), nil
+	}
+	return string(data), nil
+}
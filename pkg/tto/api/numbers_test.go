@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStringInt(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    StringInt
+		wantErr bool
+	}{
+		{in: `"12345"`, want: 12345},
+		{in: `12345`, want: 12345},
+		{in: `" 42 "`, want: 42},
+		{in: `"1.2e+06"`, want: 1200000},
+		{in: `"12.0"`, want: 12},
+		{in: `""`, want: 0},
+		{in: `null`, want: 0},
+		{in: `"abc"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got StringInt
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	data, err := json.Marshal(StringInt(250000))
+	if err != nil || string(data) != `"250000"` {
+		t.Errorf("Marshal = %s, %v", data, err)
+	}
+}
+
+func TestStringFloat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    StringFloat
+		wantErr bool
+	}{
+		{in: `"1250.50"`, want: 1250.5},
+		{in: `980`, want: 980},
+		{in: `""`, want: 0},
+		{in: `null`, want: 0},
+		{in: `"n/a"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got StringFloat
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	data, err := json.Marshal(StringFloat(1250.5))
+	if err != nil || string(data) != `"1250.5"` {
+		t.Errorf("Marshal = %s, %v", data, err)
+	}
+}
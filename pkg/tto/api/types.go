@@ -0,0 +1,230 @@
+// Package api holds the types of the TTO (TikTok One creator marketplace) API responses captured
+// by the crawler, shared by the network decoder and the parser.
+package api
+
+// Response is the body of a CreativeOne/MatchPack/MGetCreatorsCard response.
+type Response struct {
+	BaseResp BaseResp  `json:"baseResp"`
+	Creators []Creator `json:"creators"`
+
+	// Unknown holds the top-level fields that are not modelled above.
+	Unknown UnknownFields `json:"-"`
+}
+
+// BaseResp is the status envelope of every TTO response. StatusCode 0 means success.
+type BaseResp struct {
+	StatusCode    int    `json:"StatusCode"`
+	StatusMessage string `json:"StatusMessage"`
+}
+
+// Creator is one creator card.
+type Creator struct {
+	AioCreatorID   string         `json:"aioCreatorID"`
+	ContentLabels  []Label        `json:"contentLabels"`
+	CreatorProfile CreatorProfile `json:"creatorProfile"`
+	CreatorTTInfo  CreatorTTInfo  `json:"creatorTTInfo"`
+	CreatorType    int            `json:"creatorType"`
+	CreditScore    CreditScore    `json:"creditScore"`
+	DisplayType    int            `json:"displayType"`
+	EsData         EsData         `json:"esData"`
+	IndustryLabels []Label        `json:"industryLabels"`
+	IsCarveOut     bool           `json:"isCarveOut"`
+	PriceIndex     int            `json:"priceIndex"`
+	RecentItems    []VideoItem    `json:"recentItems"`
+	RiskInfo       RiskInfo       `json:"riskInfo"`
+	StatisticData  StatisticData  `json:"statisticData"`
+	TtUID          string         `json:"ttUID"`
+
+	Unknown UnknownFields `json:"-"`
+}
+
+// Label is a content or industry label attached to a creator.
+type Label struct {
+	LabelID   string `json:"labelID"`
+	LabelName string `json:"labelName"`
+}
+
+// CreatorProfile is the self-declared part of the creator profile.
+type CreatorProfile struct {
+	Price              struct{} `json:"price"`
+	SpokenLanguageList []string `json:"spokenLanguageList"`
+}
+
+// CreatorTTInfo is the TikTok account information of a creator.
+type CreatorTTInfo struct {
+	AdCreativeClass     int         `json:"adCreativeClass"`
+	AioCreatorID        string      `json:"aioCreatorID"`
+	AvatarURI           string      `json:"avatarURI"`
+	AvatarURL           string      `json:"avatarURL"`
+	AvatarURLList       []ImageURL  `json:"avatarURLList"`
+	Bio                 string      `json:"bio"`
+	BrandedContentClass int         `json:"brandedContentClass"`
+	Categories          []int       `json:"categories"`
+	CreditScore         CreditScore `json:"creditScore"`
+	DataVDCRegion       int         `json:"dataVDCRegion"`
+	DisplayStatus       int         `json:"displayStatus"`
+	FollowerCnt         int         `json:"followerCnt"`
+	HandleName          string      `json:"handleName"`
+	IsBannedInTT        bool        `json:"isBannedInTT"`
+	IsRegisteredAIO     bool        `json:"isRegisteredAIO"`
+	IsTest              bool        `json:"isTest"`
+	LivingRegion        string      `json:"livingRegion"`
+	NickName            string      `json:"nickName"`
+	RiskInfo            RiskInfo    `json:"riskInfo"`
+	StoreRegion         string      `json:"storeRegion"`
+	TtUID               string      `json:"ttUID"`
+
+	Unknown UnknownFields `json:"-"`
+}
+
+// ImageURL is one rendition of an image (avatar or video cover).
+type ImageURL struct {
+	Format   string `json:"format"`
+	ImageURL string `json:"imageUrl"`
+}
+
+// CreditScore is the TTO credit score of a creator.
+type CreditScore struct {
+	AioCreatorID    string `json:"aioCreatorID"`
+	CurrentScore    int    `json:"currentScore"`
+	CurrentTier     int    `json:"currentTier"`
+	ScoreLowerLimit int    `json:"scoreLowerLimit"`
+	ScoreUpperLimit int    `json:"scoreUpperLimit"`
+}
+
+// RiskInfo identifies the creator in the risk system.
+type RiskInfo struct {
+	CreatorID string `json:"creatorID"`
+}
+
+// EsData is the search-index view of a creator.
+type EsData struct {
+	AppearOnSearchSetting bool  `json:"appearOnSearchSetting"`
+	Categories            []int `json:"categories"`
+	Price                 Price `json:"price"`
+	Status                int   `json:"status"`
+}
+
+// Price is the rate card of a creator, per 100k views.
+type Price struct {
+	Currency                    string      `json:"currency"`
+	RecommendRate100K           StringFloat `json:"recommendRate100k"`
+	StartingRate100K            StringFloat `json:"startingRate100k"`
+	StoreRegionCurrency         string      `json:"storeRegionCurrency"`
+	StoreRegionStartingRate100K StringFloat `json:"storeRegionStartingRate100k"`
+}
+
+// VideoItem is a video of a creator, as listed in recentItems, popularVideos and recentVideos.
+type VideoItem struct {
+	Comment          int        `json:"comment"`
+	CoverURL         string     `json:"coverURL"`
+	CoverURLList     []ImageURL `json:"coverURLList"`
+	CreateTime       StringInt  `json:"createTime"`
+	Heart            int        `json:"heart"`
+	IsBoosted        bool       `json:"isBoosted"`
+	IsSponsoredVideo bool       `json:"isSponsoredVideo"`
+	ItemID           string     `json:"itemID"`
+	Share            int        `json:"share"`
+	Title            string     `json:"title"`
+	VideoURL         string     `json:"videoURL"`
+	Views            StringInt  `json:"views"`
+}
+
+// StatisticData groups the audience and performance statistics of a creator.
+type StatisticData struct {
+	Algo                 Algo                 `json:"algo"`
+	FollowerCountHistory FollowerCountHistory `json:"followerCountHistory"`
+	FollowerDistriData   FollowerDistriData   `json:"followerDistriData"`
+	OverallPerformance   OverallPerformance   `json:"overallPerformance"`
+	TtBasicInfo          TtBasicInfo          `json:"ttBasicInfo"`
+	VideoPerformance     VideoPerformance     `json:"videoPerformance"`
+
+	Unknown UnknownFields `json:"-"`
+}
+
+// Algo holds the algorithmic detections on the creator content.
+type Algo struct {
+	ContentLanguage []string `json:"contentLanguage"`
+}
+
+// TtBasicInfo holds basic TikTok app information.
+type TtBasicInfo struct {
+	AppLanguage []string `json:"appLanguage"`
+}
+
+// FollowerCountHistory is the follower count time series of a creator.
+type FollowerCountHistory struct {
+	FollowerCount      []FollowerTrend `json:"followerCount"`
+	FollowerGrowthRate []GrowthRate    `json:"followerGrowthRate"`
+}
+
+// FollowerTrend is the follower count on a day (Date is YYYYMMDD).
+type FollowerTrend struct {
+	Count int    `json:"count"`
+	Date  string `json:"date"`
+}
+
+// GrowthRate is the follower growth rate on a day (Date is YYYYMMDD).
+type GrowthRate struct {
+	Date string  `json:"date"`
+	Rate float64 `json:"rate"`
+}
+
+// FollowerDistriData holds the follower audience distributions.
+type FollowerDistriData struct {
+	Active      []ActiveDistri      `json:"active"`
+	Age         []AgeDistri         `json:"age"`
+	DeviceBrand []DeviceBrandDistri `json:"deviceBrand"`
+	Gender      []GenderDistri      `json:"gender"`
+	Region      []RegionDistri      `json:"region"`
+}
+
+type ActiveDistri struct {
+	Active string  `json:"active"`
+	Ratio  float64 `json:"ratio"`
+}
+
+type AgeDistri struct {
+	AgeInterval string  `json:"ageInterval"`
+	Ratio       float64 `json:"ratio"`
+}
+
+type DeviceBrandDistri struct {
+	DeviceBrand string  `json:"deviceBrand"`
+	Ratio       float64 `json:"ratio"`
+}
+
+type GenderDistri struct {
+	Gender string  `json:"gender"`
+	Ratio  float64 `json:"ratio"`
+}
+
+type RegionDistri struct {
+	Country string  `json:"country"`
+	Ratio   float64 `json:"ratio"`
+}
+
+// OverallPerformance holds the creator performance and the benchmarks of comparable creators.
+type OverallPerformance struct {
+	AvgSixSecondsViewsBenchMarkViews float64 `json:"avgSixSecondsViewsBenchMarkViews"`
+	AvgSixSecondsViewsRate           float64 `json:"avgSixSecondsViewsRate"`
+	AvgSixSecondsViewsRateRank       float64 `json:"avgSixSecondsViewsRateRank"`
+	EngagementRate                   float64 `json:"engagementRate"`
+	EngagementRateBenchMark          float64 `json:"engagementRateBenchMark"`
+	EngagementRateRank               float64 `json:"engagementRateRank"`
+	FollowerCount                    int     `json:"followerCount"`
+	FollowerTier                     int     `json:"followerTier"`
+	FollowersGrowthRate              float64 `json:"followersGrowthRate"`
+	FollowersGrowthRateRank          float64 `json:"followersGrowthRateRank"`
+	MedianBenchMarkViews             int     `json:"medianBenchMarkViews"`
+	MedianViews                      int     `json:"medianViews"`
+	MedianViewsRank                  float64 `json:"medianViewsRank"`
+	VideoCompleteRate                float64 `json:"videoCompleteRate"`
+	VideoCompleteRateRank            float64 `json:"videoCompleteRateRank"`
+}
+
+// VideoPerformance lists the popular and recent videos of a creator.
+type VideoPerformance struct {
+	PopularVideos []VideoItem `json:"popularVideos"`
+	RecentVideos  []VideoItem `json:"recentVideos"`
+}
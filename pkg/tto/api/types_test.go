@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+func loadResponse(t *testing.T, name string) Response {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("decode %s: %v", name, err)
+	}
+	return resp
+}
+
+// TestGoldenResponses decodes the saved payloads and compares their re-encoding with the golden files,
+// so any change of the decoded shape shows up in the diff. Run with -update to accept a change.
+func TestGoldenResponses(t *testing.T) {
+	for _, name := range []string{"creators_card.json", "creators_rate_limited.json"} {
+		t.Run(name, func(t *testing.T) {
+			resp := loadResponse(t, name)
+			got, err := json.MarshalIndent(resp, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", name[:len(name)-len(".json")]+".golden.json")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s does not match %s:\n%s", name, golden, got)
+			}
+		})
+	}
+}
+
+func TestDecodeStringNumbers(t *testing.T) {
+	resp := loadResponse(t, "creators_card.json")
+	if len(resp.Creators) != 1 {
+		t.Fatalf("got %d creators, want 1", len(resp.Creators))
+	}
+	creator := resp.Creators[0]
+
+	price := creator.EsData.Price
+	if price.RecommendRate100K != 1250.5 || price.StartingRate100K != 980 || price.StoreRegionStartingRate100K != 24500000 {
+		t.Errorf("prices = %+v", price)
+	}
+
+	recent := creator.StatisticData.VideoPerformance.RecentVideos
+	if len(recent) != 2 {
+		t.Fatalf("got %d recent videos, want 2", len(recent))
+	}
+	if recent[0].Views != 250000 || recent[0].CreateTime != 1718000000 {
+		t.Errorf("recent[0] views=%d createTime=%d", recent[0].Views, recent[0].CreateTime)
+	}
+	// An empty string and null decode to 0
+	if recent[1].Views != 0 || recent[1].CreateTime != 0 {
+		t.Errorf("recent[1] views=%d createTime=%d, want 0", recent[1].Views, recent[1].CreateTime)
+	}
+
+	popular := creator.StatisticData.VideoPerformance.PopularVideos[0]
+	// A bare number and an exponent are accepted as well
+	if popular.CreateTime != 1715000000 || popular.Views != 1200000 {
+		t.Errorf("popular views=%d createTime=%d", popular.Views, popular.CreateTime)
+	}
+	if creator.RecentItems[0].Views.Int64() != 250000 {
+		t.Errorf("recentItems[0].views = %d", creator.RecentItems[0].Views)
+	}
+}
+
+func TestDecodeStatus(t *testing.T) {
+	resp := loadResponse(t, "creators_rate_limited.json")
+	if resp.BaseResp.StatusCode != 10009 || resp.BaseResp.StatusMessage != "request too frequent" {
+		t.Errorf("baseResp = %+v", resp.BaseResp)
+	}
+	if len(resp.Creators) != 0 {
+		t.Errorf("got %d creators, want none", len(resp.Creators))
+	}
+}
+
+func TestRoundTripSharedTypes(t *testing.T) {
+	resp := loadResponse(t, "creators_card.json")
+	creator := resp.Creators[0]
+
+	items := []VideoItem{creator.RecentItems[0], creator.StatisticData.VideoPerformance.PopularVideos[0], creator.StatisticData.VideoPerformance.RecentVideos[1]}
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded VideoItem
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, item) {
+			t.Errorf("video item round trip:\n got %+v\nwant %+v", decoded, item)
+		}
+	}
+
+	for _, score := range []CreditScore{creator.CreditScore, creator.CreatorTTInfo.CreditScore} {
+		data, err := json.Marshal(score)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded CreditScore
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded != score {
+			t.Errorf("credit score round trip: got %+v, want %+v", decoded, score)
+		}
+	}
+	if creator.CreditScore.CurrentScore != 98 || creator.CreditScore.CurrentTier != 4 {
+		t.Errorf("credit score = %+v", creator.CreditScore)
+	}
+}
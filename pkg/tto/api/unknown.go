@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// UnknownFields holds the raw JSON of the object keys that have no matching struct field, so schema
+// drift on the TTO side shows up in the logs instead of being dropped silently.
+type UnknownFields map[string]json.RawMessage
+
+// Keys returns the unknown keys, sorted.
+func (u UnknownFields) Keys() []string {
+	keys := make([]string, 0, len(u))
+	for k := range u {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (r *Response) UnmarshalJSON(data []byte) error {
+	type plain Response
+	unknown, err := decodeCapturingUnknown(data, (*plain)(r))
+	r.Unknown = unknown
+	return err
+}
+
+func (c *Creator) UnmarshalJSON(data []byte) error {
+	type plain Creator
+	unknown, err := decodeCapturingUnknown(data, (*plain)(c))
+	c.Unknown = unknown
+	return err
+}
+
+func (c *CreatorTTInfo) UnmarshalJSON(data []byte) error {
+	type plain CreatorTTInfo
+	unknown, err := decodeCapturingUnknown(data, (*plain)(c))
+	c.Unknown = unknown
+	return err
+}
+
+func (s *StatisticData) UnmarshalJSON(data []byte) error {
+	type plain StatisticData
+	unknown, err := decodeCapturingUnknown(data, (*plain)(s))
+	s.Unknown = unknown
+	return err
+}
+
+// UnknownFieldPaths lists the dotted paths of every unknown field captured in the response,
+// e.g. "creators[].statisticData.newMetric". Paths are de-duplicated across creators.
+func (r *Response) UnknownFieldPaths() []string {
+	set := make(map[string]bool)
+	add := func(prefix string, u UnknownFields) {
+		for _, k := range u.Keys() {
+			set[prefix+k] = true
+		}
+	}
+	add("", r.Unknown)
+	for _, c := range r.Creators {
+		add("creators[].", c.Unknown)
+		add("creators[].creatorTTInfo.", c.CreatorTTInfo.Unknown)
+		add("creators[].statisticData.", c.StatisticData.Unknown)
+	}
+
+	paths := make([]string, 0, len(set))
+	for p := range set {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// decodeCapturingUnknown decodes data into v (a pointer to a struct without a custom unmarshaler)
+// and returns the object keys that do not match any of its json tags.
+func decodeCapturingUnknown(data []byte, v interface{}) (UnknownFields, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		// Not an object (e.g. null); nothing to capture
+		return nil, nil
+	}
+
+	known := knownKeys(reflect.TypeOf(v).Elem())
+	var unknown UnknownFields
+	for k, msg := range raw {
+		if known[strings.ToLower(k)] {
+			continue
+		}
+		if unknown == nil {
+			unknown = make(UnknownFields)
+		}
+		unknown[k] = msg
+	}
+	return unknown, nil
+}
+
+var knownKeysCache sync.Map // reflect.Type -> map[string]bool
+
+// knownKeys returns the lower-cased json names of the fields of t. encoding/json matches keys
+// case-insensitively, so the comparison is case-insensitive too.
+func knownKeys(t reflect.Type) map[string]bool {
+	if cached, ok := knownKeysCache.Load(t); ok {
+		return cached.(map[string]bool)
+	}
+	keys := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if idx := strings.Index(tag, ","); idx >= 0 {
+				tag = tag[:idx]
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		keys[strings.ToLower(name)] = true
+	}
+	knownKeysCache.Store(t, keys)
+	return keys
+}
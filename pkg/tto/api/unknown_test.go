@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestUnknownFieldPaths(t *testing.T) {
+	resp := loadResponse(t, "creators_card.json")
+	want := []string{
+		"creators[].aiSummary",
+		"creators[].creatorTTInfo.verifiedBadge",
+		"creators[].statisticData.liveStats",
+		"extra",
+	}
+	if got := resp.UnknownFieldPaths(); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnknownFieldPaths() = %v, want %v", got, want)
+	}
+	if got := string(resp.Creators[0].Unknown["aiSummary"]); got != `"new field"` {
+		t.Errorf("captured aiSummary = %s", got)
+	}
+}
+
+func TestUnknownFieldsIgnoreKeyCase(t *testing.T) {
+	// encoding/json matches keys case-insensitively, so a re-cased known key is not unknown
+	var creator Creator
+	if err := json.Unmarshal([]byte(`{"TTUID": "1", "AioCreatorId": "2"}`), &creator); err != nil {
+		t.Fatal(err)
+	}
+	if creator.TtUID != "1" || creator.AioCreatorID != "2" {
+		t.Errorf("creator = %+v", creator)
+	}
+	if len(creator.Unknown) != 0 {
+		t.Errorf("Unknown = %v, want none", creator.Unknown.Keys())
+	}
+}
+
+func TestNoUnknownFields(t *testing.T) {
+	resp := loadResponse(t, "creators_rate_limited.json")
+	if paths := resp.UnknownFieldPaths(); len(paths) != 0 {
+		t.Errorf("UnknownFieldPaths() = %v, want none", paths)
+	}
+}
@@ -3,3 +3,8 @@ package utils
 const COUNTRY_UNKNOWN = "Unknown"
 const VN_TIMEZONE = "Asia/Ho_Chi_Minh"
 const DATE_FORMAT_YYYYMMDD = "2006-01-02"
+
+// Values of crawler.social_profiles.tiktokshop_creator_status.
+const TTO_CREATOR_STATUS_PENDING = -1
+const TTO_CREATOR_STATUS_DONE = 1
+const TTO_CREATOR_STATUS_SKIPPED = 2
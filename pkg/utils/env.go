@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetEnvString returns the environment value for key, or fallback when it is unset or blank.
+func GetEnvString(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// GetEnvInt returns the environment value for key parsed as an int, or fallback when it is unset or invalid.
+func GetEnvInt(key string, fallback int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		slog.Warn("Invalid integer in environment, using default", "key", key, "value", v, "default", fallback)
+		return fallback
+	}
+	return n
+}
+
+// GetEnvFloat returns the environment value for key parsed as a float64, or fallback when it is unset or invalid.
+func GetEnvFloat(key string, fallback float64) float64 {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		slog.Warn("Invalid number in environment, using default", "key", key, "value", v, "default", fallback)
+		return fallback
+	}
+	return f
+}
+
+// GetEnvDuration returns the environment value for key parsed with time.ParseDuration, or fallback when it is unset or invalid.
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		slog.Warn("Invalid duration in environment, using default", "key", key, "value", v, "default", fallback)
+		return fallback
+	}
+	return d
+}
+
+// GetEnvBool returns the environment value for key parsed as a bool, or fallback when it is unset or invalid.
+func GetEnvBool(key string, fallback bool) bool {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		slog.Warn("Invalid boolean in environment, using default", "key", key, "value", v, "default", fallback)
+		return fallback
+	}
+	return b
+}
@@ -0,0 +1,22 @@
+package utils
+
+import "strings"
+
+// handlePrefixes are stripped, in order, from the handles pasted as profile URLs.
+var handlePrefixes = []string{"https://", "http://", "www.", "m.", "tiktok.com/"}
+
+// NormalizeHandle turns a TikTok handle as people paste it ("@Name", "tiktok.com/@name",
+// "https://www.tiktok.com/@name/video/123?lang=en") into the bare lowercase handle. It returns ""
+// when nothing is left.
+func NormalizeHandle(raw string) string {
+	handle := strings.ToLower(strings.TrimSpace(raw))
+	for _, prefix := range handlePrefixes {
+		handle = strings.TrimPrefix(handle, prefix)
+	}
+	handle = strings.TrimPrefix(handle, "@")
+	// Anything after the handle is a video path, a query or a fragment
+	if i := strings.IndexAny(handle, "/?#"); i >= 0 {
+		handle = handle[:i]
+	}
+	return strings.TrimSpace(handle)
+}
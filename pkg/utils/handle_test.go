@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestNormalizeHandle(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"alice", "alice"},
+		{"@X", "x"},
+		{"  @Alice.Nguyen_92 ", "alice.nguyen_92"},
+		{"tiktok.com/@alice", "alice"},
+		{"m.tiktok.com/@x", "x"},
+		{"https://m.tiktok.com/@x", "x"},
+		{"https://www.tiktok.com/@x/video/1?lang=en", "x"},
+		{"http://www.tiktok.com/@Alice?is_from_webapp=1", "alice"},
+		{"www.tiktok.com/@alice#top", "alice"},
+		{"@", ""},
+		{"   ", ""},
+		{"https://www.tiktok.com/", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeHandle(tt.raw); got != tt.want {
+			t.Errorf("NormalizeHandle(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
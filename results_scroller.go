@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"tto_chromedp/pkg/logging"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// ROW_DATA_INDEX_ELEM selects one row of the virtualised results list by its data-index
+	ROW_DATA_INDEX_ELEM = `div[data-index='%d']`
+	// CREATOR_NAME_SELECTOR is the creator name element inside a result card
+	CREATOR_NAME_SELECTOR = SECTION_LOCATOR + " div div " + CREATOR_NAME_ELEM
+
+	// Fraction of the list viewport scrolled on each step, small enough that no row is skipped
+	SCROLL_STEP_RATIO = 0.8
+)
+
+// ResultCard is the structured content of one row of the explore results list.
+type ResultCard struct {
+	DataIndex int    `json:"data_index"`
+	Name      string `json:"name"`
+	Text      string `json:"text"`
+}
+
+// visibleRowsResult is what the row extraction script returns on each step.
+type visibleRowsResult struct {
+	Rows     []ResultCard `json:"rows"`
+	AtBottom bool         `json:"atBottom"`
+	NoResult bool         `json:"noResult"`
+}
+
+// ResultsScroller walks the virtualised results list (SEARCH_RESULTS_BODY) step by step. Only the rows
+// near the viewport exist in the DOM, so every step records the data-index values it has not seen yet.
+// When the list bottom is reached it waits for the next MGetCreatorsCard page before giving up.
+type ResultsScroller struct {
+	ctx         context.Context
+	pageLoaded  chan struct{}
+	Settle      time.Duration
+	PageTimeout time.Duration
+}
+
+// NewResultsScroller creates a scroller for the tab of ctx. It listens for responses matching
+// urlPattern to know when the next page of the list has been loaded.
+func NewResultsScroller(ctx context.Context, urlPattern string) *ResultsScroller {
+	s := &ResultsScroller{
+		ctx:         ctx,
+		pageLoaded:  make(chan struct{}, 1),
+		Settle:      1500 * time.Millisecond,
+		PageTimeout: 10 * time.Second,
+	}
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		if ev, ok := ev.(*network.EventResponseReceived); ok && strings.Contains(ev.Response.URL, urlPattern) {
+			select {
+			case s.pageLoaded <- struct{}{}:
+			default:
+			}
+		}
+	})
+	return s
+}
+
+// Collect scrolls from the top of the list and returns the cards of every row, ordered by data-index.
+// It stops after maxItems rows (0 means no limit) or when the list is exhausted.
+func (s *ResultsScroller) Collect(maxItems int) ([]ResultCard, error) {
+	if err := chromedp.Run(s.ctx,
+		network.Enable(),
+		chromedp.WaitVisible(SEARCH_RESULTS_BODY, chromedp.ByQuery),
+	); err != nil {
+		return nil, fmt.Errorf("results list not visible: %w", err)
+	}
+	if err := s.scrollTo(0); err != nil {
+		return nil, err
+	}
+	// Drop page signals left over from the search that rendered the list
+	select {
+	case <-s.pageLoaded:
+	default:
+	}
+
+	seen := make(map[int]ResultCard)
+	for {
+		rows, err := s.visibleRows()
+		if err != nil {
+			return nil, err
+		}
+		if rows.NoResult {
+			return nil, nil
+		}
+		added := 0
+		for _, row := range rows.Rows {
+			if _, ok := seen[row.DataIndex]; !ok {
+				seen[row.DataIndex] = row
+				added++
+			}
+		}
+		if maxItems > 0 && len(seen) >= maxItems {
+			break
+		}
+
+		if rows.AtBottom {
+			// The bottom of the list triggers the next page request, wait for it to land
+			if !s.waitForPage() && added == 0 {
+				logging.FromContext(s.ctx).Debug("Results list exhausted", "rows", len(seen))
+				break
+			}
+		}
+		if err := s.step(); err != nil {
+			return nil, err
+		}
+	}
+
+	cards := make([]ResultCard, 0, len(seen))
+	for _, card := range seen {
+		cards = append(cards, card)
+	}
+	sort.Slice(cards, func(i, j int) bool { return cards[i].DataIndex < cards[j].DataIndex })
+	if maxItems > 0 && len(cards) > maxItems {
+		cards = cards[:maxItems]
+	}
+	return cards, nil
+}
+
+// Reveal scrolls the list until the row with the given data-index is rendered, so it can be clicked.
+func (s *ResultsScroller) Reveal(dataIndex int) error {
+	if err := s.scrollTo(0); err != nil {
+		return err
+	}
+	for {
+		rows, err := s.visibleRows()
+		if err != nil {
+			return err
+		}
+		for _, row := range rows.Rows {
+			if row.DataIndex == dataIndex {
+				return chromedp.Run(s.ctx, chromedp.ScrollIntoView(fmt.Sprintf(ROW_DATA_INDEX_ELEM, dataIndex), chromedp.ByQuery))
+			}
+		}
+		if rows.AtBottom {
+			return fmt.Errorf("row %d not found in results list", dataIndex)
+		}
+		if err := s.step(); err != nil {
+			return err
+		}
+	}
+}
+
+// CardNameSelector returns the selector of the creator name link inside the row with the given data-index.
+func CardNameSelector(dataIndex int) string {
+	return fmt.Sprintf("%s %s %s", SEARCH_RESULTS_BODY, fmt.Sprintf(ROW_DATA_INDEX_ELEM, dataIndex), CREATOR_NAME_SELECTOR)
+}
+
+func (s *ResultsScroller) visibleRows() (visibleRowsResult, error) {
+	var result visibleRowsResult
+	script := fmt.Sprintf(`(() => {
+		const list = document.querySelector(%q);
+		if (!list) { return {rows: [], atBottom: true, noResult: true}; }
+		const rows = Array.from(list.querySelectorAll('[data-index]')).map(row => {
+			const name = row.querySelector(%q);
+			return {
+				data_index: parseInt(row.getAttribute('data-index'), 10),
+				name: name ? name.innerText.trim() : '',
+				text: row.innerText.trim(),
+			};
+		});
+		const scroller = list.scrollHeight > list.clientHeight ? list : document.scrollingElement;
+		const atBottom = scroller.scrollTop + scroller.clientHeight >= scroller.scrollHeight - 2;
+		const noResult = rows.length === 0 && list.innerText.includes(%q);
+		return {rows, atBottom, noResult};
+	})()`, SEARCH_RESULTS_BODY, CREATOR_NAME_SELECTOR, NO_RESULTS_TEXT)
+
+	if err := chromedp.Run(s.ctx, chromedp.Evaluate(script, &result)); err != nil {
+		return result, fmt.Errorf("failed to read results rows: %w", err)
+	}
+	return result, nil
+}
+
+func (s *ResultsScroller) step() error {
+	script := fmt.Sprintf(`(() => {
+		const list = document.querySelector(%q);
+		const scroller = list && list.scrollHeight > list.clientHeight ? list : document.scrollingElement;
+		scroller.scrollTop += Math.max(1, scroller.clientHeight * %v);
+	})()`, SEARCH_RESULTS_BODY, SCROLL_STEP_RATIO)
+	if err := chromedp.Run(s.ctx, chromedp.Evaluate(script, nil), chromedp.Sleep(s.Settle)); err != nil {
+		return fmt.Errorf("failed to scroll results list: %w", err)
+	}
+	return nil
+}
+
+func (s *ResultsScroller) scrollTo(top int) error {
+	script := fmt.Sprintf(`(() => {
+		const list = document.querySelector(%q);
+		const scroller = list && list.scrollHeight > list.clientHeight ? list : document.scrollingElement;
+		scroller.scrollTop = %d;
+	})()`, SEARCH_RESULTS_BODY, top)
+	if err := chromedp.Run(s.ctx, chromedp.Evaluate(script, nil), chromedp.Sleep(s.Settle)); err != nil {
+		return fmt.Errorf("failed to reset results list scroll: %w", err)
+	}
+	return nil
+}
+
+// waitForPage waits for the next list page response. It reports whether one arrived.
+func (s *ResultsScroller) waitForPage() bool {
+	timer := time.NewTimer(s.PageTimeout)
+	defer timer.Stop()
+	select {
+	case <-s.pageLoaded:
+		// Give the list a moment to render the new rows
+		time.Sleep(s.Settle)
+		return true
+	case <-timer.C:
+		return false
+	case <-s.ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"tto_chromedp/pkg/api"
+	"tto_chromedp/pkg/apierror"
+	"tto_chromedp/pkg/logging"
+	"tto_chromedp/pkg/metrics"
+	"tto_chromedp/pkg/models"
+	"tto_chromedp/pkg/scheduler"
+	"tto_chromedp/pkg/utils"
+)
+
+const (
+	// DEFAULT_SERVE_ADDR serves /status, /metrics, /healthz and the API when -metrics-addr is not set.
+	DEFAULT_SERVE_ADDR = ":8080"
+
+	JOB_PENDING = "pending"
+	JOB_REFRESH = "refresh"
+)
+
+// runServe keeps the process running and crawls on a schedule until the shutdown is requested:
+//   - pending: every TTO_PENDING_POLL (default 1m), the new pending profiles, also run at startup. A
+//     failed or partial profile is left out for TTO_FAILURE_BACKOFF, doubled at each consecutive
+//     failure, and skipped after TTO_MAX_CRAWL_FAILURES
+//   - refresh: on the TTO_SCHEDULE_CRON expression in VN time (default 03:00 every day), the pending
+//     profiles plus the done ones older than TTO_REFRESH_AFTER_DAYS (default 7)
+//
+// Jobs never overlap; GET /status shows the next run of each job and the last result. The HTTP API
+// of pkg/api queues on-demand crawls of one creator on the same worker; a batch in progress lets them
+// through between two profiles. The API requires the TTO_API_TOKEN bearer token, and is served on
+// TTO_API_ADDR when set instead of next to /metrics; with neither, it is not served at all. runServe
+// fails when one of its listeners does.
+func runServe(ctx context.Context, enrich *enricher, addr string, limit int) error {
+	logger := logging.Stage(ctx, "serve")
+	ctx, stopServe := context.WithCancelCause(ctx)
+	defer stopServe(nil)
+	sched, err := scheduler.New(func(ctx context.Context, job scheduler.Job) (*models.CrawlRun, error) {
+		runID := logging.NewRunID()
+		runCheckpoint, err := enrich.newCheckpoint(ctx, runID, "serve", job.Selection)
+		if err != nil || runCheckpoint == nil {
+			return nil, err
+		}
+		run := enrich.run(logging.With(ctx, "job", job.Name), runID, "serve", runCheckpoint, false)
+		return &run, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pendingPoll := utils.GetEnvDuration("TTO_PENDING_POLL", time.Minute)
+	jobs := []scheduler.Job{
+		{
+			Name:      JOB_PENDING,
+			Spec:      fmt.Sprintf("@every %s", pendingPoll),
+			Selection: models.CrawlSelection{CreatorStatus: utils.TTO_CREATOR_STATUS_PENDING, Limit: limit},
+		},
+		{
+			Name: JOB_REFRESH,
+			Spec: utils.GetEnvString("TTO_SCHEDULE_CRON", "0 3 * * *"),
+			Selection: models.CrawlSelection{
+				CreatorStatus:    utils.TTO_CREATOR_STATUS_PENDING,
+				RefreshAfterDays: utils.GetEnvInt("TTO_REFRESH_AFTER_DAYS", 7),
+				Limit:            limit,
+			},
+		},
+	}
+	for _, job := range jobs {
+		if err := sched.Add(job); err != nil {
+			return err
+		}
+	}
+	if err := sched.Trigger(JOB_PENDING); err != nil {
+		return err
+	}
+
+	// The on-demand crawls run between two profiles of a batch, or on their own when the worker is idle
+	enrich.between = func() { sched.RunTasks(ctx) }
+	apiToken := utils.GetEnvString("TTO_API_TOKEN", "")
+	logging.RegisterSecret(apiToken)
+	apiServer := api.New(enrich.socialProfileRepo, sched.Submit,
+		func(ctx context.Context, profile models.SocialProfile) (models.CrawlRunEntry, *models.TTOUser, error) {
+			return enrich.crawlProfile(ctx, profile, apierror.NewCounter())
+		},
+		utils.GetEnvDuration("TTO_API_JOB_RETENTION", api.DEFAULT_JOB_RETENTION),
+		utils.GetEnvInt("TTO_API_MAX_QUEUED", api.DEFAULT_MAX_QUEUED),
+		apiToken,
+	)
+
+	mux := metrics.NewMux()
+	mux.Handle("/status", sched)
+	listeners := []string{addr}
+	apiAddr := utils.GetEnvString("TTO_API_ADDR", "")
+	switch {
+	case apiAddr != "":
+		apiMux := http.NewServeMux()
+		apiServer.Register(apiMux)
+		if err := serveListener(ctx, stopServe, apiAddr, apiMux); err != nil {
+			return err
+		}
+		listeners = append(listeners, apiAddr)
+	case apiToken != "":
+		apiServer.Register(mux)
+	default:
+		logger.Warn("Control API disabled: set TTO_API_TOKEN or TTO_API_ADDR to serve it")
+	}
+	if err := serveListener(ctx, stopServe, addr, mux); err != nil {
+		return err
+	}
+
+	logger.Info("Scheduler started", "addr", listeners, "api_auth", apiToken != "", "pending_poll", pendingPoll, "refresh", jobs[1].Spec)
+	sched.Run(ctx, enrich.shut.Requested())
+	if err := context.Cause(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	logger.Info("Scheduler stopped")
+	return nil
+}
+
+// serveListener serves handler on addr and stops the serve mode, through stop, when the server fails.
+func serveListener(ctx context.Context, stop context.CancelCauseFunc, addr string, handler http.Handler) error {
+	errs, err := metrics.ServeHandler(ctx, addr, handler)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err, ok := <-errs; ok {
+			stop(err)
+		}
+	}()
+	return nil
+}
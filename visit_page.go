@@ -3,96 +3,49 @@ package main
 import (
 	"context"
 	"fmt"
-	"log" // Required for profile path handling
-	"path/filepath"
 	"time"
 
-	"github.com/chromedp/cdproto/emulation" // <--- ADDED EMULATION IMPORT
-	"github.com/chromedp/cdproto/network"
+	"tto_chromedp/pkg/logging"
 
-	// <-- FIXED: Renamed import to cdpnetwork
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 )
 
-// Constants and Configuration based on user's request and best practices.
-// visitHomePage logs into the platform and saves the session state (cookies).
+// visitHomePage opens the home page on the profile and keeps the browser open for duration, so the
+// session can be refreshed or a login completed by hand. It returns early, closing the browser, when
+// ctx is cancelled.
 func visitHomePage(
-	loginURL string,
+	ctx context.Context,
+	homeURL string,
 	userAgent string,
-	profileName string, // Added profileName to function signature
+	profileName string,
 	headless bool,
-	proxy string, // Note: Proxy configuration is more complex in chromedp and often requires external tools or specific transport settings.
+	duration time.Duration,
 ) error {
-	opts := initChromedpOptions(profileName, headless, userAgent)
-
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer cancel()
-
-	// Create browser context with a timeout for the entire login process
-	taskCtx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
-	defer cancel()
-
-	// Apply context settings for realism (locale, timezone, viewport)
-	// These are typically set using the Emulation domain in CDP.
-	if err := chromedp.Run(taskCtx,
-		// 5. Set a common desktop viewport size
-		chromedp.EmulateViewport(1920, 1080),
-		// 6. Set locale/timezone for extra realism
-		emulation.SetTimezoneOverride("Asia/Ho_Chi_Minh"), // <-- FIXED
-		emulation.SetLocaleOverride(),                     // <-- FIXED
-	); err != nil {
-		return fmt.Errorf("failed to set emulation settings: %w", err)
+	logger := logging.Stage(ctx, "visit")
+	tabCtx, cancelBrowser, err := newBrowserTab(ctx, profileName, headless, userAgent)
+	if err != nil {
+		return err
 	}
+	defer cancelBrowser()
 
-	// Create a new context with a separate timeout for navigation and interaction tasks
-	interactionCtx, cancelInteraction := context.WithTimeout(taskCtx, 600*time.Second) // 120 second total timeout
-	defer cancelInteraction()
-
-	// --- 2. Define Chromedp Tasks (Login Flow) ---
-
-	var currentURL string // Variable to store the current URL for verification
-
-	err := chromedp.Run(interactionCtx,
-		// Navigate to login page
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			log.Printf("Navigating to %s", loginURL)
-			return network.SetExtraHTTPHeaders(network.Headers{
-				"Accept-Language": "vi-VN,vi;q=0.9,en-US;q=0.8,en;q=0.7", // Adjusting Accept-Language based on vi-VN locale
-			}).Do(ctx)
+	logger.Info("Navigating to home page", "url", homeURL)
+	if err := chromedp.Run(tabCtx,
+		network.SetExtraHTTPHeaders(network.Headers{
+			"Accept-Language": "vi-VN,vi;q=0.9,en-US;q=0.8,en;q=0.7", // Adjusting Accept-Language based on vi-VN locale
 		}),
-		chromedp.Navigate(loginURL),
-
-		// **IMPROVED WAIT:** Wait for the body and initial page load to ensure stability
-		// chromedp.WaitReady("body", chromedp.ByQuery),
-		chromedp.Sleep(600*time.Second),
-	)
-
-	if err != nil {
-		// Log the last known URL on error
-		if currentURL != "" {
-			log.Printf("An error occurred. Last known URL: %s", currentURL)
-		} else {
-			log.Printf("An error occurred: %s", err.Error())
-		}
-		return err
+		chromedp.Navigate(homeURL),
+	); err != nil {
+		return fmt.Errorf("failed to open %s: %w", homeURL, err)
 	}
 
+	logger.Info("Home page open, waiting", "duration", duration)
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		logger.Info("Closing the browser early", "reason", ctx.Err())
+	}
 	return nil
 }
-
-// initChromedpOptions sets up the allocator options with anti-detection flags and user data.
-func initChromedpOptions(profileName string, headless bool, userAgent string) []chromedp.ExecAllocatorOption {
-	profilePath := filepath.Join(".", "profiles", profileName)
-	fmt.Println("profilePath---------, ", profilePath)
-
-	opts := append(
-		chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.UserDataDir(profilePath),
-		chromedp.Flag("headless", false),
-		chromedp.Flag("no-first-run", true),
-		chromedp.Flag("no-default-browser-check", true),
-		chromedp.Flag("disable-extensions", false),
-		chromedp.Flag("disable-blink-features", "AutomationControlled"),
-	)
-	return opts
-}